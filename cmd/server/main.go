@@ -9,11 +9,14 @@ import (
 	"os/signal"
 	"syscall"
 	"time"
+	_ "time/tzdata" // the runtime image has no zoneinfo for event searches
 
 	"github.com/rsys-speerzad/stackgen/pkg/configs"
+	"github.com/rsys-speerzad/stackgen/pkg/notify"
 	"github.com/rsys-speerzad/stackgen/pkg/router"
 	"github.com/rsys-speerzad/stackgen/pkg/store"
 	"github.com/rsys-speerzad/stackgen/pkg/testing"
+	"github.com/rsys-speerzad/stackgen/pkg/webhooks"
 )
 
 func main() {
@@ -37,8 +40,17 @@ func Run() {
 			log.Fatalf("Failed to create test data: %v", err)
 		}
 	}
+	// deliver queued webhooks in the background
+	ctx, stopWorkers := context.WithCancel(context.Background())
+	go webhooks.NewDispatcher(store.GetDB()).Run(ctx)
+	// tell every replica's streams and caches about writes
+	changes := router.NewBus()
+	// remind participants of approaching response deadlines
+	go notify.NewService(store.GetDB(), notify.FromEnv(), changes).RunReminders(ctx)
+	// announce recommendation changes to webhooks
+	go webhooks.NewService(store.GetDB()).Watch(ctx, changes)
 	// start API server
-	server := router.NewServer()
+	server := router.NewServer(changes)
 	// gracefully close the server
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
@@ -46,6 +58,8 @@ func Run() {
 	go func() {
 		<-c
 		println()
+		log.Println("Stopping background workers...")
+		stopWorkers()
 		log.Println("Closing db connection...")
 		store.CloseDB()
 		log.Println("Shutting down server...")
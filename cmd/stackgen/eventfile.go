@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"gopkg.in/yaml.v3"
+)
+
+// eventFile is the YAML description of an event accepted by "event create -file":
+//
+//	title: Brainstorming meeting
+//	description: Ideas for Q1
+//	duration: 60            # minutes
+//	timezone: America/New_York
+//	slots:
+//	  - 2025-01-12 2pm-4pm
+//	  - 2025-01-14 18-21
+//	participants:           # user IDs; invited by email
+//	  - 0f8c6a52-3c43-4a2e-9f1e-6d0b1a7b9c11
+//	deadline: 2025-01-10 5pm
+//	fairness: true          # avoid slots outside participants' working hours
+//	buffer: 15              # minutes attendees need free before and after
+//	quorum:                 # viable if at least 5 can attend, including the leads
+//	  min: 5
+//	  required:             # user IDs
+//	    - 0f8c6a52-3c43-4a2e-9f1e-6d0b1a7b9c11
+//	  auto_finalize: true   # on the best slot that meets it, once the deadline passes
+//	sessions:               # hold it three times, e.g. a series of workshops
+//	  count: 3
+//	  distinct_days: true   # in timezone
+//	  min_gap: 60           # minutes between sessions
+//	recurrence:             # a weekly series; the time must suit every week
+//	  weeks: 8
+//	rooms:                  # resource IDs; held in the first one free
+//	  - 5d1e7f0a-8b2c-4c3d-9e4f-0a1b2c3d4e5f
+//	resources:              # resource IDs, all of which it needs
+//	  - 7a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d
+//
+// Instead of slots, a search has the server find the best time itself:
+//
+//	search:
+//	  from: mon             # first and last day
+//	  to: 2025-01-24
+//	  hours: 9-17           # in timezone
+//	  weekdays: true        # skip weekends
+type eventFile struct {
+	Title        string          `yaml:"title"`
+	Description  string          `yaml:"description"`
+	Duration     int             `yaml:"duration"`
+	Timezone     string          `yaml:"timezone"`
+	Slots        []string        `yaml:"slots"`
+	Participants []string        `yaml:"participants"`
+	Deadline     string          `yaml:"deadline"`
+	Search       *searchFile     `yaml:"search"`
+	Fairness     bool            `yaml:"fairness"`
+	Buffer       int             `yaml:"buffer"`
+	Quorum       *quorumFile     `yaml:"quorum"`
+	Sessions     *sessionsFile   `yaml:"sessions"`
+	Recurrence   *recurrenceFile `yaml:"recurrence"`
+	Rooms        []string        `yaml:"rooms"`
+	Resources    []string        `yaml:"resources"`
+}
+
+type recurrenceFile struct {
+	Weeks int `yaml:"weeks"`
+}
+
+type sessionsFile struct {
+	Count        int  `yaml:"count"`
+	DistinctDays bool `yaml:"distinct_days"`
+	MinGap       int  `yaml:"min_gap"`
+}
+
+type quorumFile struct {
+	Min          int      `yaml:"min"`
+	Required     []string `yaml:"required"`
+	AutoFinalize bool     `yaml:"auto_finalize"`
+}
+
+type searchFile struct {
+	From     string `yaml:"from"`
+	To       string `yaml:"to"`
+	Hours    string `yaml:"hours"`
+	Weekdays bool   `yaml:"weekdays"`
+}
+
+func readEventFile(path string, now time.Time, loc *time.Location) (*models.Event, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var file eventFile
+	if err := yaml.Unmarshal(data, &file); err != nil {
+		return nil, fmt.Errorf("parsing %s: %w", path, err)
+	}
+	if file.Timezone != "" {
+		if loc, err = time.LoadLocation(file.Timezone); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+	}
+	event, err := newEvent(file.Title, file.Description, file.Duration, file.Slots, now, loc)
+	if err != nil {
+		return nil, err
+	}
+	event.Fairness = file.Fairness
+	event.BufferBefore, event.BufferAfter = file.Buffer, file.Buffer
+	event.RoomIDs, event.ResourceIDs = file.Rooms, file.Resources
+	if q := file.Quorum; q != nil {
+		event.Quorum = &models.Quorum{MinAttendees: q.Min, RequiredIDs: q.Required, AutoFinalize: q.AutoFinalize}
+	}
+	if f := file.Sessions; f != nil {
+		event.Sessions = &models.Sessions{Count: f.Count, DistinctDays: f.DistinctDays, MinGap: f.MinGap}
+		if loc != time.Local {
+			event.Sessions.TimeZone = loc.String()
+		}
+	}
+	if f := file.Recurrence; f != nil {
+		event.Recurrence = &models.Recurrence{Weeks: f.Weeks}
+		if loc != time.Local {
+			event.Recurrence.TimeZone = loc.String()
+		}
+	}
+	if file.Search != nil {
+		if event.Search, err = newSearch(file.Search, now, loc); err != nil {
+			return nil, fmt.Errorf("invalid search: %w", err)
+		}
+	}
+	return event, invite(event, file.Participants, file.Deadline, now, loc)
+}
+
+func newEvent(title, description string, duration int, specs []string, now time.Time, loc *time.Location) (*models.Event, error) {
+	event := &models.Event{
+		Title:             title,
+		Description:       description,
+		EstimatedDuration: duration,
+	}
+	for _, spec := range specs {
+		slot, err := parseSlot(spec, now, loc)
+		if err != nil {
+			return nil, err
+		}
+		event.EventSlots = append(event.EventSlots, models.EventSlot{StartTime: slot.StartTime, EndTime: slot.EndTime})
+	}
+	return event, nil
+}
+
+// newSearch converts the search of an event file, whose days and hours are
+// in loc, which must be a named zone so that the server can follow it.
+func newSearch(file *searchFile, now time.Time, loc *time.Location) (*models.SlotSearch, error) {
+	if loc == time.Local {
+		return nil, fmt.Errorf("set the timezone its hours are in")
+	}
+	from, err := parseDay(strings.ToLower(file.From), now.In(loc))
+	if err != nil {
+		return nil, err
+	}
+	to, err := parseDay(strings.ToLower(file.To), now.In(loc))
+	if err != nil {
+		return nil, err
+	}
+	dayStart, dayEnd, err := parseHours(file.Hours)
+	if err != nil {
+		return nil, err
+	}
+	return &models.SlotSearch{
+		From:         from,
+		To:           to.AddDate(0, 0, 1),
+		TimeZone:     loc.String(),
+		DayStart:     dayStart,
+		DayEnd:       dayEnd,
+		WeekdaysOnly: file.Weekdays,
+	}, nil
+}
+
+// invite sets the participants of event and their response deadline, if any.
+func invite(event *models.Event, participants []string, deadline string, now time.Time, loc *time.Location) error {
+	event.ParticipantIDs = participants
+	if deadline == "" {
+		return nil
+	}
+	t, err := parseInstant(deadline, now, loc)
+	if err != nil {
+		return fmt.Errorf("invalid deadline: %w", err)
+	}
+	event.ResponseDeadline = &t
+	return nil
+}
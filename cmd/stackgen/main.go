@@ -0,0 +1,641 @@
+// Command stackgen is a command-line client for the stackgen scheduling API.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/client"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+const usage = `Usage: stackgen [-server URL] [-tz ZONE] <command> [flags] [args]
+
+Commands:
+  user create -name NAME -email EMAIL [-hours 9-17] [-buffer MIN]   working hours, in -tz
+  user show ID
+  availability add [-user ID] [-if-needed] SLOT...   e.g. "tomorrow 9-12" "fri 2pm-4pm"
+  availability list [-user ID]
+  resource create -name NAME [-kind room|equipment] [-capacity N]
+  resource show ID                        availability and bookings
+  resource available ID SLOT...           bookable only then; otherwise any time
+  event create -title T -duration MIN -slot SLOT... [-fair] [-buffer MIN] [-room ID]... [-resource ID]... | -file event.yaml
+  event show ID
+  event recommend ID
+  event heatmap [-granularity 15m] ID    who is available when, across the slots
+  event vote [-user ID] ID N yes|maybe|no   N numbers the slots as in event show
+  event finalize [-slot SLOT] ID          defaults to the recommended slot
+  event delete ID
+
+Environment:
+  STACKGEN_SERVER   default for -server (http://localhost:8080)
+  STACKGEN_USER     default for -user
+`
+
+// multiFlag collects a repeatable string flag.
+type multiFlag []string
+
+func (m *multiFlag) String() string     { return strings.Join(*m, ", ") }
+func (m *multiFlag) Set(v string) error { *m = append(*m, v); return nil }
+
+type cli struct {
+	client *client.Client
+	out    io.Writer
+	now    time.Time
+	loc    *time.Location
+}
+
+func main() {
+	if err := run(os.Args[1:], os.Stdout); err != nil {
+		fmt.Fprintln(os.Stderr, "stackgen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(args []string, out io.Writer) error {
+	global := flag.NewFlagSet("stackgen", flag.ContinueOnError)
+	global.SetOutput(out)
+	global.Usage = func() { fmt.Fprint(out, usage) }
+	server := global.String("server", envOr("STACKGEN_SERVER", "http://localhost:8080"), "server URL")
+	tz := global.String("tz", "Local", "time zone used to interpret and print slots")
+	if err := global.Parse(args); err != nil {
+		return err
+	}
+	loc, err := time.LoadLocation(*tz)
+	if err != nil {
+		return err
+	}
+	c := &cli{client: client.New(*server), out: out, now: time.Now(), loc: loc}
+	rest := global.Args()
+	if len(rest) < 2 {
+		global.Usage()
+		return errors.New("missing command")
+	}
+	ctx := context.Background()
+	switch rest[0] + " " + rest[1] {
+	case "user create":
+		return c.createUser(ctx, rest[2:])
+	case "user show":
+		return c.showUser(ctx, rest[2:])
+	case "availability add":
+		return c.addAvailability(ctx, rest[2:])
+	case "availability list":
+		return c.listAvailability(ctx, rest[2:])
+	case "resource create":
+		return c.createResource(ctx, rest[2:])
+	case "resource show":
+		return c.showResource(ctx, rest[2:])
+	case "resource available":
+		return c.addResourceAvailability(ctx, rest[2:])
+	case "event create":
+		return c.createEvent(ctx, rest[2:])
+	case "event show":
+		return c.showEvent(ctx, rest[2:])
+	case "event recommend":
+		return c.recommend(ctx, rest[2:])
+	case "event heatmap":
+		return c.heatmap(ctx, rest[2:])
+	case "event vote":
+		return c.vote(ctx, rest[2:])
+	case "event finalize":
+		return c.finalize(ctx, rest[2:])
+	case "event delete":
+		return c.deleteEvent(ctx, rest[2:])
+	}
+	global.Usage()
+	return fmt.Errorf("unknown command %q", rest[0]+" "+rest[1])
+}
+
+func (c *cli) flags(name string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ContinueOnError)
+	fs.SetOutput(c.out)
+	return fs
+}
+
+// oneArg parses fs and returns its single positional argument.
+func oneArg(fs *flag.FlagSet, args []string, what string) (string, error) {
+	if err := fs.Parse(args); err != nil {
+		return "", err
+	}
+	if fs.NArg() != 1 {
+		return "", fmt.Errorf("%s: expected exactly one %s", fs.Name(), what)
+	}
+	return fs.Arg(0), nil
+}
+
+func (c *cli) createUser(ctx context.Context, args []string) error {
+	fs := c.flags("user create")
+	name := fs.String("name", "", "user name")
+	email := fs.String("email", "", "user email")
+	hours := fs.String("hours", "", "working hours in the -tz zone, such as 9-17")
+	buffer := fs.Int("buffer", 0, "minutes to keep free before and after meetings")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	user := &models.User{Name: *name, Email: *email, BufferBefore: *buffer, BufferAfter: *buffer}
+	if *hours != "" {
+		if c.loc == time.Local {
+			return fmt.Errorf("-hours needs -tz set to the zone they are in")
+		}
+		var err error
+		if user.WorkdayStart, user.WorkdayEnd, err = parseHours(*hours); err != nil {
+			return err
+		}
+		user.TimeZone = c.loc.String()
+	}
+	user, err := c.client.CreateUser(ctx, user)
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintln(c.out, user.ID)
+	return nil
+}
+
+func (c *cli) showUser(ctx context.Context, args []string) error {
+	id, err := oneArg(c.flags("user show"), args, "user ID")
+	if err != nil {
+		return err
+	}
+	user, err := c.client.GetUser(ctx, id)
+	if err != nil {
+		return describe(err)
+	}
+	return c.printJSON(user)
+}
+
+func (c *cli) addAvailability(ctx context.Context, args []string) error {
+	fs := c.flags("availability add")
+	userID := fs.String("user", os.Getenv("STACKGEN_USER"), "user ID")
+	ifNeeded := fs.Bool("if-needed", false, "only available if no better slot is found")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		return errors.New("availability add: -user or STACKGEN_USER is required")
+	}
+	if fs.NArg() == 0 {
+		return errors.New(`availability add: expected at least one slot, e.g. "tomorrow 9-12"`)
+	}
+	var slots []models.Slot
+	for _, spec := range fs.Args() {
+		slot, err := parseSlot(spec, c.now, c.loc)
+		if err != nil {
+			return err
+		}
+		if *ifNeeded {
+			slot.Preference = models.PreferenceIfNeeded
+		}
+		slots = append(slots, slot)
+	}
+	if err := c.client.AddAvailability(ctx, *userID, slots); err != nil {
+		return describe(err)
+	}
+	return c.printSlots(slots)
+}
+
+func (c *cli) listAvailability(ctx context.Context, args []string) error {
+	fs := c.flags("availability list")
+	userID := fs.String("user", os.Getenv("STACKGEN_USER"), "user ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		return errors.New("availability list: -user or STACKGEN_USER is required")
+	}
+	slots, err := c.client.GetAvailability(ctx, *userID)
+	if err != nil {
+		return describe(err)
+	}
+	return c.printSlots(slots)
+}
+
+func (c *cli) createEvent(ctx context.Context, args []string) error {
+	fs := c.flags("event create")
+	file := fs.String("file", "", "YAML file describing the event")
+	title := fs.String("title", "", "event title")
+	description := fs.String("description", "", "event description")
+	duration := fs.Int("duration", 60, "estimated duration in minutes")
+	var slots multiFlag
+	fs.Var(&slots, "slot", `proposed slot such as "2025-01-12 2pm-4pm" (repeatable)`)
+	var participants multiFlag
+	fs.Var(&participants, "participant", "ID of a user to invite (repeatable)")
+	deadline := fs.String("deadline", "", `response deadline such as "2025-01-10 5pm"`)
+	fair := fs.Bool("fair", false, "avoid slots outside participants' working hours")
+	buffer := fs.Int("buffer", 0, "minutes attendees need free before and after the event")
+	var rooms multiFlag
+	fs.Var(&rooms, "room", "ID of a room the event can be held in, by preference (repeatable)")
+	var resources multiFlag
+	fs.Var(&resources, "resource", "ID of a resource the event needs (repeatable)")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	var event *models.Event
+	var err error
+	if *file != "" {
+		event, err = readEventFile(*file, c.now, c.loc)
+	} else if event, err = newEvent(*title, *description, *duration, slots, c.now, c.loc); err == nil {
+		event.Fairness = *fair
+		event.BufferBefore, event.BufferAfter = *buffer, *buffer
+		event.RoomIDs, event.ResourceIDs = []string(rooms), []string(resources)
+		err = invite(event, participants, *deadline, c.now, c.loc)
+	}
+	if err != nil {
+		return err
+	}
+	created, err := c.client.CreateEvent(ctx, event)
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintln(c.out, created.ID)
+	return nil
+}
+
+func (c *cli) createResource(ctx context.Context, args []string) error {
+	fs := c.flags("resource create")
+	name := fs.String("name", "", "resource name")
+	kind := fs.String("kind", models.ResourceRoom, "room or equipment")
+	capacity := fs.Int("capacity", 0, "seats of a room")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	resource, err := c.client.CreateResource(ctx, &models.Resource{Name: *name, Kind: *kind, Capacity: *capacity})
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintln(c.out, resource.ID)
+	return nil
+}
+
+func (c *cli) showResource(ctx context.Context, args []string) error {
+	id, err := oneArg(c.flags("resource show"), args, "resource ID")
+	if err != nil {
+		return err
+	}
+	resource, err := c.client.GetResource(ctx, id)
+	if err != nil {
+		return describe(err)
+	}
+	schedule, err := c.client.GetResourceSchedule(ctx, id)
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintf(c.out, "%s (%s", resource.Name, resource.Kind)
+	if resource.Capacity > 0 {
+		fmt.Fprintf(c.out, ", %d seats", resource.Capacity)
+	}
+	fmt.Fprintln(c.out, ")")
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tSTATUS")
+	if len(schedule.Availability) == 0 {
+		fmt.Fprintln(w, "any time\tavailable")
+	}
+	for _, slot := range schedule.Availability {
+		fmt.Fprintf(w, "%s\tavailable\n", c.formatRange(slot.StartTime, slot.EndTime))
+	}
+	for _, booking := range schedule.Bookings {
+		fmt.Fprintf(w, "%s\tbooked by %s\n", c.formatRange(booking.StartTime, booking.EndTime), booking.EventID)
+	}
+	return w.Flush()
+}
+
+func (c *cli) addResourceAvailability(ctx context.Context, args []string) error {
+	fs := c.flags("resource available")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() < 2 {
+		return errors.New(`resource available: expected a resource ID and at least one slot, e.g. "mon 9-17"`)
+	}
+	var periods []models.Period
+	var slots []models.Slot
+	for _, spec := range fs.Args()[1:] {
+		slot, err := parseSlot(spec, c.now, c.loc)
+		if err != nil {
+			return err
+		}
+		periods = append(periods, models.Period{StartTime: slot.StartTime, EndTime: slot.EndTime})
+		slots = append(slots, slot)
+	}
+	if err := c.client.AddResourceAvailability(ctx, fs.Arg(0), periods); err != nil {
+		return describe(err)
+	}
+	return c.printSlots(slots)
+}
+
+func (c *cli) showEvent(ctx context.Context, args []string) error {
+	id, err := oneArg(c.flags("event show"), args, "event ID")
+	if err != nil {
+		return err
+	}
+	event, err := c.client.GetEvent(ctx, id)
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintf(c.out, "%s (%d min)\n", event.Title, event.EstimatedDuration)
+	if event.Description != "" {
+		fmt.Fprintln(c.out, event.Description)
+	}
+	if event.IsFinalized() {
+		fmt.Fprintf(c.out, "Finalized: %s\n", c.formatRange(*event.FinalStartTime, *event.FinalEndTime))
+	}
+	if search := event.Search; search != nil {
+		days := ""
+		if search.WeekdaysOnly {
+			days = " on weekdays"
+		}
+		fmt.Fprintf(c.out, "Searching %s, %s to %s %s%s\n", c.formatRange(search.From, search.To),
+			search.DayStart, search.DayEnd, search.TimeZone, days)
+		return nil
+	}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "#\tSTART\tEND")
+	for i, slot := range eventSlots(event) {
+		fmt.Fprintf(w, "%d\t%s\t%s\n", i+1, slot.StartTime.In(c.loc).Format(timeLayout), slot.EndTime.In(c.loc).Format(timeLayout))
+	}
+	return w.Flush()
+}
+
+// eventSlots returns the proposed slots of event by start time, the order
+// they are numbered in.
+func eventSlots(event *models.Event) []models.EventSlot {
+	slots := slices.Clone(event.EventSlots)
+	slices.SortStableFunc(slots, func(a, b models.EventSlot) int {
+		return a.StartTime.Compare(b.StartTime)
+	})
+	return slots
+}
+
+func (c *cli) vote(ctx context.Context, args []string) error {
+	fs := c.flags("event vote")
+	userID := fs.String("user", os.Getenv("STACKGEN_USER"), "user ID")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *userID == "" {
+		return errors.New("event vote: -user or STACKGEN_USER is required")
+	}
+	if fs.NArg() != 3 {
+		return errors.New("event vote: expected an event ID, a slot number and yes, maybe or no")
+	}
+	user, err := uuid.Parse(*userID)
+	if err != nil {
+		return fmt.Errorf("event vote: invalid user ID %q", *userID)
+	}
+	event, err := c.client.GetEvent(ctx, fs.Arg(0))
+	if err != nil {
+		return describe(err)
+	}
+	slots := eventSlots(event)
+	n, err := strconv.Atoi(fs.Arg(1))
+	if err != nil || n < 1 || n > len(slots) {
+		return fmt.Errorf("event vote: slot must be a number from 1 to %d, as listed by event show", len(slots))
+	}
+	slot := slots[n-1]
+	vote, err := c.client.Vote(ctx, event.ID.String(), slot.ID.String(), &models.SlotVote{UserID: user, Vote: fs.Arg(2)})
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintf(c.out, "Voted %s for %s\n", vote.Vote, c.formatRange(slot.StartTime, slot.EndTime))
+	return nil
+}
+
+func (c *cli) recommend(ctx context.Context, args []string) error {
+	id, err := oneArg(c.flags("event recommend"), args, "event ID")
+	if err != nil {
+		return err
+	}
+	rec, err := c.client.GetRecommendations(ctx, id)
+	if err != nil {
+		return describe(err)
+	}
+	cache := map[string]string{}
+	names := func(ids []string) string { return joinOrDash(c.userNames(ctx, ids, cache)) }
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	if len(rec.Candidates) > 0 {
+		fmt.Fprintln(w, "SLOT\tPAIN\tLOCAL TIMES\tMISSING\tQUORUM")
+		for _, candidate := range rec.Candidates {
+			var local []string
+			for _, attendee := range candidate.Attendees {
+				local = append(local, fmt.Sprintf("%s %s", names([]string{attendee.UserID}), attendee.StartTime.Format("15:04")))
+			}
+			fmt.Fprintf(w, "%s\t%d min\t%s\t%s\t%s\n", c.formatRange(candidate.StartTime, candidate.EndTime), candidate.PainScore,
+				strings.Join(local, ", "), names(candidate.MissingUserIDs), quorumStatus(candidate.MeetsQuorum))
+		}
+		return w.Flush()
+	}
+	if len(rec.Occurrences) > 0 {
+		fmt.Fprintf(w, "Every week: %s\n", names(rec.UserIDs))
+		fmt.Fprintln(w, "WEEK\tSLOT\tATTENDING\tCONFLICTS")
+		for i, occurrence := range rec.Occurrences {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, c.formatRange(occurrence.StartTime, occurrence.EndTime),
+				names(occurrence.UserIDs), names(occurrence.MissingUserIDs))
+		}
+		return w.Flush()
+	}
+	if len(rec.Sessions) > 0 {
+		fmt.Fprintln(w, "SESSION\tSLOT\tATTENDING\tMISSING")
+		for i, session := range rec.Sessions {
+			fmt.Fprintf(w, "%d\t%s\t%s\t%s\n", i+1, c.formatRange(session.StartTime, session.EndTime),
+				names(session.UserIDs), names(session.MissingUserIDs))
+		}
+		return w.Flush()
+	}
+	fmt.Fprintln(w, "SLOT\tATTENDING\tMISSING\tROOM")
+	fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", c.formatRange(rec.StartTime, rec.EndTime), names(rec.UserIDs), names(rec.MissingUserIDs),
+		orDash(rec.RoomID))
+	if err := w.Flush(); err != nil {
+		return err
+	}
+	for _, missing := range rec.Missing {
+		fmt.Fprintf(c.out, "%s: %s\n", names([]string{missing.UserID}), reason(missing))
+	}
+	return nil
+}
+
+// reason describes why a user can't attend.
+func reason(missing models.MissingUser) string {
+	switch missing.Reason {
+	case models.ReasonDeclined:
+		return "declined"
+	case models.ReasonConflict:
+		return fmt.Sprintf("conflicts with %q (%s)", missing.EventTitle, missing.EventID)
+	case models.ReasonNoAvailability:
+		return "hasn't shared any availability"
+	case models.ReasonPartial:
+		return fmt.Sprintf("available for only %d min", missing.OverlapMinutes)
+	case models.ReasonOutsideHours:
+		return "outside working hours"
+	case models.ReasonUnavailable:
+		return "not available"
+	}
+	return missing.Reason
+}
+
+func (c *cli) heatmap(ctx context.Context, args []string) error {
+	fs := c.flags("event heatmap")
+	granularity := fs.Duration("granularity", 15*time.Minute, "size of a bucket")
+	id, err := oneArg(fs, args, "event ID")
+	if err != nil {
+		return err
+	}
+	heatmap, err := c.client.GetHeatmap(ctx, id, *granularity)
+	if err != nil {
+		return describe(err)
+	}
+	names := map[string]string{}
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "SLOT\tAVAILABLE\t\tUSERS")
+	for _, bucket := range heatmap.Buckets {
+		bar := strings.Repeat("#", bucket.Count) + strings.Repeat(".", max(heatmap.Participants-bucket.Count, 0))
+		fmt.Fprintf(w, "%s\t%d/%d\t%s\t%s\n", c.formatRange(bucket.StartTime, bucket.EndTime), bucket.Count, heatmap.Participants,
+			bar, joinOrDash(c.userNames(ctx, bucket.UserIDs, names)))
+	}
+	return w.Flush()
+}
+
+// userNames returns the names of the users with the given IDs, looking up
+// those not yet in names. Users that can't be looked up keep their ID.
+func (c *cli) userNames(ctx context.Context, ids []string, names map[string]string) []string {
+	var out []string
+	for _, id := range ids {
+		if _, ok := names[id]; !ok {
+			names[id] = id
+			if user, err := c.client.GetUser(ctx, id); err == nil {
+				names[id] = user.Name
+			}
+		}
+		out = append(out, names[id])
+	}
+	return out
+}
+
+// quorumStatus describes whether a slot meets the quorum, if the event has one.
+func quorumStatus(meets *bool) string {
+	switch {
+	case meets == nil:
+		return "-"
+	case *meets:
+		return "met"
+	}
+	return "not met"
+}
+
+func (c *cli) finalize(ctx context.Context, args []string) error {
+	fs := c.flags("event finalize")
+	spec := fs.String("slot", "", "final slot; defaults to the recommended slot")
+	id, err := oneArg(fs, args, "event ID")
+	if err != nil {
+		return err
+	}
+	var slot models.Slot
+	if *spec != "" {
+		if slot, err = parseSlot(*spec, c.now, c.loc); err != nil {
+			return err
+		}
+	} else {
+		event, err := c.client.GetEvent(ctx, id)
+		if err != nil {
+			return describe(err)
+		}
+		rec, err := c.client.GetRecommendations(ctx, id)
+		if err != nil {
+			return describe(err)
+		}
+		if rec.StartTime.IsZero() {
+			return errors.New("event finalize: no recommended slot, pass -slot")
+		}
+		// the recommended slot may be longer than the meeting itself
+		slot = models.Slot{StartTime: rec.StartTime, EndTime: rec.StartTime.Add(time.Duration(event.EstimatedDuration) * time.Minute)}
+	}
+	event, err := c.client.FinalizeEvent(ctx, id, slot)
+	if err != nil {
+		return describe(err)
+	}
+	fmt.Fprintf(c.out, "%s finalized for %s\n", event.Title, c.formatRange(*event.FinalStartTime, *event.FinalEndTime))
+	if event.RoomID != nil {
+		fmt.Fprintf(c.out, "Booked room %s\n", event.RoomID)
+	}
+	return nil
+}
+
+func (c *cli) deleteEvent(ctx context.Context, args []string) error {
+	id, err := oneArg(c.flags("event delete"), args, "event ID")
+	if err != nil {
+		return err
+	}
+	return describe(c.client.DeleteEvent(ctx, id))
+}
+
+func (c *cli) printSlots(slots []models.Slot) error {
+	w := tabwriter.NewWriter(c.out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "START\tEND\tPREFERENCE")
+	for _, slot := range slots {
+		preference := slot.Preference
+		if preference == "" {
+			preference = models.PreferenceYes
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\n", slot.StartTime.In(c.loc).Format(timeLayout), slot.EndTime.In(c.loc).Format(timeLayout), preference)
+	}
+	return w.Flush()
+}
+
+func (c *cli) printJSON(v interface{}) error {
+	encoder := json.NewEncoder(c.out)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(v)
+}
+
+const timeLayout = "Mon 2006-01-02 15:04 MST"
+
+func (c *cli) formatRange(start, end time.Time) string {
+	start, end = start.In(c.loc), end.In(c.loc)
+	if start.YearDay() == end.YearDay() && start.Year() == end.Year() {
+		return start.Format(timeLayout) + " - " + end.Format("15:04")
+	}
+	return start.Format(timeLayout) + " - " + end.Format(timeLayout)
+}
+
+// describe expands validation errors returned by the server into one line per field.
+func describe(err error) error {
+	var apiErr *client.Error
+	if !errors.As(err, &apiErr) || len(apiErr.FieldErrors()) == 0 {
+		return err
+	}
+	msgs := []string{apiErr.Problem.Title}
+	for _, field := range apiErr.FieldErrors() {
+		msgs = append(msgs, fmt.Sprintf("  %s: %s", field.Field, field.Message))
+	}
+	return errors.New(strings.Join(msgs, "\n"))
+}
+
+func joinOrDash(values []string) string {
+	if len(values) == 0 {
+		return "-"
+	}
+	return strings.Join(values, ", ")
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
+
+func envOr(key, fallback string) string {
+	if value := os.Getenv(key); value != "" {
+		return value
+	}
+	return fallback
+}
@@ -0,0 +1,178 @@
+package main
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+var weekdays = map[string]time.Weekday{
+	"sun": time.Sunday, "sunday": time.Sunday,
+	"mon": time.Monday, "monday": time.Monday,
+	"tue": time.Tuesday, "tuesday": time.Tuesday,
+	"wed": time.Wednesday, "wednesday": time.Wednesday,
+	"thu": time.Thursday, "thursday": time.Thursday,
+	"fri": time.Friday, "friday": time.Friday,
+	"sat": time.Saturday, "saturday": time.Saturday,
+}
+
+// parseSlot parses a human friendly slot relative to now, in loc. Accepted
+// forms are "<day> <from>-<to>" and "<RFC3339>/<RFC3339>", where day is
+// today, tomorrow, a weekday name (its next occurrence, including today) or a
+// YYYY-MM-DD date, and from/to are hours such as 9, 9:30, 14 or 2pm:
+//
+//	tomorrow 9-12
+//	fri 2pm-4:30pm
+//	2025-01-12 14-16
+func parseSlot(spec string, now time.Time, loc *time.Location) (models.Slot, error) {
+	spec = strings.TrimSpace(spec)
+	if from, to, ok := strings.Cut(spec, "/"); ok {
+		return parseRFC3339Slot(from, to)
+	}
+	dayPart, rangePart, ok := strings.Cut(spec, " ")
+	if !ok {
+		return models.Slot{}, fmt.Errorf("invalid slot %q: expected \"<day> <from>-<to>\"", spec)
+	}
+	day, err := parseDay(strings.ToLower(dayPart), now.In(loc))
+	if err != nil {
+		return models.Slot{}, fmt.Errorf("invalid slot %q: %w", spec, err)
+	}
+	fromPart, toPart, ok := strings.Cut(strings.ReplaceAll(strings.ToLower(rangePart), " ", ""), "-")
+	if !ok {
+		return models.Slot{}, fmt.Errorf("invalid slot %q: expected a time range such as 9-12", spec)
+	}
+	to, toSuffix, err := parseClock(toPart, "")
+	if err != nil {
+		return models.Slot{}, fmt.Errorf("invalid slot %q: %w", spec, err)
+	}
+	// "2-4pm" means 2pm to 4pm, but "11-1pm" means 11am to 1pm
+	from, _, err := parseClock(fromPart, toSuffix)
+	if err != nil {
+		return models.Slot{}, fmt.Errorf("invalid slot %q: %w", spec, err)
+	}
+	if from >= to && toSuffix == "pm" {
+		from, _, _ = parseClock(fromPart, "am")
+	}
+	if from >= to {
+		return models.Slot{}, fmt.Errorf("invalid slot %q: end must be after start", spec)
+	}
+	return models.Slot{StartTime: onDay(day, from), EndTime: onDay(day, to)}, nil
+}
+
+func parseRFC3339Slot(from, to string) (models.Slot, error) {
+	start, err := time.Parse(time.RFC3339, strings.TrimSpace(from))
+	if err != nil {
+		return models.Slot{}, fmt.Errorf("invalid slot start: %w", err)
+	}
+	end, err := time.Parse(time.RFC3339, strings.TrimSpace(to))
+	if err != nil {
+		return models.Slot{}, fmt.Errorf("invalid slot end: %w", err)
+	}
+	if !end.After(start) {
+		return models.Slot{}, fmt.Errorf("invalid slot: end must be after start")
+	}
+	return models.Slot{StartTime: start, EndTime: end}, nil
+}
+
+// parseInstant parses a point in time relative to now, in loc: either
+// "<day> <time>" with the same day and time forms as parseSlot, or RFC3339.
+func parseInstant(spec string, now time.Time, loc *time.Location) (time.Time, error) {
+	spec = strings.TrimSpace(spec)
+	if t, err := time.Parse(time.RFC3339, spec); err == nil {
+		return t, nil
+	}
+	dayPart, clockPart, ok := strings.Cut(spec, " ")
+	if !ok {
+		return time.Time{}, fmt.Errorf("invalid time %q: expected \"<day> <time>\"", spec)
+	}
+	day, err := parseDay(strings.ToLower(dayPart), now.In(loc))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", spec, err)
+	}
+	clock, _, err := parseClock(strings.ToLower(strings.ReplaceAll(clockPart, " ", "")), "")
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time %q: %w", spec, err)
+	}
+	return onDay(day, clock), nil
+}
+
+// onDay returns the wall clock time clock on day, which is not clock after
+// midnight on days that daylight saving time starts or ends.
+func onDay(day time.Time, clock time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), int(clock/time.Hour), int(clock%time.Hour/time.Minute), 0, 0, day.Location())
+}
+
+// parseHours parses daily hours in the same forms as the range of parseSlot,
+// such as 9-17 or 8:30am-4pm, into times of day such as 09:00 and 17:00.
+func parseHours(spec string) (start, end string, err error) {
+	slot, err := parseSlot("2000-01-01 "+spec, time.Time{}, time.UTC)
+	if err != nil {
+		return "", "", fmt.Errorf("invalid hours %q: expected a time range such as 9-17", spec)
+	}
+	end = slot.EndTime.Format("15:04")
+	if slot.EndTime.Day() != slot.StartTime.Day() {
+		end = "24:00"
+	}
+	return slot.StartTime.Format("15:04"), end, nil
+}
+
+// parseDay returns midnight of the named day in now's location.
+func parseDay(day string, now time.Time) (time.Time, error) {
+	midnight := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	switch day {
+	case "today":
+		return midnight, nil
+	case "tomorrow":
+		return midnight.AddDate(0, 0, 1), nil
+	}
+	if weekday, ok := weekdays[day]; ok {
+		offset := (int(weekday) - int(now.Weekday()) + 7) % 7
+		return midnight.AddDate(0, 0, offset), nil
+	}
+	date, err := time.ParseInLocation("2006-01-02", day, now.Location())
+	if err != nil {
+		return time.Time{}, fmt.Errorf("unknown day %q", day)
+	}
+	return date, nil
+}
+
+// parseClock parses 9, 9:30, 14, 2pm or 2:30pm into an offset from midnight.
+// defaultSuffix applies when the value has no am/pm suffix of its own; the
+// suffix actually used is returned.
+func parseClock(value, defaultSuffix string) (time.Duration, string, error) {
+	suffix := defaultSuffix
+	for _, s := range []string{"am", "pm"} {
+		if strings.HasSuffix(value, s) {
+			value, suffix = strings.TrimSuffix(value, s), s
+		}
+	}
+	hourPart, minutePart, hasMinutes := strings.Cut(value, ":")
+	hour, err := strconv.Atoi(hourPart)
+	if err != nil {
+		return 0, "", fmt.Errorf("invalid time %q", value)
+	}
+	minute := 0
+	if hasMinutes {
+		if minute, err = strconv.Atoi(minutePart); err != nil || minute < 0 || minute > 59 {
+			return 0, "", fmt.Errorf("invalid time %q", value)
+		}
+	}
+	switch suffix {
+	case "am", "pm":
+		if hour < 1 || hour > 12 {
+			return 0, "", fmt.Errorf("invalid time %q%s", value, suffix)
+		}
+		hour %= 12
+		if suffix == "pm" {
+			hour += 12
+		}
+	default:
+		if hour < 0 || hour > 24 || (hour == 24 && minute > 0) {
+			return 0, "", fmt.Errorf("invalid time %q", value)
+		}
+	}
+	return time.Duration(hour)*time.Hour + time.Duration(minute)*time.Minute, suffix, nil
+}
@@ -0,0 +1,245 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseSlot(t *testing.T) {
+	est, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	// a Wednesday
+	now := time.Date(2025, 1, 8, 10, 30, 0, 0, est)
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2025, 1, day, hour, minute, 0, 0, est)
+	}
+	tests := []struct {
+		spec       string
+		start, end time.Time
+	}{
+		{"today 14-16", at(8, 14, 0), at(8, 16, 0)},
+		{"tomorrow 9-12", at(9, 9, 0), at(9, 12, 0)},
+		{"Tomorrow 9:30 - 11", at(9, 9, 30), at(9, 11, 0)},
+		{"fri 2pm-4:30pm", at(10, 14, 0), at(10, 16, 30)},
+		{"friday 2-4pm", at(10, 14, 0), at(10, 16, 0)},
+		{"wed 11-1pm", at(8, 11, 0), at(8, 13, 0)},
+		{"mon 12am-1am", at(13, 0, 0), at(13, 1, 0)},
+		{"2025-01-12 18-24", at(12, 18, 0), at(13, 0, 0)},
+		{"2025-01-12T19:00:00Z/2025-01-12T21:00:00Z", time.Date(2025, 1, 12, 19, 0, 0, 0, time.UTC), time.Date(2025, 1, 12, 21, 0, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			slot, err := parseSlot(tt.spec, now, est)
+			require.NoError(t, err)
+			assert.True(t, tt.start.Equal(slot.StartTime), "start: want %s, got %s", tt.start, slot.StartTime)
+			assert.True(t, tt.end.Equal(slot.EndTime), "end: want %s, got %s", tt.end, slot.EndTime)
+		})
+	}
+}
+
+func TestParseSlot_Invalid(t *testing.T) {
+	now := time.Date(2025, 1, 8, 10, 30, 0, 0, time.UTC)
+	for _, spec := range []string{
+		"tomorrow",
+		"someday 9-12",
+		"tomorrow 9",
+		"tomorrow 12-9",
+		"tomorrow 5pm-4pm",
+		"tomorrow 13pm-14pm",
+		"tomorrow 9:75-10",
+		"2025-01-12T19:00:00Z/2025-01-12T18:00:00Z",
+	} {
+		_, err := parseSlot(spec, now, time.UTC)
+		assert.Error(t, err, spec)
+	}
+}
+
+func TestReadEventFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+title: Brainstorming meeting
+duration: 60
+timezone: America/New_York
+slots:
+  - 2025-01-12 2pm-4pm
+  - 2025-01-14 18-21
+participants:
+  - 0f8c6a52-3c43-4a2e-9f1e-6d0b1a7b9c11
+deadline: 2025-01-10 5pm
+`), 0o600))
+
+	event, err := readEventFile(path, time.Now(), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, "Brainstorming meeting", event.Title)
+	assert.Equal(t, 60, event.EstimatedDuration)
+	require.Len(t, event.EventSlots, 2)
+	assert.True(t, time.Date(2025, 1, 12, 19, 0, 0, 0, time.UTC).Equal(event.EventSlots[0].StartTime))
+	assert.True(t, time.Date(2025, 1, 15, 2, 0, 0, 0, time.UTC).Equal(event.EventSlots[1].EndTime))
+	assert.Equal(t, []string{"0f8c6a52-3c43-4a2e-9f1e-6d0b1a7b9c11"}, []string(event.ParticipantIDs))
+	require.NotNil(t, event.ResponseDeadline)
+	assert.True(t, time.Date(2025, 1, 10, 22, 0, 0, 0, time.UTC).Equal(*event.ResponseDeadline))
+}
+
+func TestReadEventFile_Search(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+title: Planning
+duration: 45
+timezone: Europe/Berlin
+search:
+  from: mon
+  to: 2025-01-24
+  hours: 9-24
+  weekdays: true
+`), 0o600))
+
+	now := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC) // a Wednesday
+	event, err := readEventFile(path, now, time.UTC)
+	require.NoError(t, err)
+	require.NotNil(t, event.Search)
+	assert.Empty(t, event.EventSlots)
+	assert.True(t, time.Date(2025, 1, 12, 23, 0, 0, 0, time.UTC).Equal(event.Search.From))
+	assert.True(t, time.Date(2025, 1, 24, 23, 0, 0, 0, time.UTC).Equal(event.Search.To))
+	assert.Equal(t, "Europe/Berlin", event.Search.TimeZone)
+	assert.Equal(t, "09:00", event.Search.DayStart)
+	assert.Equal(t, "24:00", event.Search.DayEnd)
+	assert.True(t, event.Search.WeekdaysOnly)
+
+	_, err = newSearch(&searchFile{From: "mon", To: "fri", Hours: "9-17"}, now, time.Local)
+	assert.Error(t, err, "the local zone has no name the server understands")
+}
+
+func TestReadEventFile_Quorum(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+title: Planning
+duration: 45
+slots:
+  - 2025-01-12 2pm-4pm
+quorum:
+  min: 5
+  required:
+    - 0f8c6a52-3c43-4a2e-9f1e-6d0b1a7b9c11
+  auto_finalize: true
+`), 0o600))
+
+	event, err := readEventFile(path, time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, &models.Quorum{
+		MinAttendees: 5,
+		RequiredIDs:  []string{"0f8c6a52-3c43-4a2e-9f1e-6d0b1a7b9c11"},
+		AutoFinalize: true,
+	}, event.Quorum)
+}
+
+func TestReadEventFile_Sessions(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+title: Workshops
+duration: 60
+timezone: Europe/Berlin
+slots:
+  - 2025-01-13 9-17
+  - 2025-01-14 9-17
+sessions:
+  count: 2
+  distinct_days: true
+  min_gap: 30
+`), 0o600))
+
+	event, err := readEventFile(path, time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, &models.Sessions{Count: 2, DistinctDays: true, MinGap: 30, TimeZone: "Europe/Berlin"}, event.Sessions)
+}
+
+func TestReadEventFile_Recurrence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+title: Weekly sync
+duration: 30
+timezone: Europe/Berlin
+slots:
+  - 2025-01-13 9-10
+  - 2025-01-14 9-10
+recurrence:
+  weeks: 8
+`), 0o600))
+
+	event, err := readEventFile(path, time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, &models.Recurrence{Weeks: 8, TimeZone: "Europe/Berlin"}, event.Recurrence)
+}
+
+func TestReadEventFile_Resources(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "event.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+title: Demo
+duration: 60
+slots:
+  - 2025-01-13 9-17
+rooms:
+  - 5d1e7f0a-8b2c-4c3d-9e4f-0a1b2c3d4e5f
+  - 6e2f8a1b-9c3d-4d4e-8f5a-1b2c3d4e5f6a
+resources:
+  - 7a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d
+`), 0o600))
+
+	event, err := readEventFile(path, time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC), time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, pq.StringArray{"5d1e7f0a-8b2c-4c3d-9e4f-0a1b2c3d4e5f", "6e2f8a1b-9c3d-4d4e-8f5a-1b2c3d4e5f6a"}, event.RoomIDs)
+	assert.Equal(t, pq.StringArray{"7a2b3c4d-5e6f-4a7b-8c9d-0e1f2a3b4c5d"}, event.ResourceIDs)
+}
+
+func TestParseSlot_DaylightSavingTime(t *testing.T) {
+	est, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	now := time.Date(2025, 3, 8, 10, 0, 0, 0, est) // the Saturday before clocks go forward
+	at := func(month time.Month, day, hour int) time.Time {
+		return time.Date(2025, month, day, hour, 0, 0, 0, est)
+	}
+	for spec, want := range map[string][2]time.Time{
+		"2025-03-09 9-12": {at(3, 9, 9), at(3, 9, 12)},   // 23 hours long
+		"2025-11-02 9-12": {at(11, 2, 9), at(11, 2, 12)}, // 25 hours long
+	} {
+		slot, err := parseSlot(spec, now, est)
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, [2]time.Time{slot.StartTime, slot.EndTime}, spec)
+	}
+	got, err := parseInstant("sun 5pm", now, est)
+	require.NoError(t, err)
+	assert.Equal(t, at(3, 9, 17), got)
+}
+
+func TestParseInstant(t *testing.T) {
+	now := time.Date(2025, 1, 8, 10, 0, 0, 0, time.UTC) // a Wednesday
+	got, err := parseInstant("fri 5pm", now, time.UTC)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2025, 1, 10, 17, 0, 0, 0, time.UTC), got)
+	got, err = parseInstant("2025-01-10T09:30:00+01:00", now, time.UTC)
+	require.NoError(t, err)
+	assert.True(t, time.Date(2025, 1, 10, 8, 30, 0, 0, time.UTC).Equal(got))
+	_, err = parseInstant("soon", now, time.UTC)
+	assert.Error(t, err)
+}
+
+func TestParseHours(t *testing.T) {
+	for spec, want := range map[string][2]string{
+		"9-17":         {"09:00", "17:00"},
+		"8:30am-4pm":   {"08:30", "16:00"},
+		"20-24":        {"20:00", "24:00"},
+		"10pm-11:30pm": {"22:00", "23:30"},
+	} {
+		start, end, err := parseHours(spec)
+		require.NoError(t, err, spec)
+		assert.Equal(t, want, [2]string{start, end}, spec)
+	}
+	_, _, err := parseHours("17-9")
+	assert.Error(t, err)
+}
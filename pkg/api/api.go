@@ -35,33 +35,24 @@ func Success(w http.ResponseWriter, r *http.Request, jsonMsg []byte) {
 	)
 }
 
+// Error writes err as an application/problem+json response. When code is 0
+// the status is derived from the error taxonomy in models.
 func Error(w http.ResponseWriter, r *http.Request, err error, code int) {
-	if code == 0 {
-		code = toHTTPStatusCode(err)
-	}
-	w.Header().Set("Content-Type", "application/json")
-	w.Header().Set("Access-Control-Allow-Origin", "*")
-	w.WriteHeader(code)
 	if err == nil {
 		err = fmt.Errorf("nil err")
 	}
-	logErr := err
-	errorMsgJSON, err := json.Marshal(models.ErrorResponse{Message: err.Error()})
-	if err != nil {
-		log.Println(err)
-	} else {
-		if _, err = w.Write(errorMsgJSON); err != nil {
-			log.Printf("Error writing response: %v", err)
-		}
-	}
+	problem := toProblem(err, code)
+	problem.Instance = r.URL.Path
+	w.Header().Set("Access-Control-Allow-Origin", "*")
+	writeProblem(w, problem)
 
 	log.Printf(
 		"%s %s %s %d %s",
 		r.Method,
 		r.RequestURI,
 		r.RemoteAddr,
-		code,
-		logErr.Error(),
+		problem.Status,
+		err.Error(),
 	)
 }
 
@@ -71,13 +62,76 @@ func toHTTPStatusCode(err error) int {
 		return http.StatusBadRequest
 	case errors.Is(err, models.ErrInvalidMessageType):
 		return http.StatusBadRequest
+	case errors.Is(err, models.ErrInvalidArgument):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrInvalidPayload):
+		return http.StatusBadRequest
+	case errors.Is(err, models.ErrValidation):
+		return http.StatusUnprocessableEntity
 	case errors.Is(err, models.ErrNotFound):
 		return http.StatusNotFound
+	case errors.Is(err, models.ErrConflict):
+		return http.StatusConflict
+	case errors.Is(err, models.ErrUnauthorized):
+		return http.StatusUnauthorized
+	case errors.Is(err, models.ErrForbidden):
+		return http.StatusForbidden
 	default:
 		return http.StatusInternalServerError
 	}
 }
 
+// toProblem maps err onto a problem document. A non-zero code overrides the
+// status derived from the error.
+func toProblem(err error, code int) models.Problem {
+	if code == 0 {
+		code = toHTTPStatusCode(err)
+	}
+	problem := models.Problem{
+		Type:   problemType(code),
+		Title:  http.StatusText(code),
+		Status: code,
+		Detail: err.Error(),
+	}
+	var verr *models.ValidationError
+	if errors.As(err, &verr) {
+		problem.Errors = verr.Fields
+	}
+	return problem
+}
+
+func problemType(code int) string {
+	switch code {
+	case http.StatusBadRequest:
+		return "/problems/bad-request"
+	case http.StatusUnprocessableEntity:
+		return "/problems/validation"
+	case http.StatusNotFound:
+		return "/problems/not-found"
+	case http.StatusConflict:
+		return "/problems/conflict"
+	case http.StatusUnauthorized:
+		return "/problems/unauthorized"
+	case http.StatusForbidden:
+		return "/problems/forbidden"
+	default:
+		return "about:blank"
+	}
+}
+
+func writeProblem(w http.ResponseWriter, problem models.Problem) {
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(problem.Status)
+	jsonMsg, err := json.Marshal(problem)
+	if err != nil {
+		log.Println(err)
+		return
+	}
+	if _, err = w.Write(jsonMsg); err != nil {
+		log.Printf("Error writing response: %v", err)
+	}
+}
+
 func ResponseWriter(w http.ResponseWriter, data interface{}, statusCode int) {
 	if statusCode == 0 {
 		statusCode = http.StatusOK
@@ -88,7 +142,9 @@ func ResponseWriter(w http.ResponseWriter, data interface{}, statusCode int) {
 			w.WriteHeader(statusCode)
 			w.Write(jsonMsg)
 		} else {
-			http.Error(w, err.Error(), http.StatusInternalServerError)
+			writeProblem(w, toProblem(err, http.StatusInternalServerError))
 		}
+		return
 	}
+	w.WriteHeader(statusCode)
 }
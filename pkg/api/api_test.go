@@ -1,7 +1,9 @@
 package api
 
 import (
+	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
@@ -93,6 +95,37 @@ func TestError_DefaultStatusCode(t *testing.T) {
 	}
 }
 
+func TestError_ProblemJSON(t *testing.T) {
+	rr := httptest.NewRecorder()
+	req := httptest.NewRequest("POST", "/user", nil)
+	err := &models.ValidationError{Fields: []models.FieldError{
+		{Field: "email", Message: "must be a valid email address"},
+		{Field: "name", Message: "is required"},
+	}}
+
+	Error(rr, req, err, 0)
+
+	resp := rr.Result()
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Errorf("expected status 422, got %d", resp.StatusCode)
+	}
+	if ct := resp.Header.Get("Content-Type"); ct != "application/problem+json" {
+		t.Errorf("expected Content-Type application/problem+json, got %s", ct)
+	}
+	var problem models.Problem
+	if err := json.NewDecoder(resp.Body).Decode(&problem); err != nil {
+		t.Fatalf("failed to decode problem: %v", err)
+	}
+	if problem.Status != http.StatusUnprocessableEntity || problem.Type != "/problems/validation" || problem.Instance != "/user" {
+		t.Errorf("unexpected problem %+v", problem)
+	}
+	if len(problem.Errors) != 2 || problem.Errors[0].Field != "email" {
+		t.Errorf("expected both field errors, got %+v", problem.Errors)
+	}
+}
+
 func TestError_CustomStatusCode(t *testing.T) {
 	rr := httptest.NewRecorder()
 	req := httptest.NewRequest("GET", "/err", nil)
@@ -137,7 +170,14 @@ func TestToHTTPStatusCode(t *testing.T) {
 	}{
 		{models.ErrMissingArgument, http.StatusBadRequest},
 		{models.ErrInvalidMessageType, http.StatusBadRequest},
+		{models.ErrInvalidArgument, http.StatusBadRequest},
+		{models.ErrInvalidPayload, http.StatusBadRequest},
 		{models.ErrNotFound, http.StatusNotFound},
+		{fmt.Errorf("failed to get user: %w", models.ErrNotFound), http.StatusNotFound},
+		{&models.ValidationError{}, http.StatusUnprocessableEntity},
+		{models.ErrConflict, http.StatusConflict},
+		{models.ErrUnauthorized, http.StatusUnauthorized},
+		{models.ErrForbidden, http.StatusForbidden},
 		{errors.New("other"), http.StatusInternalServerError},
 	}
 	for _, tt := range tests {
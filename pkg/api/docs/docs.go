@@ -0,0 +1,39 @@
+package docs
+
+import (
+	_ "embed"
+	"net/http"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+)
+
+// Spec is the OpenAPI document describing every route served by the API.
+//
+//go:embed openapi.json
+var Spec []byte
+
+//go:embed index.html
+var page []byte
+
+func InitializeRouter(r *httprouter.Router) {
+	api.Register(r, Routes())
+}
+
+// Routes lists the documentation endpoints.
+func Routes() []api.Route {
+	return []api.Route{
+		{Method: http.MethodGet, Path: "/openapi.json", Handle: serveSpec}, // OpenAPI document
+		{Method: http.MethodGet, Path: "/docs", Handle: serveDocs},         // Interactive API docs
+	}
+}
+
+func serveSpec(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "application/json")
+	api.Success(w, r, Spec)
+}
+
+func serveDocs(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	api.Success(w, r, page)
+}
@@ -0,0 +1,227 @@
+package docs
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/api/events"
+	"github.com/rsys-speerzad/stackgen/pkg/api/resources"
+	"github.com/rsys-speerzad/stackgen/pkg/api/users"
+	"github.com/rsys-speerzad/stackgen/pkg/api/webhooks"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// schemaTypes maps every component schema onto the Go type it documents.
+var schemaTypes = map[string]reflect.Type{
+	"User":                        reflect.TypeOf(models.User{}),
+	"UserUpdate":                  reflect.TypeOf(models.UserUpdate{}),
+	"Slot":                        reflect.TypeOf(models.Slot{}),
+	"AvailabilityRequest":         reflect.TypeOf(models.AvailabilityRequest{}),
+	"Availabilities":              reflect.TypeOf(models.Availabilities{}),
+	"Event":                       reflect.TypeOf(models.Event{}),
+	"EventSlot":                   reflect.TypeOf(models.EventSlot{}),
+	"SlotVote":                    reflect.TypeOf(models.SlotVote{}),
+	"SlotSearch":                  reflect.TypeOf(models.SlotSearch{}),
+	"Quorum":                      reflect.TypeOf(models.Quorum{}),
+	"Sessions":                    reflect.TypeOf(models.Sessions{}),
+	"Recurrence":                  reflect.TypeOf(models.Recurrence{}),
+	"RecommendedSlot":             reflect.TypeOf(models.RecommendedSlot{}),
+	"MissingUser":                 reflect.TypeOf(models.MissingUser{}),
+	"Heatmap":                     reflect.TypeOf(models.Heatmap{}),
+	"HeatmapBucket":               reflect.TypeOf(models.HeatmapBucket{}),
+	"Session":                     reflect.TypeOf(models.Session{}),
+	"Occurrence":                  reflect.TypeOf(models.Occurrence{}),
+	"Candidate":                   reflect.TypeOf(models.Candidate{}),
+	"AttendeeSlot":                reflect.TypeOf(models.AttendeeSlot{}),
+	"Resource":                    reflect.TypeOf(models.Resource{}),
+	"Period":                      reflect.TypeOf(models.Period{}),
+	"ResourceAvailability":        reflect.TypeOf(models.ResourceAvailability{}),
+	"ResourceBooking":             reflect.TypeOf(models.ResourceBooking{}),
+	"ResourceAvailabilityRequest": reflect.TypeOf(models.ResourceAvailabilityRequest{}),
+	"ResourceSchedule":            reflect.TypeOf(models.ResourceSchedule{}),
+	"FieldError":                  reflect.TypeOf(models.FieldError{}),
+	"Problem":                     reflect.TypeOf(models.Problem{}),
+	"WebhookSubscription":         reflect.TypeOf(models.WebhookSubscription{}),
+	"WebhookDelivery":             reflect.TypeOf(models.WebhookDelivery{}),
+}
+
+type schema struct {
+	Ref        string             `json:"$ref"`
+	Properties map[string]*schema `json:"properties"`
+}
+
+type operation struct {
+	Responses map[string]struct {
+		Ref     string `json:"$ref"`
+		Content map[string]struct {
+			Schema *schema `json:"schema"`
+		} `json:"content"`
+	} `json:"responses"`
+}
+
+type pathItem struct {
+	Get    *operation `json:"get"`
+	Post   *operation `json:"post"`
+	Put    *operation `json:"put"`
+	Delete *operation `json:"delete"`
+}
+
+func (p pathItem) operations() map[string]*operation {
+	ops := map[string]*operation{}
+	for method, op := range map[string]*operation{
+		http.MethodGet:    p.Get,
+		http.MethodPost:   p.Post,
+		http.MethodPut:    p.Put,
+		http.MethodDelete: p.Delete,
+	} {
+		if op != nil {
+			ops[method] = op
+		}
+	}
+	return ops
+}
+
+type document struct {
+	OpenAPI    string              `json:"openapi"`
+	Paths      map[string]pathItem `json:"paths"`
+	Components struct {
+		Schemas   map[string]*schema `json:"schemas"`
+		Responses map[string]any     `json:"responses"`
+	} `json:"components"`
+}
+
+func loadSpec(t *testing.T) document {
+	t.Helper()
+	var doc document
+	require.NoError(t, json.Unmarshal(Spec, &doc))
+	require.Equal(t, "3.1.0", doc.OpenAPI)
+	return doc
+}
+
+// registeredRoutes returns "METHOD /path" for every API route, using OpenAPI
+// path templating.
+func registeredRoutes() []string {
+	var routes []api.Route
+	routes = append(routes, users.Routes(users.NewHandler(nil, bus.Discard))...)
+	routes = append(routes, resources.Routes(resources.NewHandler(nil, bus.Discard))...)
+	routes = append(routes, events.Routes(events.NewHandler(nil, bus.Discard))...)
+	routes = append(routes, webhooks.Routes(webhooks.NewHandler(nil))...)
+	var out []string
+	for _, route := range routes {
+		segments := strings.Split(route.Path, "/")
+		for i, segment := range segments {
+			if strings.HasPrefix(segment, ":") {
+				segments[i] = "{" + segment[1:] + "}"
+			}
+		}
+		out = append(out, route.Method+" "+strings.Join(segments, "/"))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func TestSpec_CoversRegisteredRoutes(t *testing.T) {
+	doc := loadSpec(t)
+	var documented []string
+	for path, item := range doc.Paths {
+		for method := range item.operations() {
+			documented = append(documented, method+" "+path)
+		}
+	}
+	sort.Strings(documented)
+	assert.Equal(t, registeredRoutes(), documented, "routes registered by InitializeRouter and the OpenAPI paths have drifted")
+}
+
+func TestSpec_SchemasMatchModels(t *testing.T) {
+	doc := loadSpec(t)
+	for name := range doc.Components.Schemas {
+		_, ok := schemaTypes[name]
+		assert.True(t, ok, "schema %s has no Go type in schemaTypes", name)
+	}
+	for name, typ := range schemaTypes {
+		s, ok := doc.Components.Schemas[name]
+		if !assert.True(t, ok, "schema %s is missing from the spec", name) {
+			continue
+		}
+		var documented []string
+		for property := range s.Properties {
+			documented = append(documented, property)
+		}
+		sort.Strings(documented)
+		assert.Equal(t, jsonFields(typ), documented, "schema %s does not match the JSON shape of %s", name, typ)
+	}
+}
+
+func TestSpec_ResponsesReferenceKnownSchemas(t *testing.T) {
+	doc := loadSpec(t)
+	for path, item := range doc.Paths {
+		for method, op := range item.operations() {
+			assert.NotEmpty(t, op.Responses, "%s %s has no responses", method, path)
+			for code, resp := range op.Responses {
+				if resp.Ref != "" {
+					name := strings.TrimPrefix(resp.Ref, "#/components/responses/")
+					assert.Contains(t, doc.Components.Responses, name, "%s %s %s", method, path, code)
+					continue
+				}
+				for _, media := range resp.Content {
+					if media.Schema.Ref == "" {
+						continue
+					}
+					name := strings.TrimPrefix(media.Schema.Ref, "#/components/schemas/")
+					assert.Contains(t, doc.Components.Schemas, name, "%s %s %s", method, path, code)
+				}
+			}
+		}
+	}
+}
+
+func TestRoutes_ServeSpecAndDocs(t *testing.T) {
+	r := httprouter.New()
+	InitializeRouter(r)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Equal(t, "application/json", w.Header().Get("Content-Type"))
+	assert.JSONEq(t, string(Spec), w.Body.String())
+
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/docs", nil))
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), "/openapi.json")
+}
+
+// jsonFields lists the JSON object keys encoding/json produces for typ.
+func jsonFields(typ reflect.Type) []string {
+	var fields []string
+	for i := 0; i < typ.NumField(); i++ {
+		field := typ.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+		if field.Anonymous && name == "" {
+			fields = append(fields, jsonFields(field.Type)...)
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields = append(fields, name)
+	}
+	sort.Strings(fields)
+	return fields
+}
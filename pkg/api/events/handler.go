@@ -1,37 +1,72 @@
 package events
 
 import (
-	"encoding/json"
+	"fmt"
 	"net/http"
+	"os"
+	"strconv"
+	"time"
 
 	"github.com/jinzhu/gorm"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/cache"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/notify"
 	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+	"github.com/rsys-speerzad/stackgen/pkg/webhooks"
 )
 
 type Handler struct {
-	store events.Store
+	store         events.Store
+	publisher     webhooks.Publisher
+	notifications notify.Notifications
+	changes       bus.Bus
 }
 
-func NewHandler(db *gorm.DB) *Handler {
+func NewHandler(db *gorm.DB, changes bus.Bus) *Handler {
 	return &Handler{
-		store: events.NewStore(db),
+		store:         newStore(db, changes),
+		publisher:     webhooks.NewService(db),
+		notifications: notify.NewService(db, notify.FromEnv(), changes),
+		changes:       changes,
 	}
 }
 
+// newStore returns the event store, caching up to RECOMMENDATION_CACHE_SIZE
+// recommendation results (1000 by default, 0 disables caching).
+func newStore(db *gorm.DB, changes bus.Bus) events.Store {
+	size := 1000
+	if n, err := strconv.Atoi(os.Getenv("RECOMMENDATION_CACHE_SIZE")); err == nil {
+		size = n
+	}
+	if size <= 0 {
+		return events.NewStore(db)
+	}
+	return events.NewCachedStore(db, cache.NewLRU(size), changes)
+}
+
+// NewHandlerWithStore returns a handler backed by the given event store that
+// neither publishes webhooks, sends notifications nor announces changes.
+func NewHandlerWithStore(store events.Store) *Handler {
+	return &Handler{store: store, publisher: webhooks.Discard, notifications: notify.Disabled, changes: bus.Discard}
+}
+
 // CreateEvent handles the creation of a new event.
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	var event *models.Event
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if err := api.DecodeStrict(r, &event); err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
 	if err := h.store.Create(event); err != nil {
-		http.Error(w, "Failed to create event: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to create event: %w", err), 0)
 		return
 	}
+	h.publisher.Publish(models.WebhookEventCreated, event)
+	h.changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: event.ID.String()})
+	h.notifications.EventCreated(event)
 	api.ResponseWriter(w, event, http.StatusCreated) // Use the utility function to write the response
 }
 
@@ -39,16 +74,12 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, urlParams httpr
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	id := urlParams.ByName("id")
 	if id == "" {
-		http.Error(w, "Event ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: event ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	event, err := h.store.Get(id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Event not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get event: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to get event: %w", err), 0)
 		return
 	}
 	api.ResponseWriter(w, event, 0) // Use the utility function to write the response
@@ -57,14 +88,16 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request, urlParams httprout
 // UpdateEvent updates an existing event by its ID.
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	var event *models.Event
-	if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
-		http.Error(w, "Invalid resquest payload", http.StatusBadRequest)
+	if err := api.Decode(r, &event); err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
 	if err := h.store.Update(event); err != nil {
-		http.Error(w, "Failed to create event: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to update event: %w", err), 0)
 		return
 	}
+	h.publisher.Publish(models.WebhookEventUpdated, event)
+	h.changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: event.ID.String()})
 	api.ResponseWriter(w, event, 0) // Use the utility function to write the response
 }
 
@@ -72,30 +105,101 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request, urlParams httpr
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	id := urlParams.ByName("id")
 	if id == "" {
-		http.Error(w, "Event ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: event ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	if err := h.store.Delete(id); err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Event not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get event: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to delete event: %w", err), 0)
 		return
 	}
+	h.publisher.Publish(models.WebhookEventDeleted, map[string]string{"id": id})
+	h.changes.Publish(bus.Change{Kind: bus.EventDeleted, EventID: id})
 	api.ResponseWriter(w, "", 0) // Use the utility function to write the response
 }
 
 func (h *Handler) GetRecommendations(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	id := urlParams.ByName("id")
 	if id == "" {
-		http.Error(w, "Event ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: event ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	recommendations, err := h.store.GetRecommendations(id)
 	if err != nil {
-		http.Error(w, "Failed to get recommendations: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to get recommendations: %w", err), 0)
 		return
 	}
 	api.ResponseWriter(w, recommendations, 0) // Use the utility function to write the response
 }
+
+// defaultGranularity is the size of heatmap buckets unless asked otherwise.
+const defaultGranularity = 15 * time.Minute
+
+// GetHeatmap reports the availability of an event's participants in buckets
+// of the granularity query parameter, such as 30m.
+func (h *Handler) GetHeatmap(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: event ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	granularity := defaultGranularity
+	if value := r.URL.Query().Get("granularity"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed < models.MinHeatmapGranularity || parsed > models.MaxHeatmapGranularity {
+			api.Error(w, r, &models.ValidationError{Fields: []models.FieldError{
+				{Field: "granularity", Message: "must be a duration from 5m to 24h, such as 15m"},
+			}}, 0)
+			return
+		}
+		granularity = parsed
+	}
+	heatmap, err := h.store.GetHeatmap(id, granularity)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to get heatmap: %w", err), 0)
+		return
+	}
+	api.ResponseWriter(w, heatmap, 0) // Use the utility function to write the response
+}
+
+// Finalize fixes the time of an event to the chosen slot.
+func (h *Handler) Finalize(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: event ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	var slot models.Slot
+	if err := api.DecodeStrict(r, &slot); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	event, err := h.store.Finalize(id, slot)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to finalize event: %w", err), 0)
+		return
+	}
+	h.publisher.Publish(models.WebhookEventFinalized, event)
+	h.changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: id})
+	h.notifications.EventFinalized(event)
+	api.ResponseWriter(w, event, 0) // Use the utility function to write the response
+}
+
+// Vote records a participant's answer for one of the event's slots.
+func (h *Handler) Vote(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id, slotID := urlParams.ByName("id"), urlParams.ByName("sid")
+	if id == "" || slotID == "" {
+		api.Error(w, r, fmt.Errorf("%w: event and slot IDs are required", models.ErrMissingArgument), 0)
+		return
+	}
+	var vote models.SlotVote
+	if err := api.DecodeStrict(r, &vote); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	if err := h.store.Vote(id, slotID, &vote); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to vote: %w", err), 0)
+		return
+	}
+	h.changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: id})
+	api.ResponseWriter(w, vote, 0) // Use the utility function to write the response
+}
@@ -8,9 +8,9 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/google/uuid"
-	"github.com/jinzhu/gorm"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -42,15 +42,34 @@ func (m *mockStore) GetRecommendations(eventID string) (*models.RecommendedSlot,
 	return args.Get(0).(*models.RecommendedSlot), args.Error(1)
 }
 
+func (m *mockStore) GetHeatmap(eventID string, granularity time.Duration) (*models.Heatmap, error) {
+	args := m.Called(eventID, granularity)
+	return args.Get(0).(*models.Heatmap), args.Error(1)
+}
+
+func (m *mockStore) Finalize(id string, slot models.Slot) (*models.Event, error) {
+	args := m.Called(id, slot)
+	return args.Get(0).(*models.Event), args.Error(1)
+}
+
+func (m *mockStore) Vote(eventID, slotID string, vote *models.SlotVote) error {
+	args := m.Called(eventID, slotID, vote)
+	return args.Error(0)
+}
+
+func (m *mockStore) ListOpen(now time.Time) ([]models.Event, error) {
+	args := m.Called(now)
+	return args.Get(0).([]models.Event), args.Error(1)
+}
+
 func newHandlerWithMockStore(store *mockStore) *Handler {
-	h := &Handler{store: store}
-	return h
+	return NewHandlerWithStore(store)
 }
 
 func TestCreate_Success(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	event := &models.Event{ID: uuid.New(), Title: "Test"}
+	event := &models.Event{ID: uuid.New(), Title: "Test", EstimatedDuration: 60}
 	store.On("Create", event).Return(nil)
 	body, _ := json.Marshal(event)
 	r := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
@@ -69,10 +88,30 @@ func TestCreate_BadRequest(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestCreate_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	now := time.Now()
+	event := &models.Event{EventSlots: []models.EventSlot{{StartTime: now, EndTime: now}}}
+	body, _ := json.Marshal(event)
+	r := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.Create(w, r, httprouter.Params{})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var problem models.Problem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, []models.FieldError{
+		{Field: "title", Message: "is required"},
+		{Field: "estimated_duration", Message: "must be greater than 0"},
+		{Field: "event_slots[0].end_time", Message: "must be after start_time"},
+	}, problem.Errors)
+	store.AssertNotCalled(t, "Create", mock.Anything)
+}
+
 func TestCreate_Error(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	event := &models.Event{ID: uuid.New(), Title: "Test"}
+	event := &models.Event{ID: uuid.New(), Title: "Test", EstimatedDuration: 60}
 	store.On("Create", event).Return(errors.New("fail"))
 	body, _ := json.Marshal(event)
 	r := httptest.NewRequest(http.MethodPost, "/events", bytes.NewReader(body))
@@ -99,7 +138,7 @@ func TestGet_Success(t *testing.T) {
 func TestGet_NotFound(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	store.On("Get", "1").Return(&models.Event{}, gorm.ErrRecordNotFound)
+	store.On("Get", "1").Return(&models.Event{}, models.ErrNotFound)
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/events/1", nil)
 	params := httprouter.Params{{Key: "id", Value: "1"}}
@@ -134,7 +173,7 @@ func TestUpdate_Success(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
 	id := uuid.New()
-	event := &models.Event{ID: id, Title: "Test"}
+	event := &models.Event{ID: id, Title: "Test", EstimatedDuration: 60}
 	store.On("Update", event).Return(nil)
 	body, _ := json.Marshal(event)
 	r := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/events/%s", id.String()), bytes.NewReader(body))
@@ -157,7 +196,7 @@ func TestUpdate_Error(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
 	id := uuid.New()
-	event := &models.Event{ID: id, Title: "Test"}
+	event := &models.Event{ID: id, Title: "Test", EstimatedDuration: 60}
 	store.On("Update", event).Return(errors.New("fail"))
 	body, _ := json.Marshal(event)
 	r := httptest.NewRequest(http.MethodPut, fmt.Sprintf("/events/%s", id.String()), bytes.NewReader(body))
@@ -183,7 +222,7 @@ func TestDelete_NotFound(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
 	id := uuid.New()
-	store.On("Delete", id.String()).Return(gorm.ErrRecordNotFound)
+	store.On("Delete", id.String()).Return(models.ErrNotFound)
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodDelete, fmt.Sprintf("/events/%s", id.String()), nil)
 	params := httprouter.Params{{Key: "id", Value: id.String()}}
@@ -250,3 +289,117 @@ func TestGetRecommendations_Error(t *testing.T) {
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	store.AssertExpectations(t)
 }
+
+func TestGetHeatmap_Success(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("GetHeatmap", "1", 15*time.Minute).Return(&models.Heatmap{Granularity: 15}, nil).Once()
+	store.On("GetHeatmap", "1", time.Hour).Return(&models.Heatmap{Granularity: 60}, nil).Once()
+	params := httprouter.Params{{Key: "id", Value: "1"}}
+
+	w := httptest.NewRecorder()
+	h.GetHeatmap(w, httptest.NewRequest(http.MethodGet, "/event/1/heatmap", nil), params)
+	assert.Equal(t, http.StatusOK, w.Code)
+	w = httptest.NewRecorder()
+	h.GetHeatmap(w, httptest.NewRequest(http.MethodGet, "/event/1/heatmap?granularity=1h", nil), params)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"granularity":60`)
+	store.AssertExpectations(t)
+}
+
+func TestGetHeatmap_InvalidGranularity(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	params := httprouter.Params{{Key: "id", Value: "1"}}
+	for _, granularity := range []string{"soon", "1m", "48h", "-15m"} {
+		w := httptest.NewRecorder()
+		h.GetHeatmap(w, httptest.NewRequest(http.MethodGet, "/event/1/heatmap?granularity="+granularity, nil), params)
+		assert.Equal(t, http.StatusUnprocessableEntity, w.Code, granularity)
+	}
+	store.AssertNotCalled(t, "GetHeatmap", mock.Anything, mock.Anything)
+}
+
+func TestFinalize_Success(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	start := time.Date(2025, 1, 12, 19, 0, 0, 0, time.UTC)
+	slot := models.Slot{StartTime: start, EndTime: start.Add(time.Hour)}
+	event := &models.Event{ID: uuid.New(), Title: "Test", FinalStartTime: &slot.StartTime, FinalEndTime: &slot.EndTime}
+	store.On("Finalize", "1", slot).Return(event, nil)
+	body, _ := json.Marshal(slot)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/event/1/finalize", bytes.NewReader(body))
+	params := httprouter.Params{{Key: "id", Value: "1"}}
+	h.Finalize(w, r, params)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"final_start_time":"2025-01-12T19:00:00Z"`)
+	store.AssertExpectations(t)
+}
+
+func TestFinalize_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/event/1/finalize", bytes.NewReader([]byte(`{}`)))
+	params := httprouter.Params{{Key: "id", Value: "1"}}
+	h.Finalize(w, r, params)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	store.AssertNotCalled(t, "Finalize", mock.Anything, mock.Anything)
+}
+
+func TestFinalize_NotFound(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	start := time.Date(2025, 1, 12, 19, 0, 0, 0, time.UTC)
+	slot := models.Slot{StartTime: start, EndTime: start.Add(time.Hour)}
+	store.On("Finalize", "1", slot).Return(&models.Event{}, models.ErrNotFound)
+	body, _ := json.Marshal(slot)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/event/1/finalize", bytes.NewReader(body))
+	params := httprouter.Params{{Key: "id", Value: "1"}}
+	h.Finalize(w, r, params)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestVote_Success(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	userID := uuid.New()
+	store.On("Vote", "1", "2", &models.SlotVote{UserID: userID, Vote: models.VoteMaybe}).Return(nil)
+	body, _ := json.Marshal(map[string]string{"user_id": userID.String(), "vote": "maybe"})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/event/1/slots/2/vote", bytes.NewReader(body))
+	params := httprouter.Params{{Key: "id", Value: "1"}, {Key: "sid", Value: "2"}}
+	h.Vote(w, r, params)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"vote":"maybe"`)
+	store.AssertExpectations(t)
+}
+
+func TestVote_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	body, _ := json.Marshal(map[string]string{"user_id": uuid.NewString(), "vote": "perhaps"})
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/event/1/slots/2/vote", bytes.NewReader(body))
+	params := httprouter.Params{{Key: "id", Value: "1"}, {Key: "sid", Value: "2"}}
+	h.Vote(w, r, params)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "vote")
+	store.AssertNotCalled(t, "Vote", mock.Anything, mock.Anything, mock.Anything)
+}
+
+func TestVote_NotFound(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	vote := &models.SlotVote{UserID: uuid.New(), Vote: models.VoteNo}
+	store.On("Vote", "1", "2", vote).Return(models.ErrNotFound)
+	body, _ := json.Marshal(vote)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPut, "/event/1/slots/2/vote", bytes.NewReader(body))
+	params := httprouter.Params{{Key: "id", Value: "1"}, {Key: "sid", Value: "2"}}
+	h.Vote(w, r, params)
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	store.AssertExpectations(t)
+}
@@ -1,15 +1,29 @@
 package events
 
 import (
+	"net/http"
+
 	"github.com/jinzhu/gorm"
 	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
 )
 
-func InitializeRouter(r *httprouter.Router, db *gorm.DB) {
-	handler := NewHandler(db)
-	r.POST("/event", handler.Create)                                 // Create a new event
-	r.GET("/event/:id", handler.Get)                                 // Get event by ID
-	r.PUT("/event/:id", handler.Update)                              // Update event by ID
-	r.DELETE("/event/:id", handler.Delete)                           // Delete event by ID
-	r.GET("/events/:id/recommendations", handler.GetRecommendations) // Get recommendations for an event
+func InitializeRouter(r *httprouter.Router, db *gorm.DB, changes bus.Bus) {
+	api.Register(r, Routes(NewHandler(db, changes)))
+}
+
+// Routes lists the event endpoints served by handler.
+func Routes(handler *Handler) []api.Route {
+	return []api.Route{
+		{Method: http.MethodPost, Path: "/event", Handle: handler.Create},                                          // Create a new event
+		{Method: http.MethodGet, Path: "/event/:id", Handle: handler.Get},                                          // Get event by ID
+		{Method: http.MethodPut, Path: "/event/:id", Handle: handler.Update},                                       // Update event by ID
+		{Method: http.MethodDelete, Path: "/event/:id", Handle: handler.Delete},                                    // Delete event by ID
+		{Method: http.MethodGet, Path: "/events/:id/recommendations", Handle: handler.GetRecommendations},          // Get recommendations for an event
+		{Method: http.MethodGet, Path: "/event/:id/recommendations/stream", Handle: handler.StreamRecommendations}, // Stream recommendation updates for an event
+		{Method: http.MethodGet, Path: "/event/:id/heatmap", Handle: handler.GetHeatmap},                           // Availability of the participants over an event's slots
+		{Method: http.MethodPost, Path: "/event/:id/finalize", Handle: handler.Finalize},                           // Fix the final time of an event
+		{Method: http.MethodPut, Path: "/event/:id/slots/:sid/vote", Handle: handler.Vote},                         // Vote on one of an event's slots
+	}
 }
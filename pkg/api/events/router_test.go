@@ -19,6 +19,10 @@ func mockInitializeRouter(router *httprouter.Router) {
 	router.PUT("/event/:id", dummyHandler)
 	router.DELETE("/event/:id", dummyHandler)
 	router.GET("/events/:id/recommendations", dummyHandler)
+	router.GET("/event/:id/recommendations/stream", dummyHandler)
+	router.GET("/event/:id/heatmap", dummyHandler)
+	router.POST("/event/:id/finalize", dummyHandler)
+	router.PUT("/event/:id/slots/:sid/vote", dummyHandler)
 }
 func TestInitializeRouter_Routes(t *testing.T) {
 	router := httprouter.New()
@@ -33,6 +37,10 @@ func TestInitializeRouter_Routes(t *testing.T) {
 		{"PUT", "/event/123"},
 		{"DELETE", "/event/123"},
 		{"GET", "/events/123/recommendations"},
+		{"GET", "/event/123/recommendations/stream"},
+		{"GET", "/event/123/heatmap"},
+		{"POST", "/event/123/finalize"},
+		{"PUT", "/event/123/slots/456/vote"},
 	}
 
 	for _, tt := range tests {
@@ -0,0 +1,150 @@
+package events
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// keepAliveInterval keeps idle streams open through proxies that time out
+// silent connections.
+var keepAliveInterval = 15 * time.Second
+
+// StreamRecommendations streams the recommendations of an event as
+// Server-Sent Events. The current result is sent first, then a new one every
+// time a change to users, availability, resources or events alters it. A
+// final "deleted" event is sent if the event is deleted. The stream ends when
+// the bus is closed, such as when the server shuts down.
+func (h *Handler) StreamRecommendations(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: event ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		api.Error(w, r, errors.New("streaming is not supported"), http.StatusInternalServerError)
+		return
+	}
+	// subscribe before the first read so that no change is missed in between;
+	// unchanged results are not sent again
+	filter := &changeFilter{}
+	changed, unsubscribe := h.changes.Subscribe(filter.matches)
+	defer unsubscribe()
+	current, err := h.recommendations(filter, id)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to get recommendations: %w", err), 0)
+		return
+	}
+
+	header := w.Header()
+	header.Set("Content-Type", "text/event-stream")
+	header.Set("Cache-Control", "no-cache")
+	header.Set("Connection", "keep-alive")
+	header.Set("X-Accel-Buffering", "no") // disable proxy buffering
+	w.WriteHeader(http.StatusOK)
+	seq := 1
+	writeEvent(w, seq, "recommendation", current)
+	flusher.Flush()
+
+	keepAlive := time.NewTicker(keepAliveInterval)
+	defer keepAlive.Stop()
+	for {
+		select {
+		case <-r.Context().Done():
+			return
+		case <-keepAlive.C:
+			io.WriteString(w, ": keep-alive\n\n")
+		case _, open := <-changed:
+			if !open {
+				return
+			}
+			next, err := h.recommendations(filter, id)
+			switch {
+			case errors.Is(err, models.ErrNotFound):
+				seq++
+				data, _ := json.Marshal(map[string]string{"id": id})
+				writeEvent(w, seq, "deleted", data)
+				flusher.Flush()
+				return
+			case err != nil:
+				log.Printf("streaming recommendations for event %s: %v", id, err)
+				continue
+			case string(next) == string(current):
+				continue
+			}
+			current = next
+			seq++
+			writeEvent(w, seq, "recommendation", current)
+		}
+		flusher.Flush()
+	}
+}
+
+// recommendations returns the JSON encoded recommendations of an event,
+// loading the event into filter first, as its participants or resources may
+// have changed.
+func (h *Handler) recommendations(filter *changeFilter, id string) ([]byte, error) {
+	event, err := h.store.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	filter.load(event)
+	rec, err := h.store.GetRecommendations(id)
+	if err != nil {
+		return nil, err
+	}
+	return json.Marshal(rec)
+}
+
+// changeFilter accepts the changes that can alter the recommendations of the
+// event last loaded into it, or every change until one is.
+type changeFilter struct {
+	mu    sync.Mutex
+	event *models.Event
+}
+
+func (f *changeFilter) load(event *models.Event) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.event = event
+}
+
+func (f *changeFilter) matches(change bus.Change) bool {
+	f.mu.Lock()
+	event := f.event
+	f.mu.Unlock()
+	return event == nil || concerns(event, change)
+}
+
+// concerns reports whether change can alter the recommendations of event: a
+// change to any event, as a finalized one takes up the time of its
+// participants, to one of its participants, or any user if it has no
+// participant list, to a resource it needs, or a resync.
+func concerns(event *models.Event, change bus.Change) bool {
+	switch {
+	case change.EventID != "":
+		return true
+	case change.UserID != "":
+		return len(event.ParticipantIDs) == 0 || slices.Contains(event.ParticipantIDs, change.UserID)
+	case change.ResourceID != "":
+		return slices.Contains(event.ResourceIDs, change.ResourceID) || slices.Contains(event.RoomIDs, change.ResourceID)
+	default:
+		return true
+	}
+}
+
+func writeEvent(w io.Writer, id int, event string, data []byte) {
+	fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", id, event, data)
+}
@@ -0,0 +1,153 @@
+package events
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+)
+
+type sseEvent struct {
+	id, event, data string
+}
+
+func readEvent(t *testing.T, r *bufio.Reader) sseEvent {
+	t.Helper()
+	var e sseEvent
+	for {
+		line, err := r.ReadString('\n')
+		require.NoError(t, err)
+		line = strings.TrimSuffix(line, "\n")
+		switch {
+		case line == "":
+			if e.event != "" {
+				return e
+			}
+		case strings.HasPrefix(line, "id: "):
+			e.id = strings.TrimPrefix(line, "id: ")
+		case strings.HasPrefix(line, "event: "):
+			e.event = strings.TrimPrefix(line, "event: ")
+		case strings.HasPrefix(line, "data: "):
+			e.data = strings.TrimPrefix(line, "data: ")
+		}
+	}
+}
+
+func newStreamServer(t *testing.T, store *mockStore, changes bus.Bus) *httptest.Server {
+	t.Helper()
+	h := newHandlerWithMockStore(store)
+	h.changes = changes
+	r := httprouter.New()
+	r.GET("/event/:id/recommendations/stream", h.StreamRecommendations)
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestStreamRecommendations_PushesChanges(t *testing.T) {
+	store := new(mockStore)
+	changes := bus.NewMemory()
+	server := newStreamServer(t, store, changes)
+	calls := make(chan struct{}, 10)
+	signal := func(mock.Arguments) { calls <- struct{}{} }
+	first := &models.RecommendedSlot{StartTime: time.Date(2025, 1, 12, 14, 0, 0, 0, time.UTC), UserIDs: []string{"Alice"}}
+	second := &models.RecommendedSlot{StartTime: first.StartTime, UserIDs: []string{"Alice", "Bob"}}
+	store.On("Get", "e1").Return(&models.Event{ParticipantIDs: []string{"u1", "u2"}}, nil).Times(3)
+	store.On("Get", "e1").Return((*models.Event)(nil), models.ErrNotFound).Once()
+	store.On("GetRecommendations", "e1").Return(first, nil).Twice().Run(signal)
+	store.On("GetRecommendations", "e1").Return(second, nil).Once().Run(signal)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, server.URL+"/event/e1/recommendations/stream", nil)
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+	body := bufio.NewReader(resp.Body)
+
+	e := readEvent(t, body)
+	<-calls
+	assert.Equal(t, sseEvent{"1", "recommendation", `{"start_time":"2025-01-12T14:00:00Z","end_time":"0001-01-01T00:00:00Z","user_ids":["Alice"],"missing_user_ids":null}`}, e)
+
+	// a change to another event is re-checked too, as its finalization can
+	// conflict with this one, but an unchanged result is not repeated
+	changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: "e2"})
+	<-calls
+	changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: "u2"})
+	e = readEvent(t, body)
+	assert.Equal(t, "2", e.id)
+	assert.Contains(t, e.data, `"user_ids":["Alice","Bob"]`)
+
+	changes.Publish(bus.Change{Kind: bus.EventDeleted, EventID: "e1"})
+	e = readEvent(t, body)
+	assert.Equal(t, sseEvent{"3", "deleted", `{"id":"e1"}`}, e)
+	store.AssertExpectations(t)
+}
+
+func TestStreamRecommendations_NotFound(t *testing.T) {
+	store := new(mockStore)
+	server := newStreamServer(t, store, bus.NewMemory())
+	store.On("Get", "missing").Return((*models.Event)(nil), models.ErrNotFound)
+
+	resp, err := http.Get(server.URL + "/event/missing/recommendations/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	assert.Equal(t, "application/problem+json", resp.Header.Get("Content-Type"))
+}
+
+func TestStreamRecommendations_EndsWhenBusCloses(t *testing.T) {
+	store := new(mockStore)
+	changes := bus.NewMemory()
+	server := newStreamServer(t, store, changes)
+	store.On("Get", "e1").Return(&models.Event{}, nil)
+	store.On("GetRecommendations", "e1").Return(&models.RecommendedSlot{}, nil)
+
+	resp, err := http.Get(server.URL + "/event/e1/recommendations/stream")
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	body := bufio.NewReader(resp.Body)
+	readEvent(t, body)
+
+	changes.Close()
+	_, err = io.ReadAll(body)
+	assert.NoError(t, err, "the stream ends")
+}
+
+func TestConcerns(t *testing.T) {
+	invited := &models.Event{ParticipantIDs: []string{"u1"}, ResourceIDs: []string{"r1"}, RoomIDs: []string{"r2"}}
+	everyone := &models.Event{}
+	for _, tc := range []struct {
+		name   string
+		event  *models.Event
+		change bus.Change
+		want   bool
+	}{
+		{"the event", invited, bus.Change{Kind: bus.EventChanged, EventID: "e1"}, true},
+		{"another event", invited, bus.Change{Kind: bus.EventChanged, EventID: "e2"}, true},
+		{"a participant", invited, bus.Change{Kind: bus.AvailabilityChanged, UserID: "u1"}, true},
+		{"someone else", invited, bus.Change{Kind: bus.AvailabilityChanged, UserID: "u2"}, false},
+		{"anyone without a participant list", everyone, bus.Change{Kind: bus.UserChanged, UserID: "u2"}, true},
+		{"a needed resource", invited, bus.Change{Kind: bus.ResourceChanged, ResourceID: "r1"}, true},
+		{"a room", invited, bus.Change{Kind: bus.ResourceChanged, ResourceID: "r2"}, true},
+		{"another resource", everyone, bus.Change{Kind: bus.ResourceChanged, ResourceID: "r1"}, false},
+		{"a resync", invited, bus.Change{Kind: bus.Resync}, true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, concerns(tc.event, tc.change))
+		})
+	}
+}
@@ -0,0 +1,162 @@
+package resources
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store/resources"
+)
+
+type Handler struct {
+	store   resources.Store
+	changes bus.Bus
+}
+
+func NewHandler(db *gorm.DB, changes bus.Bus) *Handler {
+	return &Handler{
+		store:   resources.NewStore(db),
+		changes: changes,
+	}
+}
+
+// NewHandlerWithStore returns a handler backed by the given resource store
+// that doesn't announce changes.
+func NewHandlerWithStore(store resources.Store) *Handler {
+	return &Handler{store: store, changes: bus.Discard}
+}
+
+// Create creates a new resource
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	var resource *models.Resource
+	if err := api.DecodeStrict(r, &resource); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	if err := h.store.Create(resource); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to create resource: %w", err), 0)
+		return
+	}
+	h.changes.Publish(bus.Change{Kind: bus.ResourceChanged, ResourceID: resource.ID.String()})
+	api.ResponseWriter(w, resource, http.StatusCreated) // Use the utility function to write the response
+}
+
+// Get gets the resource by ID
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: resource ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	resource, err := h.store.Get(id)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to get resource: %w", err), 0)
+		return
+	}
+	api.ResponseWriter(w, resource, 0) // Use the utility function to write the response
+}
+
+// Update updates the resource by ID
+func (h *Handler) Update(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: resource ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	var resource *models.Resource
+	if err := api.Decode(r, &resource); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	if err := h.store.Update(id, resource); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to update resource: %w", err), 0)
+		return
+	}
+	h.changes.Publish(bus.Change{Kind: bus.ResourceChanged, ResourceID: id})
+	api.ResponseWriter(w, "resource updated successfully", 0) // Use the utility function to write the response
+}
+
+// Delete deletes the resource by ID, along with its availability and
+// bookings
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: resource ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	if err := h.store.Delete(id); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to delete resource: %w", err), 0)
+		return
+	}
+	h.changes.Publish(bus.Change{Kind: bus.ResourceChanged, ResourceID: id})
+	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
+}
+
+// GetSchedule gets the availability and bookings of the resource
+func (h *Handler) GetSchedule(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: resource ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	schedule, err := h.store.GetSchedule(id)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to get schedule: %w", err), 0)
+		return
+	}
+	api.ResponseWriter(w, schedule, 0) // Use the utility function to write the response
+}
+
+// AddAvailability adds periods the resource can be booked in
+func (h *Handler) AddAvailability(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: resource ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	resourceID, err := uuid.Parse(id)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("%w: resource ID must be a UUID", models.ErrInvalidArgument), 0)
+		return
+	}
+	var req models.ResourceAvailabilityRequest
+	if err := api.DecodeStrict(r, &req); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	slots := make([]models.ResourceAvailability, len(req.Slots))
+	for i, period := range req.Slots {
+		slots[i] = models.ResourceAvailability{ResourceID: resourceID, Period: period}
+	}
+	if err := h.store.AddAvailability(slots); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to add availability: %w", err), 0)
+		return
+	}
+	h.changes.Publish(bus.Change{Kind: bus.ResourceChanged, ResourceID: id})
+	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
+}
+
+// DeleteAvailability deletes a period the resource can be booked in
+func (h *Handler) DeleteAvailability(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: resource ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	aid := urlParams.ByName("aid")
+	if aid == "" {
+		api.Error(w, r, fmt.Errorf("%w: availability ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	if err := h.store.DeleteAvailability(id, aid); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to delete availability: %w", err), 0)
+		return
+	}
+	h.changes.Publish(bus.Change{Kind: bus.ResourceChanged, ResourceID: id})
+	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
+}
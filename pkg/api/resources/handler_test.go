@@ -0,0 +1,168 @@
+package resources
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockStore struct {
+	mock.Mock
+}
+
+func (m *mockStore) Create(resource *models.Resource) error {
+	args := m.Called(resource)
+	return args.Error(0)
+}
+func (m *mockStore) Get(id string) (*models.Resource, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.Resource), args.Error(1)
+}
+func (m *mockStore) Update(id string, resource *models.Resource) error {
+	args := m.Called(id, resource)
+	return args.Error(0)
+}
+func (m *mockStore) Delete(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *mockStore) GetSchedule(resourceID string) (*models.ResourceSchedule, error) {
+	args := m.Called(resourceID)
+	return args.Get(0).(*models.ResourceSchedule), args.Error(1)
+}
+func (m *mockStore) AddAvailability(slots []models.ResourceAvailability) error {
+	args := m.Called(slots)
+	return args.Error(0)
+}
+func (m *mockStore) DeleteAvailability(resourceID, slotID string) error {
+	args := m.Called(resourceID, slotID)
+	return args.Error(0)
+}
+
+func TestCreate_Success(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	resource := &models.Resource{ID: uuid.New(), Name: "Boardroom", Kind: models.ResourceRoom, Capacity: 12}
+	store.On("Create", resource).Return(nil)
+	body, _ := json.Marshal(resource)
+	r := httptest.NewRequest(http.MethodPost, "/resource", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.Create(w, r, httprouter.Params{})
+	assert.Equal(t, http.StatusCreated, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestCreate_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	body, _ := json.Marshal(&models.Resource{Name: "Boardroom", Kind: "desk", Capacity: -1})
+	r := httptest.NewRequest(http.MethodPost, "/resource", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.Create(w, r, httprouter.Params{})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var problem models.Problem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, []models.FieldError{
+		{Field: "kind", Message: `"desk" must be one of room, equipment`},
+		{Field: "capacity", Message: "must be at least 0"},
+	}, problem.Errors)
+	store.AssertNotCalled(t, "Create", mock.Anything)
+}
+
+func TestGet_NotFound(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	store.On("Get", "123").Return((*models.Resource)(nil), models.ErrNotFound)
+	r := httptest.NewRequest(http.MethodGet, "/resource/123", nil)
+	w := httptest.NewRecorder()
+	h.Get(w, r, httprouter.Params{{Key: "id", Value: "123"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestDelete_Success(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	store.On("Delete", "123").Return(nil)
+	r := httptest.NewRequest(http.MethodDelete, "/resource/123", nil)
+	w := httptest.NewRecorder()
+	h.Delete(w, r, httprouter.Params{{Key: "id", Value: "123"}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestDelete_Booked(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	store.On("Delete", "123").Return(fmt.Errorf("%w: Boardroom has 1 bookings that haven't ended", models.ErrConflict))
+	r := httptest.NewRequest(http.MethodDelete, "/resource/123", nil)
+	w := httptest.NewRecorder()
+	h.Delete(w, r, httprouter.Params{{Key: "id", Value: "123"}})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestGetSchedule_Success(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	start := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	schedule := &models.ResourceSchedule{
+		Availability: []models.ResourceAvailability{{ID: uuid.New(), Period: models.Period{StartTime: start, EndTime: start.Add(8 * time.Hour)}}},
+		Bookings:     []models.ResourceBooking{{ID: uuid.New(), EventID: uuid.New(), Period: models.Period{StartTime: start, EndTime: start.Add(time.Hour)}}},
+	}
+	store.On("GetSchedule", "123").Return(schedule, nil)
+	r := httptest.NewRequest(http.MethodGet, "/resource/123/availability", nil)
+	w := httptest.NewRecorder()
+	h.GetSchedule(w, r, httprouter.Params{{Key: "id", Value: "123"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	var got models.ResourceSchedule
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&got))
+	assert.Len(t, got.Availability, 1)
+	assert.Len(t, got.Bookings, 1)
+}
+
+func TestAddAvailability_Success(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	resourceID := uuid.New()
+	start := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	period := models.Period{StartTime: start, EndTime: start.Add(8 * time.Hour)}
+	store.On("AddAvailability", []models.ResourceAvailability{{ResourceID: resourceID, Period: period}}).Return(nil)
+	body, _ := json.Marshal(models.ResourceAvailabilityRequest{Slots: []models.Period{period}})
+	r := httptest.NewRequest(http.MethodPost, "/resource/"+resourceID.String()+"/availability", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.AddAvailability(w, r, httprouter.Params{{Key: "id", Value: resourceID.String()}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestAddAvailability_BadRequest_InvalidResourceID(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	r := httptest.NewRequest(http.MethodPost, "/resource/abc/availability", bytes.NewReader([]byte(`{"slots": []}`)))
+	w := httptest.NewRecorder()
+	h.AddAvailability(w, r, httprouter.Params{{Key: "id", Value: "abc"}})
+	assert.Equal(t, http.StatusBadRequest, w.Code)
+}
+
+func TestDeleteAvailability_Error(t *testing.T) {
+	store := new(mockStore)
+	h := NewHandlerWithStore(store)
+	store.On("DeleteAvailability", "123", "456").Return(errors.New("fail"))
+	r := httptest.NewRequest(http.MethodDelete, "/resource/123/availability/456", nil)
+	w := httptest.NewRecorder()
+	h.DeleteAvailability(w, r, httprouter.Params{{Key: "id", Value: "123"}, {Key: "aid", Value: "456"}})
+	assert.Equal(t, http.StatusInternalServerError, w.Code)
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,29 @@
+package resources
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+)
+
+func InitializeRouter(r *httprouter.Router, db *gorm.DB, changes bus.Bus) {
+	api.Register(r, Routes(NewHandler(db, changes)))
+}
+
+// Routes lists the resource endpoints served by handler.
+func Routes(handler *Handler) []api.Route {
+	return []api.Route{
+		{Method: http.MethodPost, Path: "/resource", Handle: handler.Create},       // Create a new resource
+		{Method: http.MethodGet, Path: "/resource/:id", Handle: handler.Get},       // Get resource by ID
+		{Method: http.MethodPut, Path: "/resource/:id", Handle: handler.Update},    // Update resource by ID
+		{Method: http.MethodDelete, Path: "/resource/:id", Handle: handler.Delete}, // Delete resource by ID
+
+		// availability routes
+		{Method: http.MethodGet, Path: "/resource/:id/availability", Handle: handler.GetSchedule},                // Availability and bookings of a resource
+		{Method: http.MethodPost, Path: "/resource/:id/availability", Handle: handler.AddAvailability},           // Add availability for resource
+		{Method: http.MethodDelete, Path: "/resource/:id/availability/:aid", Handle: handler.DeleteAvailability}, // Delete availability for resource
+	}
+}
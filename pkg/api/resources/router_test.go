@@ -0,0 +1,58 @@
+package resources
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockInitializeRouter registers dummy handlers for route existence testing
+func mockInitializeRouter(r *httprouter.Router) {
+	dummyHandler := func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	}
+	r.POST("/resource", dummyHandler)
+	r.GET("/resource/:id", dummyHandler)
+	r.PUT("/resource/:id", dummyHandler)
+	r.DELETE("/resource/:id", dummyHandler)
+	r.GET("/resource/:id/availability", dummyHandler)
+	r.POST("/resource/:id/availability", dummyHandler)
+	r.DELETE("/resource/:id/availability/:aid", dummyHandler)
+}
+func TestInitializeRouter_Routes(t *testing.T) {
+	router := httprouter.New()
+	mockInitializeRouter(router)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"POST", "/resource"},
+		{"GET", "/resource/123"},
+		{"PUT", "/resource/123"},
+		{"DELETE", "/resource/123"},
+		{"GET", "/resource/123/availability"},
+		{"POST", "/resource/123/availability"},
+		{"DELETE", "/resource/123/availability/456"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "Route %s %s should exist and return 200", tt.method, tt.path)
+	}
+}
+
+func TestInitializeRouter_RouteNotFound(t *testing.T) {
+	router := httprouter.New()
+	mockInitializeRouter(router)
+
+	req := httptest.NewRequest("GET", "/nonexistent", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code, "Nonexistent route should return 404")
+}
@@ -0,0 +1,17 @@
+package api
+
+import "github.com/julienschmidt/httprouter"
+
+// Route describes a single endpoint served by the API.
+type Route struct {
+	Method string
+	Path   string
+	Handle httprouter.Handle
+}
+
+// Register adds routes to the router.
+func Register(r *httprouter.Router, routes []Route) {
+	for _, route := range routes {
+		r.Handle(route.Method, route.Path, route.Handle)
+	}
+}
@@ -1,7 +1,6 @@
 package users
 
 import (
-	"encoding/json"
 	"fmt"
 	"net/http"
 
@@ -9,31 +8,44 @@ import (
 	"github.com/jinzhu/gorm"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
 	"github.com/rsys-speerzad/stackgen/pkg/store/users"
+	"github.com/rsys-speerzad/stackgen/pkg/webhooks"
 )
 
 type Handler struct {
-	store users.Store
+	store     users.Store
+	publisher webhooks.Publisher
+	changes   bus.Bus
 }
 
-func NewHandler(db *gorm.DB) *Handler {
+func NewHandler(db *gorm.DB, changes bus.Bus) *Handler {
 	return &Handler{
-		store: users.NewStore(db),
+		store:     users.NewStore(db),
+		publisher: webhooks.NewService(db),
+		changes:   changes,
 	}
 }
 
+// NewHandlerWithStore returns a handler backed by the given user store that
+// neither publishes webhooks nor announces changes.
+func NewHandlerWithStore(store users.Store) *Handler {
+	return &Handler{store: store, publisher: webhooks.Discard, changes: bus.Discard}
+}
+
 // Create creates a new user
 func (h *Handler) Create(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	var user *models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if err := api.DecodeStrict(r, &user); err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
 	if err := h.store.Create(user); err != nil {
-		http.Error(w, "Failed to create user: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to create user: %w", err), 0)
 		return
 	}
+	h.changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: user.ID.String()})
 	api.ResponseWriter(w, user, http.StatusCreated) // Use the utility function to write the response
 }
 
@@ -41,16 +53,12 @@ func (h *Handler) Create(w http.ResponseWriter, r *http.Request, urlParams httpr
 func (h *Handler) Get(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	id := urlParams.ByName("id")
 	if id == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	user, err := h.store.Get(id)
 	if err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to get user: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to get user: %w", err), 0)
 		return
 	}
 	api.ResponseWriter(w, user, 0) // Use the utility function to write the response
@@ -60,18 +68,19 @@ func (h *Handler) Get(w http.ResponseWriter, r *http.Request, urlParams httprout
 func (h *Handler) Update(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	id := urlParams.ByName("id")
 	if id == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID is required", models.ErrMissingArgument), 0)
 		return
 	}
-	var user *models.User
-	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	var update *models.UserUpdate
+	if err := api.Decode(r, &update); err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
-	if err := h.store.Update(id, user); err != nil {
-		http.Error(w, "Failed to update user: "+err.Error(), http.StatusInternalServerError)
+	if err := h.store.Update(id, update); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to update user: %w", err), 0)
 		return
 	}
+	h.changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: id})
 	api.ResponseWriter(w, "user updated successfully", 0) // Use the utility function to write the response
 }
 
@@ -79,41 +88,35 @@ func (h *Handler) Update(w http.ResponseWriter, r *http.Request, urlParams httpr
 func (h *Handler) Delete(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	id := urlParams.ByName("id")
 	if id == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	if err := h.store.Delete(id); err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "User not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to delete user: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to delete user: %w", err), 0)
 		return
 	}
-	api.ResponseWriter(w, "user deleted successfully", http.StatusNoContent) // No content to return
+	h.changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: id})
+	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
 }
 
 func (h *Handler) AddAvailability(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
 	// read user ID from URL parameters
 	UserID := urlParams.ByName("id")
 	if UserID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	// parse the user ID to uuid.UUID
 	userID, err := uuid.Parse(UserID)
 	if err != nil {
-		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID must be a UUID", models.ErrInvalidArgument), 0)
 		return
 	}
 	// decode the request body to get availability slots
-	var req = struct {
-		Slots []models.Slot `json:"slots"`
-	}{}
+	var req models.AvailabilityRequest
 	// check if the request body is valid
-	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		fmt.Println("error while decoding request body:", err)
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if err := api.DecodeStrict(r, &req); err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
 	// convert the slots to UserAvailability models
@@ -122,16 +125,19 @@ func (h *Handler) AddAvailability(w http.ResponseWriter, r *http.Request, urlPar
 		slots = append(slots, models.UserAvailability{
 			UserID: userID,
 			Slot: models.Slot{
-				StartTime: slot.StartTime,
-				EndTime:   slot.EndTime,
+				StartTime:  slot.StartTime,
+				EndTime:    slot.EndTime,
+				Preference: slot.Preference,
 			},
 		})
 	}
 	// add the availability slots to the user
 	if err := h.store.AddAvailability(slots); err != nil {
-		http.Error(w, "Failed to add availability: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to add availability: %w", err), 0)
 		return
 	}
+	h.publisher.Publish(models.WebhookAvailabilitySubmitted, webhooks.AvailabilityChange{UserID: UserID, Slots: req.Slots})
+	h.changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: UserID})
 	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
 }
 
@@ -139,32 +145,34 @@ func (h *Handler) UpdateAvailability(w http.ResponseWriter, r *http.Request, url
 	// read user ID from URL parameters
 	UserID := urlParams.ByName("id")
 	if UserID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	// parse the user ID to uuid.UUID
 	userID, err := uuid.Parse(UserID)
 	if err != nil {
-		http.Error(w, "Invalid user ID format", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID must be a UUID", models.ErrInvalidArgument), 0)
 		return
 	}
 	// read availability ID from URL parameters
 	id := urlParams.ByName("aid")
 	if id == "" {
-		http.Error(w, "Availability ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: availability ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	// decode the request body to get updated availability slot
 	var slot models.UserAvailability
-	if err := json.NewDecoder(r.Body).Decode(&slot); err != nil {
-		http.Error(w, "Invalid request payload", http.StatusBadRequest)
+	if err := api.Decode(r, &slot); err != nil {
+		api.Error(w, r, err, 0)
 		return
 	}
 	slot.UserID = userID // set the user ID
 	if err := h.store.UpdateAvailability(id, slot); err != nil {
-		http.Error(w, "Failed to update availability: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to update availability: %w", err), 0)
 		return
 	}
+	h.publisher.Publish(models.WebhookAvailabilitySubmitted, webhooks.AvailabilityChange{UserID: UserID, Slots: []models.Slot{slot.Slot}})
+	h.changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: UserID})
 	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
 }
 
@@ -172,17 +180,15 @@ func (h *Handler) DeleteAvailability(w http.ResponseWriter, r *http.Request, url
 	// read availability ID from URL parameters
 	id := urlParams.ByName("aid")
 	if id == "" {
-		http.Error(w, "Availability ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: availability ID is required", models.ErrMissingArgument), 0)
 		return
 	}
-	if err := h.store.DeleteAvailability(id); err != nil {
-		if err == gorm.ErrRecordNotFound {
-			http.Error(w, "Availability not found", http.StatusNotFound)
-			return
-		}
-		http.Error(w, "Failed to delete availability: "+err.Error(), http.StatusInternalServerError)
+	if err := h.store.DeleteAvailability(urlParams.ByName("id"), id); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to delete availability: %w", err), 0)
 		return
 	}
+	h.publisher.Publish(models.WebhookAvailabilityDeleted, webhooks.AvailabilityChange{UserID: urlParams.ByName("id")})
+	h.changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: urlParams.ByName("id")})
 	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
 }
 
@@ -190,22 +196,21 @@ func (h *Handler) GetAvailabilities(w http.ResponseWriter, r *http.Request, urlP
 	// read user ID from URL parameters
 	userID := urlParams.ByName("id")
 	if userID == "" {
-		http.Error(w, "User ID is required", http.StatusBadRequest)
+		api.Error(w, r, fmt.Errorf("%w: user ID is required", models.ErrMissingArgument), 0)
 		return
 	}
 	// get the availabilities for the user
 	availabilities, err := h.store.GetAvailability(userID)
 	if err != nil {
-		http.Error(w, "Failed to get availabilities: "+err.Error(), http.StatusInternalServerError)
+		api.Error(w, r, fmt.Errorf("failed to get availabilities: %w", err), 0)
 		return
 	}
-	var resp = struct {
-		Slots []models.Slot `json:"available_slots"`
-	}{}
+	resp := models.Availabilities{Slots: []models.Slot{}}
 	for _, availability := range availabilities {
 		resp.Slots = append(resp.Slots, models.Slot{
-			StartTime: availability.StartTime,
-			EndTime:   availability.EndTime,
+			StartTime:  availability.StartTime,
+			EndTime:    availability.EndTime,
+			Preference: availability.Preference,
 		})
 	}
 	api.ResponseWriter(w, resp, 0) // Use the utility function to write the response
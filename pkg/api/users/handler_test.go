@@ -4,13 +4,13 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"net/http"
 	"net/http/httptest"
 	"testing"
 	"time"
 
 	"github.com/google/uuid"
-	"github.com/jinzhu/gorm"
 	"github.com/julienschmidt/httprouter"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
 	"github.com/stretchr/testify/assert"
@@ -29,8 +29,8 @@ func (m *mockStore) Get(id string) (*models.User, error) {
 	args := m.Called(id)
 	return args.Get(0).(*models.User), args.Error(1)
 }
-func (m *mockStore) Update(id string, user *models.User) error {
-	args := m.Called(id, user)
+func (m *mockStore) Update(id string, update *models.UserUpdate) error {
+	args := m.Called(id, update)
 	return args.Error(0)
 }
 func (m *mockStore) Delete(id string) error {
@@ -45,8 +45,8 @@ func (m *mockStore) UpdateAvailability(id string, slot models.UserAvailability)
 	args := m.Called(id, slot)
 	return args.Error(0)
 }
-func (m *mockStore) DeleteAvailability(id string) error {
-	args := m.Called(id)
+func (m *mockStore) DeleteAvailability(userID, id string) error {
+	args := m.Called(userID, id)
 	return args.Error(0)
 }
 func (m *mockStore) GetAvailability(userID string) ([]models.UserAvailability, error) {
@@ -55,14 +55,13 @@ func (m *mockStore) GetAvailability(userID string) ([]models.UserAvailability, e
 }
 
 func newHandlerWithMockStore(store *mockStore) *Handler {
-	h := &Handler{store: store}
-	return h
+	return NewHandlerWithStore(store)
 }
 
 func TestCreate_Success(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	user := &models.User{ID: uuid.New()}
+	user := &models.User{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"}
 	store.On("Create", user).Return(nil)
 	body, _ := json.Marshal(user)
 	r := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
@@ -81,10 +80,27 @@ func TestCreate_BadRequest(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestCreate_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	body, _ := json.Marshal(&models.User{Email: "not-an-email"})
+	r := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.Create(w, r, httprouter.Params{})
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	var problem models.Problem
+	assert.NoError(t, json.NewDecoder(w.Body).Decode(&problem))
+	assert.Equal(t, []models.FieldError{
+		{Field: "name", Message: "is required"},
+		{Field: "email", Message: "must be a valid email address"},
+	}, problem.Errors)
+	store.AssertNotCalled(t, "Create", mock.Anything)
+}
+
 func TestCreate_Error(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	user := &models.User{ID: uuid.New()}
+	user := &models.User{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"}
 	store.On("Create", user).Return(errors.New("fail"))
 	body, _ := json.Marshal(user)
 	r := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
@@ -94,10 +110,24 @@ func TestCreate_Error(t *testing.T) {
 	store.AssertExpectations(t)
 }
 
+func TestCreate_Conflict(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	user := &models.User{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"}
+	store.On("Create", user).Return(fmt.Errorf("%w: email is already registered", models.ErrConflict))
+	body, _ := json.Marshal(user)
+	r := httptest.NewRequest(http.MethodPost, "/users", bytes.NewReader(body))
+	w := httptest.NewRecorder()
+	h.Create(w, r, httprouter.Params{})
+	assert.Equal(t, http.StatusConflict, w.Code)
+	assert.Equal(t, "application/problem+json", w.Header().Get("Content-Type"))
+	store.AssertExpectations(t)
+}
+
 func TestGet_Success(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	user := &models.User{ID: uuid.New()}
+	user := &models.User{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"}
 	store.On("Get", "1").Return(user, nil)
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
@@ -110,7 +140,7 @@ func TestGet_Success(t *testing.T) {
 func TestGet_NotFound(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	store.On("Get", "1").Return(&models.User{}, gorm.ErrRecordNotFound)
+	store.On("Get", "1").Return(&models.User{}, models.ErrNotFound)
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodGet, "/users/1", nil)
 	params := httprouter.Params{{Key: "id", Value: "1"}}
@@ -144,9 +174,10 @@ func TestGet_BadRequest(t *testing.T) {
 func TestUpdate_Success(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	user := &models.User{ID: uuid.New()}
-	store.On("Update", "1", user).Return(nil)
-	body, _ := json.Marshal(user)
+	name, email := "Alice", "alice@example.com"
+	update := &models.UserUpdate{Name: &name, Email: &email}
+	store.On("Update", "1", update).Return(nil)
+	body, _ := json.Marshal(update)
 	r := httptest.NewRequest(http.MethodPut, "/users/1", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 	params := httprouter.Params{{Key: "id", Value: "1"}}
@@ -155,6 +186,19 @@ func TestUpdate_Success(t *testing.T) {
 	store.AssertExpectations(t)
 }
 
+func TestUpdate_Partial(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	zone, none := "Europe/Berlin", 0
+	store.On("Update", "1", &models.UserUpdate{TimeZone: &zone, BufferBefore: &none}).Return(nil)
+	r := httptest.NewRequest(http.MethodPut, "/users/1", bytes.NewReader([]byte(`{"time_zone":"Europe/Berlin","buffer_before":0}`)))
+	w := httptest.NewRecorder()
+	params := httprouter.Params{{Key: "id", Value: "1"}}
+	h.Update(w, r, params)
+	assert.Equal(t, http.StatusOK, w.Code)
+	store.AssertExpectations(t)
+}
+
 func TestUpdate_BadRequest(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
@@ -168,9 +212,10 @@ func TestUpdate_BadRequest(t *testing.T) {
 func TestUpdate_Error(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	user := &models.User{ID: uuid.New()}
-	store.On("Update", "1", user).Return(errors.New("fail"))
-	body, _ := json.Marshal(user)
+	name := "Alice"
+	update := &models.UserUpdate{Name: &name}
+	store.On("Update", "1", update).Return(errors.New("fail"))
+	body, _ := json.Marshal(update)
 	r := httptest.NewRequest(http.MethodPut, "/users/1", bytes.NewReader(body))
 	w := httptest.NewRecorder()
 	params := httprouter.Params{{Key: "id", Value: "1"}}
@@ -182,7 +227,7 @@ func TestUpdate_Error(t *testing.T) {
 func TestUpdate_BadRequest_NoID(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	user := &models.User{ID: uuid.New()}
+	user := &models.User{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"}
 	body, _ := json.Marshal(user)
 	r := httptest.NewRequest(http.MethodPut, "/users/", bytes.NewReader(body))
 	w := httptest.NewRecorder()
@@ -206,7 +251,7 @@ func TestDelete_Success(t *testing.T) {
 func TestDelete_NotFound(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
-	store.On("Delete", "1").Return(gorm.ErrRecordNotFound)
+	store.On("Delete", "1").Return(models.ErrNotFound)
 	w := httptest.NewRecorder()
 	r := httptest.NewRequest(http.MethodDelete, "/users/1", nil)
 	params := httprouter.Params{{Key: "id", Value: "1"}}
@@ -298,6 +343,24 @@ func TestAddAvailability_BadRequest_InvalidBody(t *testing.T) {
 	assert.Equal(t, http.StatusBadRequest, w.Code)
 }
 
+func TestAddAvailability_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	userID := uuid.New()
+	now := time.Now()
+	reqBody, _ := json.Marshal(map[string]interface{}{"slots": []models.Slot{
+		{StartTime: now, EndTime: now.Add(30 * time.Minute)},
+		{StartTime: now, EndTime: now.Add(-30 * time.Minute)},
+	}})
+	r := httptest.NewRequest(http.MethodPost, "/users/"+userID.String()+"/availability", bytes.NewReader(reqBody))
+	w := httptest.NewRecorder()
+	params := httprouter.Params{{Key: "id", Value: userID.String()}}
+	h.AddAvailability(w, r, params)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), "slots[1].end_time")
+	store.AssertNotCalled(t, "AddAvailability", mock.Anything)
+}
+
 func TestAddAvailability_Error(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
@@ -398,10 +461,10 @@ func TestDeleteAvailability_Success(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
 	aid := "aid"
-	store.On("DeleteAvailability", aid).Return(nil)
+	store.On("DeleteAvailability", "1", aid).Return(nil)
 	r := httptest.NewRequest(http.MethodDelete, "/users/1/availability/"+aid, nil)
 	w := httptest.NewRecorder()
-	params := httprouter.Params{{Key: "aid", Value: aid}}
+	params := httprouter.Params{{Key: "id", Value: "1"}, {Key: "aid", Value: aid}}
 	h.DeleteAvailability(w, r, params)
 	assert.Equal(t, http.StatusNoContent, w.Code)
 	store.AssertExpectations(t)
@@ -411,10 +474,10 @@ func TestDeleteAvailability_NotFound(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
 	aid := "aid"
-	store.On("DeleteAvailability", aid).Return(gorm.ErrRecordNotFound)
+	store.On("DeleteAvailability", "1", aid).Return(models.ErrNotFound)
 	r := httptest.NewRequest(http.MethodDelete, "/users/1/availability/"+aid, nil)
 	w := httptest.NewRecorder()
-	params := httprouter.Params{{Key: "aid", Value: aid}}
+	params := httprouter.Params{{Key: "id", Value: "1"}, {Key: "aid", Value: aid}}
 	h.DeleteAvailability(w, r, params)
 	assert.Equal(t, http.StatusNotFound, w.Code)
 	store.AssertExpectations(t)
@@ -424,10 +487,10 @@ func TestDeleteAvailability_Error(t *testing.T) {
 	store := new(mockStore)
 	h := newHandlerWithMockStore(store)
 	aid := "aid"
-	store.On("DeleteAvailability", aid).Return(errors.New("fail"))
+	store.On("DeleteAvailability", "1", aid).Return(errors.New("fail"))
 	r := httptest.NewRequest(http.MethodDelete, "/users/1/availability/"+aid, nil)
 	w := httptest.NewRecorder()
-	params := httprouter.Params{{Key: "aid", Value: aid}}
+	params := httprouter.Params{{Key: "id", Value: "1"}, {Key: "aid", Value: aid}}
 	h.DeleteAvailability(w, r, params)
 	assert.Equal(t, http.StatusInternalServerError, w.Code)
 	store.AssertExpectations(t)
@@ -1,20 +1,30 @@
 package users
 
 import (
+	"net/http"
+
 	"github.com/jinzhu/gorm"
 	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
 )
 
-func InitializeRouter(r *httprouter.Router, db *gorm.DB) {
-	handler := NewHandler(db)
-	r.POST("/user", handler.Create)       // Create a new user
-	r.GET("/user/:id", handler.Get)       // Get user by ID
-	r.PUT("/user/:id", handler.Update)    // Update user by ID
-	r.DELETE("/user/:id", handler.Delete) // Delete user by ID
+func InitializeRouter(r *httprouter.Router, db *gorm.DB, changes bus.Bus) {
+	api.Register(r, Routes(NewHandler(db, changes)))
+}
+
+// Routes lists the user endpoints served by handler.
+func Routes(handler *Handler) []api.Route {
+	return []api.Route{
+		{Method: http.MethodPost, Path: "/user", Handle: handler.Create},       // Create a new user
+		{Method: http.MethodGet, Path: "/user/:id", Handle: handler.Get},       // Get user by ID
+		{Method: http.MethodPut, Path: "/user/:id", Handle: handler.Update},    // Update user by ID
+		{Method: http.MethodDelete, Path: "/user/:id", Handle: handler.Delete}, // Delete user by ID
 
-	// availability routes
-	r.GET("/user/:id/availability", handler.GetAvailabilities)          // Get availability for user
-	r.POST("/user/:id/availability", handler.AddAvailability)           // Add availability for user
-	r.PUT("/user/:id/availability/:aid", handler.UpdateAvailability)    // Update availability for user
-	r.DELETE("/user/:id/availability/:aid", handler.DeleteAvailability) // Delete availability for user
+		// availability routes
+		{Method: http.MethodGet, Path: "/user/:id/availability", Handle: handler.GetAvailabilities},          // Get availability for user
+		{Method: http.MethodPost, Path: "/user/:id/availability", Handle: handler.AddAvailability},           // Add availability for user
+		{Method: http.MethodPut, Path: "/user/:id/availability/:aid", Handle: handler.UpdateAvailability},    // Update availability for user
+		{Method: http.MethodDelete, Path: "/user/:id/availability/:aid", Handle: handler.DeleteAvailability}, // Delete availability for user
+	}
 }
@@ -0,0 +1,315 @@
+package api
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// DisallowUnknownFields makes DecodeStrict reject payloads with fields that
+// are not part of the target type, so that client typos surface as errors.
+var DisallowUnknownFields bool
+
+// Decode reads the JSON request body into v and validates it. Unknown fields
+// are ignored, so that clients can send back what they read to update it,
+// such as a user with its ID.
+func Decode(r *http.Request, v interface{}) error {
+	return decode(r, v, false)
+}
+
+// DecodeStrict is Decode for requests whose bodies aren't read back from the
+// API, such as creates, votes and finalizations, which reject unknown fields
+// if DisallowUnknownFields is set.
+func DecodeStrict(r *http.Request, v interface{}) error {
+	return decode(r, v, DisallowUnknownFields)
+}
+
+func decode(r *http.Request, v interface{}, strict bool) error {
+	decoder := json.NewDecoder(r.Body)
+	if strict {
+		decoder.DisallowUnknownFields()
+	}
+	if err := decoder.Decode(v); err != nil {
+		return fmt.Errorf("%w: %v", models.ErrInvalidPayload, err)
+	}
+	return Validate(v)
+}
+
+// Validate checks v against the rules declared in its `validate` struct tags
+// and returns a *models.ValidationError listing every failing field.
+//
+// Supported rules, separated by commas:
+//
+//	required     the value must not be the zero value (or empty for slices)
+//	nonempty     the string, if set, must not be empty
+//	email        the string must be a valid email address
+//	url          the string must be an absolute http or https URL
+//	uuid         the string, or every string of a slice, must be a UUID
+//	oneof=A B    the string, or every string of a slice, must be one of A, B
+//	clock        the string must be a time of day such as 09:30 or 24:00
+//	timezone     the string must be an IANA time zone name such as Europe/Berlin
+//	gt=N         the number must be greater than N
+//	min=N        the number must be at least N
+//	max=N        the number must be at most N
+//	gtfield=F    a value must be greater than (or after) sibling field F;
+//	             strings compare lexically
+//
+// Apart from required and nonempty, rules on strings accept the empty
+// string. Rules on a pointer other than required apply to what it points
+// to, and pass when it is nil. Nested structs and slices of structs are validated recursively. A
+// malformed rule is reported as a plain error, not a *models.ValidationError.
+func Validate(v interface{}) error {
+	var fields []models.FieldError
+	if err := validateValue(reflect.ValueOf(v), "", &fields); err != nil {
+		return err
+	}
+	if len(fields) > 0 {
+		return &models.ValidationError{Fields: fields}
+	}
+	return nil
+}
+
+func validateValue(v reflect.Value, path string, fields *[]models.FieldError) error {
+	for v.Kind() == reflect.Ptr || v.Kind() == reflect.Interface {
+		if v.IsNil() {
+			if path == "" {
+				*fields = append(*fields, models.FieldError{Field: "body", Message: "is required"})
+			}
+			return nil
+		}
+		v = v.Elem()
+	}
+	switch v.Kind() {
+	case reflect.Struct:
+		if v.Type() == reflect.TypeOf(time.Time{}) {
+			return nil
+		}
+		return validateStruct(v, path, fields)
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < v.Len(); i++ {
+			if err := validateValue(v.Index(i), fmt.Sprintf("%s[%d]", path, i), fields); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func validateStruct(v reflect.Value, path string, fields *[]models.FieldError) error {
+	t := v.Type()
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		if field.PkgPath != "" {
+			continue
+		}
+		value := v.Field(i)
+		// embedded structs share the parent's JSON namespace
+		if field.Anonymous {
+			if err := validateValue(value, path, fields); err != nil {
+				return err
+			}
+			continue
+		}
+		name := jsonName(field)
+		if name == "-" {
+			continue
+		}
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		if tag := field.Tag.Get("validate"); tag != "" {
+			for _, rule := range strings.Split(tag, ",") {
+				target := value
+				if value.Kind() == reflect.Ptr && rule != "required" {
+					if value.IsNil() {
+						continue
+					}
+					target = value.Elem()
+				}
+				msg, err := checkRule(rule, v, target)
+				if err != nil {
+					return fmt.Errorf("validate: %s.%s: %w", t.Name(), field.Name, err)
+				}
+				if msg != "" {
+					*fields = append(*fields, models.FieldError{Field: fieldPath, Message: msg})
+					break
+				}
+			}
+		}
+		if err := validateValue(value, fieldPath, fields); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRule returns the message of a failed rule, or an error if the rule
+// itself is malformed.
+func checkRule(rule string, parent, value reflect.Value) (string, error) {
+	name, param, _ := strings.Cut(rule, "=")
+	switch name {
+	case "required":
+		if isEmpty(value) {
+			return "is required", nil
+		}
+	case "nonempty":
+		if value.Kind() == reflect.String && value.Len() == 0 {
+			return "must not be empty", nil
+		}
+	case "email":
+		if s := value.String(); s != "" {
+			if addr, err := mail.ParseAddress(s); err != nil || addr.Address != s {
+				return "must be a valid email address", nil
+			}
+		}
+	case "url":
+		if s := value.String(); s != "" {
+			if u, err := url.Parse(s); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+				return "must be an absolute http or https URL", nil
+			}
+		}
+	case "uuid":
+		for _, v := range stringValues(value) {
+			if _, err := uuid.Parse(v); err != nil {
+				return fmt.Sprintf("%q must be a UUID", v), nil
+			}
+		}
+	case "oneof":
+		allowed := strings.Fields(param)
+		for _, v := range stringValues(value) {
+			if !contains(allowed, v) {
+				return fmt.Sprintf("%q must be one of %s", v, strings.Join(allowed, ", ")), nil
+			}
+		}
+	case "clock":
+		if s := value.String(); s != "" {
+			if _, err := models.ParseClock(s); err != nil {
+				return "must be a time of day such as 09:30", nil
+			}
+		}
+	case "timezone":
+		if s := value.String(); s != "" {
+			if _, err := time.LoadLocation(s); err != nil {
+				return "must be a time zone such as Europe/Berlin", nil
+			}
+		}
+	case "gt":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid gt parameter %q", param)
+		}
+		if n, ok := toFloat(value); ok && n <= limit {
+			return "must be greater than " + param, nil
+		}
+	case "min":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid min parameter %q", param)
+		}
+		if n, ok := toFloat(value); ok && n < limit {
+			return "must be at least " + param, nil
+		}
+	case "max":
+		limit, err := strconv.ParseFloat(param, 64)
+		if err != nil {
+			return "", fmt.Errorf("invalid max parameter %q", param)
+		}
+		if n, ok := toFloat(value); ok && n > limit {
+			return "must be at most " + param, nil
+		}
+	case "gtfield":
+		other, ok := parent.Type().FieldByName(param)
+		if !ok {
+			return "", fmt.Errorf("unknown field %q in gtfield", param)
+		}
+		if !isEmpty(value) && !isGreater(value, reflect.Indirect(parent.FieldByIndex(other.Index))) {
+			return "must be after " + jsonName(other), nil
+		}
+	default:
+		return "", fmt.Errorf("unknown rule %q", rule)
+	}
+	return "", nil
+}
+
+// stringValues returns a non-empty string value, or the elements of a string
+// slice.
+func stringValues(v reflect.Value) []string {
+	values := []string{}
+	if v.Kind() == reflect.String && v.Len() > 0 {
+		values = append(values, v.String())
+	} else if v.Kind() == reflect.Slice {
+		for i := 0; i < v.Len(); i++ {
+			values = append(values, v.Index(i).String())
+		}
+	}
+	return values
+}
+
+func isEmpty(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Slice, reflect.Map, reflect.String:
+		return v.Len() == 0
+	case reflect.Ptr, reflect.Interface:
+		return v.IsNil()
+	}
+	if t, ok := v.Interface().(time.Time); ok {
+		return t.IsZero()
+	}
+	return v.IsZero()
+}
+
+// isGreater reports whether a is greater than b, or b is a nil pointer's.
+func isGreater(a, b reflect.Value) bool {
+	if !b.IsValid() {
+		return true
+	}
+	if ta, ok := a.Interface().(time.Time); ok {
+		tb, _ := b.Interface().(time.Time)
+		return ta.After(tb)
+	}
+	if a.Kind() == reflect.String {
+		return a.String() > b.String()
+	}
+	na, okA := toFloat(a)
+	nb, okB := toFloat(b)
+	return okA && okB && na > nb
+}
+
+func toFloat(v reflect.Value) (float64, bool) {
+	switch v.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(v.Int()), true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(v.Uint()), true
+	case reflect.Float32, reflect.Float64:
+		return v.Float(), true
+	}
+	return 0, false
+}
+
+func jsonName(field reflect.StructField) string {
+	name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+	if name == "" {
+		return field.Name
+	}
+	return name
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,257 @@
+package api
+
+import (
+	"errors"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+func TestValidate_ReportsAllFieldErrors(t *testing.T) {
+	now := time.Now()
+	event := &models.Event{
+		EstimatedDuration: -5,
+		EventSlots: []models.EventSlot{
+			{StartTime: now, EndTime: now.Add(time.Hour)},
+			{EndTime: now},
+		},
+	}
+
+	err := Validate(event)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *models.ValidationError, got %v", err)
+	}
+	want := []string{"title", "estimated_duration", "event_slots[1].start_time"}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), verr.Fields)
+	}
+	for i, field := range want {
+		if verr.Fields[i].Field != field {
+			t.Errorf("field error %d: expected %s, got %s", i, field, verr.Fields[i].Field)
+		}
+	}
+}
+
+func TestValidate_Valid(t *testing.T) {
+	user := &models.User{Name: "Alice", Email: "alice@example.com"}
+	if err := Validate(user); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Email(t *testing.T) {
+	for _, email := range []string{"alice", "Alice <alice@example.com>", "@example.com"} {
+		err := Validate(&models.User{Name: "Alice", Email: email})
+		if !errors.Is(err, models.ErrValidation) {
+			t.Errorf("expected %q to be rejected, got %v", email, err)
+		}
+	}
+}
+
+func TestDecode_DisallowUnknownFields(t *testing.T) {
+	defer func(prev bool) { DisallowUnknownFields = prev }(DisallowUnknownFields)
+	body := `{"name":"Alice","email":"alice@example.com","emial":"typo"}`
+
+	DisallowUnknownFields = false
+	var user models.User
+	if err := DecodeStrict(httptest.NewRequest("POST", "/user", strings.NewReader(body)), &user); err != nil {
+		t.Errorf("expected unknown field to be ignored, got %v", err)
+	}
+
+	DisallowUnknownFields = true
+	err := DecodeStrict(httptest.NewRequest("POST", "/user", strings.NewReader(body)), &user)
+	if !errors.Is(err, models.ErrInvalidPayload) || !strings.Contains(err.Error(), "emial") {
+		t.Errorf("expected unknown field error, got %v", err)
+	}
+
+	// only creations are strict
+	if err := Decode(httptest.NewRequest("PUT", "/user/1", strings.NewReader(body)), &user); err != nil {
+		t.Errorf("expected unknown field to be ignored on update, got %v", err)
+	}
+}
+
+func TestDecode_NullBody(t *testing.T) {
+	var user *models.User
+	err := Decode(httptest.NewRequest("POST", "/user", strings.NewReader("null")), &user)
+	if !errors.Is(err, models.ErrValidation) {
+		t.Errorf("expected validation error, got %v", err)
+	}
+}
+
+func TestValidate_URLAndOneOf(t *testing.T) {
+	sub := &models.WebhookSubscription{URL: "ftp://example.com", EventTypes: []string{"event.created", "event.exploded"}}
+
+	err := Validate(sub)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 2 {
+		t.Fatalf("expected two field errors, got %v", err)
+	}
+	if verr.Fields[0].Field != "url" || !strings.Contains(verr.Fields[1].Message, `"event.exploded"`) {
+		t.Errorf("unexpected field errors %+v", verr.Fields)
+	}
+	sub = &models.WebhookSubscription{URL: "https://bot.example.com/hook", EventTypes: []string{models.WebhookEventCreated}}
+	if err := Validate(sub); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_UUID(t *testing.T) {
+	start := time.Date(2025, 1, 12, 9, 0, 0, 0, time.UTC)
+	event := &models.Event{
+		Title:             "Sync",
+		EstimatedDuration: 30,
+		EventSlots:        []models.EventSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+		ParticipantIDs:    []string{uuid.NewString(), "alice"},
+	}
+
+	err := Validate(event)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "participant_ids" {
+		t.Fatalf("expected a participant_ids field error, got %v", err)
+	}
+	event.ParticipantIDs = event.ParticipantIDs[:1]
+	if err := Validate(event); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Search(t *testing.T) {
+	start := time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC)
+	event := &models.Event{
+		Title:             "Sync",
+		EstimatedDuration: 30,
+		Search: &models.SlotSearch{
+			From: start, To: start.AddDate(0, 0, 14),
+			TimeZone: "Mars/Olympus_Mons", DayStart: "17:00", DayEnd: "9:00",
+		},
+	}
+
+	err := Validate(event)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *models.ValidationError, got %v", err)
+	}
+	want := []string{"search.time_zone", "search.day_end"}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("expected %d field errors, got %+v", len(want), verr.Fields)
+	}
+	for i, field := range want {
+		if verr.Fields[i].Field != field {
+			t.Errorf("field error %d: expected %s, got %s", i, field, verr.Fields[i].Field)
+		}
+	}
+	event.Search.TimeZone, event.Search.DayEnd = "Europe/Berlin", "09:00"
+	if err := Validate(event); !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Message != "must be after day_start" {
+		t.Fatalf("expected day_end before day_start to be rejected, got %v", err)
+	}
+	event.Search.DayStart, event.Search.DayEnd = "09:00", "24:00"
+	if err := Validate(event); err != nil {
+		t.Errorf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Preference(t *testing.T) {
+	start := time.Date(2025, 1, 12, 9, 0, 0, 0, time.UTC)
+	req := &models.AvailabilityRequest{Slots: []models.Slot{
+		{StartTime: start, EndTime: start.Add(time.Hour)},
+		{StartTime: start, EndTime: start.Add(time.Hour), Preference: models.PreferenceIfNeeded},
+		{StartTime: start, EndTime: start.Add(time.Hour), Preference: "maybe"},
+	}}
+
+	err := Validate(req)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "slots[2].preference" {
+		t.Fatalf("expected a slots[2].preference field error, got %v", err)
+	}
+}
+
+func TestValidate_Min(t *testing.T) {
+	user := &models.User{Name: "Alice", Email: "alice@example.com", BufferAfter: -5}
+
+	err := Validate(user)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "buffer_after" || verr.Fields[0].Message != "must be at least 0" {
+		t.Fatalf("expected a buffer_after field error, got %v", err)
+	}
+	user.BufferAfter = 0
+	if err := Validate(user); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_Max(t *testing.T) {
+	sessions := &models.Sessions{Count: 21}
+
+	err := Validate(sessions)
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) || len(verr.Fields) != 1 || verr.Fields[0].Field != "count" || verr.Fields[0].Message != "must be at most 20" {
+		t.Fatalf("expected a count field error, got %v", err)
+	}
+	sessions.Count = 20
+	if err := Validate(sessions); err != nil {
+		t.Fatalf("expected no error, got %v", err)
+	}
+}
+
+func TestValidate_PartialUpdate(t *testing.T) {
+	empty, zero, end := "", 0, "17:00"
+	if err := Validate(&models.UserUpdate{}); err != nil {
+		t.Fatalf("expected no error for an empty update, got %v", err)
+	}
+	if err := Validate(&models.UserUpdate{TimeZone: &empty, WorkdayEnd: &end, BufferBefore: &zero}); err != nil {
+		t.Fatalf("expected fields to be clearable, got %v", err)
+	}
+
+	invalid, negative := "not an email", -1
+	err := Validate(&models.UserUpdate{Name: &empty, Email: &invalid, BufferAfter: &negative})
+
+	var verr *models.ValidationError
+	if !errors.As(err, &verr) {
+		t.Fatalf("expected *models.ValidationError, got %v", err)
+	}
+	want := []models.FieldError{
+		{Field: "name", Message: "must not be empty"},
+		{Field: "email", Message: "must be a valid email address"},
+		{Field: "buffer_after", Message: "must be at least 0"},
+	}
+	if len(verr.Fields) != len(want) {
+		t.Fatalf("expected %+v, got %+v", want, verr.Fields)
+	}
+	for i := range want {
+		if verr.Fields[i] != want[i] {
+			t.Errorf("field error %d: expected %+v, got %+v", i, want[i], verr.Fields[i])
+		}
+	}
+}
+
+func TestValidate_MalformedRule(t *testing.T) {
+	for _, v := range []interface{}{
+		&struct {
+			N int `validate:"min=zero"`
+		}{},
+		&struct {
+			S string `validate:"uppercase"`
+		}{},
+		&struct {
+			S string `validate:"gtfield=Missing"`
+		}{S: "b"},
+	} {
+		err := Validate(v)
+		var verr *models.ValidationError
+		if err == nil || errors.As(err, &verr) {
+			t.Errorf("expected a plain error for %+v, got %v", v, err)
+		}
+	}
+}
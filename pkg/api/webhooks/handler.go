@@ -0,0 +1,114 @@
+package webhooks
+
+import (
+	"fmt"
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store/webhooks"
+	hooks "github.com/rsys-speerzad/stackgen/pkg/webhooks"
+)
+
+type Handler struct {
+	store webhooks.Store
+}
+
+func NewHandler(db *gorm.DB) *Handler {
+	return &Handler{
+		store: webhooks.NewStore(db),
+	}
+}
+
+// Create subscribes a URL to webhook events. The signing secret is generated
+// when omitted and is only returned by this call.
+func (h *Handler) Create(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	var sub *models.WebhookSubscription
+	if err := api.DecodeStrict(r, &sub); err != nil {
+		api.Error(w, r, err, 0)
+		return
+	}
+	if sub.Secret == "" {
+		secret, err := hooks.NewSecret()
+		if err != nil {
+			api.Error(w, r, fmt.Errorf("failed to generate secret: %w", err), 0)
+			return
+		}
+		sub.Secret = secret
+	}
+	if err := h.store.CreateSubscription(sub); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to create webhook: %w", err), 0)
+		return
+	}
+	api.ResponseWriter(w, sub, http.StatusCreated) // Use the utility function to write the response
+}
+
+// List lists every webhook subscription.
+func (h *Handler) List(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	subs, err := h.store.ListSubscriptions()
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to list webhooks: %w", err), 0)
+		return
+	}
+	for i := range subs {
+		subs[i].Secret = ""
+	}
+	if subs == nil {
+		subs = []models.WebhookSubscription{}
+	}
+	api.ResponseWriter(w, subs, 0) // Use the utility function to write the response
+}
+
+// Get gets the webhook subscription by ID.
+func (h *Handler) Get(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: webhook ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	sub, err := h.store.GetSubscription(id)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to get webhook: %w", err), 0)
+		return
+	}
+	sub.Secret = ""
+	api.ResponseWriter(w, sub, 0) // Use the utility function to write the response
+}
+
+// Delete unsubscribes the webhook by ID.
+func (h *Handler) Delete(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: webhook ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	if err := h.store.DeleteSubscription(id); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to delete webhook: %w", err), 0)
+		return
+	}
+	api.ResponseWriter(w, nil, http.StatusNoContent) // No content to return
+}
+
+// Deliveries lists the most recent deliveries of the webhook.
+func (h *Handler) Deliveries(w http.ResponseWriter, r *http.Request, urlParams httprouter.Params) {
+	id := urlParams.ByName("id")
+	if id == "" {
+		api.Error(w, r, fmt.Errorf("%w: webhook ID is required", models.ErrMissingArgument), 0)
+		return
+	}
+	if _, err := h.store.GetSubscription(id); err != nil {
+		api.Error(w, r, fmt.Errorf("failed to get webhook: %w", err), 0)
+		return
+	}
+	deliveries, err := h.store.ListDeliveries(id)
+	if err != nil {
+		api.Error(w, r, fmt.Errorf("failed to list deliveries: %w", err), 0)
+		return
+	}
+	if deliveries == nil {
+		deliveries = []models.WebhookDelivery{}
+	}
+	api.ResponseWriter(w, deliveries, 0) // Use the utility function to write the response
+}
@@ -0,0 +1,167 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+)
+
+type mockStore struct {
+	mock.Mock
+}
+
+func (m *mockStore) CreateSubscription(sub *models.WebhookSubscription) error {
+	args := m.Called(sub)
+	return args.Error(0)
+}
+func (m *mockStore) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	args := m.Called(id)
+	return args.Get(0).(*models.WebhookSubscription), args.Error(1)
+}
+func (m *mockStore) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	args := m.Called()
+	return args.Get(0).([]models.WebhookSubscription), args.Error(1)
+}
+func (m *mockStore) DeleteSubscription(id string) error {
+	args := m.Called(id)
+	return args.Error(0)
+}
+func (m *mockStore) Enqueue(eventType string, payload []byte) error {
+	args := m.Called(eventType, payload)
+	return args.Error(0)
+}
+func (m *mockStore) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	args := m.Called(now, lease, limit)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+func (m *mockStore) SaveDelivery(delivery *models.WebhookDelivery) error {
+	args := m.Called(delivery)
+	return args.Error(0)
+}
+func (m *mockStore) ListDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	args := m.Called(subscriptionID)
+	return args.Get(0).([]models.WebhookDelivery), args.Error(1)
+}
+func (m *mockStore) SwapSnapshot(snapshot models.RecommendationSnapshot) (bool, error) {
+	args := m.Called(snapshot)
+	return args.Bool(0), args.Error(1)
+}
+
+func (m *mockStore) Lead(ctx context.Context) (<-chan struct{}, error) {
+	args := m.Called(ctx)
+	lost, _ := args.Get(0).(<-chan struct{})
+	return lost, args.Error(1)
+}
+
+func newHandlerWithMockStore(store *mockStore) *Handler {
+	return &Handler{store: store}
+}
+
+func TestCreate_GeneratesSecret(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("CreateSubscription", mock.AnythingOfType("*models.WebhookSubscription")).Return(nil)
+	body := `{"url":"https://example.com/hook","event_types":["event.created"]}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	h.Create(w, r, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	var sub models.WebhookSubscription
+	assert.NoError(t, json.Unmarshal(w.Body.Bytes(), &sub))
+	assert.True(t, strings.HasPrefix(sub.Secret, "whsec_"))
+	store.AssertExpectations(t)
+}
+
+func TestCreate_KeepsGivenSecret(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("CreateSubscription", mock.MatchedBy(func(sub *models.WebhookSubscription) bool {
+		return sub.Secret == "s3cret"
+	})).Return(nil)
+	body := `{"url":"https://example.com/hook","secret":"s3cret","event_types":["recommendation.changed"]}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/webhook", strings.NewReader(body))
+	h.Create(w, r, nil)
+	assert.Equal(t, http.StatusCreated, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestCreate_ValidationError(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	body := `{"url":"ftp://example.com","event_types":["event.exploded"]}`
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/webhook", bytes.NewReader([]byte(body)))
+	h.Create(w, r, nil)
+	assert.Equal(t, http.StatusUnprocessableEntity, w.Code)
+	assert.Contains(t, w.Body.String(), `"field":"url"`)
+	assert.Contains(t, w.Body.String(), `"field":"event_types"`)
+	store.AssertNotCalled(t, "CreateSubscription", mock.Anything)
+}
+
+func TestList_HidesSecrets(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("ListSubscriptions").Return([]models.WebhookSubscription{{ID: uuid.New(), URL: "https://example.com", Secret: "s3cret"}}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+	h.List(w, r, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.NotContains(t, w.Body.String(), "s3cret")
+}
+
+func TestList_Empty(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("ListSubscriptions").Return([]models.WebhookSubscription(nil), nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/webhooks", nil)
+	h.List(w, r, nil)
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.JSONEq(t, `[]`, w.Body.String())
+}
+
+func TestGet_NotFound(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("GetSubscription", "1").Return((*models.WebhookSubscription)(nil), models.ErrNotFound)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/webhook/1", nil)
+	h.Get(w, r, httprouter.Params{{Key: "id", Value: "1"}})
+	assert.Equal(t, http.StatusNotFound, w.Code)
+}
+
+func TestDelete_Success(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("DeleteSubscription", "1").Return(nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodDelete, "/webhook/1", nil)
+	h.Delete(w, r, httprouter.Params{{Key: "id", Value: "1"}})
+	assert.Equal(t, http.StatusNoContent, w.Code)
+	store.AssertExpectations(t)
+}
+
+func TestDeliveries_Success(t *testing.T) {
+	store := new(mockStore)
+	h := newHandlerWithMockStore(store)
+	store.On("GetSubscription", "1").Return(&models.WebhookSubscription{}, nil)
+	store.On("ListDeliveries", "1").Return([]models.WebhookDelivery{{EventType: models.WebhookEventCreated, Status: models.DeliverySucceeded, Attempts: 1}}, nil)
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodGet, "/webhook/1/deliveries", nil)
+	h.Deliveries(w, r, httprouter.Params{{Key: "id", Value: "1"}})
+	assert.Equal(t, http.StatusOK, w.Code)
+	assert.Contains(t, w.Body.String(), `"status":"succeeded"`)
+	store.AssertExpectations(t)
+}
@@ -0,0 +1,24 @@
+package webhooks
+
+import (
+	"net/http"
+
+	"github.com/jinzhu/gorm"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+)
+
+func InitializeRouter(r *httprouter.Router, db *gorm.DB) {
+	api.Register(r, Routes(NewHandler(db)))
+}
+
+// Routes lists the webhook endpoints served by handler.
+func Routes(handler *Handler) []api.Route {
+	return []api.Route{
+		{Method: http.MethodPost, Path: "/webhook", Handle: handler.Create},                   // Subscribe to webhook events
+		{Method: http.MethodGet, Path: "/webhooks", Handle: handler.List},                     // List webhook subscriptions
+		{Method: http.MethodGet, Path: "/webhook/:id", Handle: handler.Get},                   // Get webhook by ID
+		{Method: http.MethodDelete, Path: "/webhook/:id", Handle: handler.Delete},             // Delete webhook by ID
+		{Method: http.MethodGet, Path: "/webhook/:id/deliveries", Handle: handler.Deliveries}, // Delivery log of a webhook
+	}
+}
@@ -0,0 +1,55 @@
+package webhooks
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/julienschmidt/httprouter"
+	"github.com/stretchr/testify/assert"
+)
+
+// mockInitializeRouter registers dummy handlers for route existence testing
+func mockInitializeRouter(router *httprouter.Router) {
+	dummyHandler := func(w http.ResponseWriter, r *http.Request, _ httprouter.Params) {
+		w.WriteHeader(http.StatusOK)
+	}
+	router.POST("/webhook", dummyHandler)
+	router.GET("/webhooks", dummyHandler)
+	router.GET("/webhook/:id", dummyHandler)
+	router.DELETE("/webhook/:id", dummyHandler)
+	router.GET("/webhook/:id/deliveries", dummyHandler)
+}
+
+func TestInitializeRouter_Routes(t *testing.T) {
+	router := httprouter.New()
+	mockInitializeRouter(router)
+
+	tests := []struct {
+		method string
+		path   string
+	}{
+		{"POST", "/webhook"},
+		{"GET", "/webhooks"},
+		{"GET", "/webhook/123"},
+		{"DELETE", "/webhook/123"},
+		{"GET", "/webhook/123/deliveries"},
+	}
+
+	for _, tt := range tests {
+		req := httptest.NewRequest(tt.method, tt.path, nil)
+		w := httptest.NewRecorder()
+		router.ServeHTTP(w, req)
+		assert.Equal(t, http.StatusOK, w.Code, "Route %s %s should exist and return 200", tt.method, tt.path)
+	}
+}
+
+func TestInitializeRouter_RouteNotFound(t *testing.T) {
+	router := httprouter.New()
+	mockInitializeRouter(router)
+
+	req := httptest.NewRequest("GET", "/nonexistent", nil)
+	w := httptest.NewRecorder()
+	router.ServeHTTP(w, req)
+	assert.Equal(t, http.StatusNotFound, w.Code, "Nonexistent route should return 404")
+}
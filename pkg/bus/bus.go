@@ -0,0 +1,143 @@
+// Package bus broadcasts changes that can affect recommendations to the
+// parts of the server that need to react to them, such as live streams.
+package bus
+
+import "sync"
+
+// Change kinds.
+const (
+	UserChanged         = "user"
+	AvailabilityChanged = "availability"
+	EventChanged        = "event"
+	EventDeleted        = "event.deleted"
+	ResourceChanged     = "resource" // a resource, its availability or its bookings
+	// Resync is published after notifications may have been lost, telling
+	// subscribers to refresh whatever they track.
+	Resync = "resync"
+)
+
+// Change describes a committed write.
+type Change struct {
+	Kind    string `json:"kind"`
+	UserID  string `json:"user_id,omitempty"`
+	EventID string `json:"event_id,omitempty"`
+	// ResourceID is set for resource changes, which can affect every event
+	// that needs the resource.
+	ResourceID string `json:"resource_id,omitempty"`
+}
+
+// Bus fans changes out to subscribers.
+type Bus interface {
+	Publish(change Change)
+	// Subscribe returns a channel that receives a value after changes
+	// accepted by match. Notifications are coalesced: a subscriber that is
+	// busy when several changes arrive is woken once. The channel is closed
+	// when the bus is. The returned function unsubscribes.
+	Subscribe(match func(Change) bool) (<-chan struct{}, func())
+	// Listen calls fn with every change, in the order they are published.
+	// fn must return quickly. The returned function stops listening.
+	Listen(fn func(Change)) func()
+}
+
+// Discard is a Bus that drops every change and never wakes subscribers.
+var Discard Bus = discard{}
+
+type discard struct{}
+
+func (discard) Publish(Change) {}
+
+func (discard) Subscribe(func(Change) bool) (<-chan struct{}, func()) {
+	return make(chan struct{}), func() {}
+}
+
+func (discard) Listen(func(Change)) func() { return func() {} }
+
+// Memory is a Bus within a single process.
+type Memory struct {
+	mu        sync.Mutex
+	next      int
+	subs      map[int]subscriber
+	listeners map[int]func(Change)
+	closed    bool
+}
+
+type subscriber struct {
+	match  func(Change) bool
+	notify chan struct{}
+}
+
+func NewMemory() *Memory {
+	return &Memory{subs: map[int]subscriber{}, listeners: map[int]func(Change){}}
+}
+
+// Publish calls every listener, then wakes every subscriber interested in
+// change without blocking.
+func (m *Memory) Publish(change Change) {
+	m.mu.Lock()
+	listeners := make([]func(Change), 0, len(m.listeners))
+	for _, fn := range m.listeners {
+		listeners = append(listeners, fn)
+	}
+	m.mu.Unlock()
+	for _, fn := range listeners {
+		fn(change)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, sub := range m.subs {
+		if sub.match != nil && !sub.match(change) {
+			continue
+		}
+		select {
+		case sub.notify <- struct{}{}:
+		default: // already pending
+		}
+	}
+}
+
+func (m *Memory) Subscribe(match func(Change) bool) (<-chan struct{}, func()) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.next
+	m.next++
+	notify := make(chan struct{}, 1)
+	if m.closed {
+		close(notify)
+		return notify, func() {}
+	}
+	m.subs[id] = subscriber{match: match, notify: notify}
+	return notify, func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.subs, id)
+	}
+}
+
+func (m *Memory) Listen(fn func(Change)) func() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	id := m.next
+	m.next++
+	m.listeners[id] = fn
+	return func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+		delete(m.listeners, id)
+	}
+}
+
+// Close closes the channels of every subscriber, telling them that no more
+// changes will come.
+func (m *Memory) Close() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.closed {
+		return nil
+	}
+	m.closed = true
+	for id, sub := range m.subs {
+		close(sub.notify)
+		delete(m.subs, id)
+	}
+	return nil
+}
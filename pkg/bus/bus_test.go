@@ -0,0 +1,77 @@
+package bus
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func pending(c <-chan struct{}) int {
+	n := 0
+	for {
+		select {
+		case <-c:
+			n++
+		default:
+			return n
+		}
+	}
+}
+
+func TestMemory_MatchesAndCoalesces(t *testing.T) {
+	b := NewMemory()
+	all, cancelAll := b.Subscribe(nil)
+	defer cancelAll()
+	one, cancelOne := b.Subscribe(func(c Change) bool { return c.EventID == "" || c.EventID == "e1" })
+	defer cancelOne()
+
+	b.Publish(Change{Kind: EventChanged, EventID: "e2"})
+	assert.Equal(t, 1, pending(all))
+	assert.Equal(t, 0, pending(one))
+
+	b.Publish(Change{Kind: AvailabilityChanged, UserID: "u1"})
+	b.Publish(Change{Kind: EventChanged, EventID: "e1"})
+	assert.Equal(t, 1, pending(one), "notifications are coalesced")
+}
+
+func TestMemory_Listen(t *testing.T) {
+	b := NewMemory()
+	var got []Change
+	stop := b.Listen(func(c Change) { got = append(got, c) })
+	b.Publish(Change{Kind: UserChanged, UserID: "u1"})
+	b.Publish(Change{Kind: EventDeleted, EventID: "e1"})
+	stop()
+	b.Publish(Change{Kind: Resync})
+	assert.Equal(t, []Change{{Kind: UserChanged, UserID: "u1"}, {Kind: EventDeleted, EventID: "e1"}}, got)
+}
+
+func TestMemory_Unsubscribe(t *testing.T) {
+	b := NewMemory()
+	c, cancel := b.Subscribe(nil)
+	cancel()
+	b.Publish(Change{Kind: UserChanged})
+	assert.Equal(t, 0, pending(c))
+}
+
+func TestMemory_Close(t *testing.T) {
+	b := NewMemory()
+	c, cancel := b.Subscribe(nil)
+	assert.NoError(t, b.Close())
+	_, open := <-c
+	assert.False(t, open)
+	cancel()
+	b.Publish(Change{Kind: UserChanged})
+
+	late, _ := b.Subscribe(nil)
+	_, open = <-late
+	assert.False(t, open, "subscribing to a closed bus")
+	assert.NoError(t, b.Close())
+}
+
+func TestDecode(t *testing.T) {
+	change, err := decode(`{"kind":"availability","user_id":"u1"}`)
+	assert.NoError(t, err)
+	assert.Equal(t, Change{Kind: AvailabilityChanged, UserID: "u1"}, change)
+	_, err = decode(`not json`)
+	assert.Error(t, err)
+}
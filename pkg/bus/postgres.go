@@ -0,0 +1,88 @@
+package bus
+
+import (
+	"encoding/json"
+	"log"
+	"time"
+
+	"github.com/lib/pq"
+)
+
+// Channel is the Postgres notification channel that database triggers
+// announce changes on.
+const Channel = "stackgen_changes"
+
+// Postgres is a Bus shared by every replica using the same database. Writes
+// are announced by database triggers (see store.AutoMigrate) when their
+// transaction commits, so every replica sees every change exactly as the
+// database does, whichever replica or tool made it.
+type Postgres struct {
+	local    *Memory
+	listener *pq.Listener
+	done     chan struct{}
+}
+
+// NewPostgres listens for changes on the database at dsn. Connection losses
+// are retried in the background; subscribers receive a Resync change once
+// the connection is back.
+func NewPostgres(dsn string) (*Postgres, error) {
+	listener := pq.NewListener(dsn, time.Second, time.Minute, func(ev pq.ListenerEventType, err error) {
+		if err != nil {
+			log.Printf("bus: listener: %v", err)
+		}
+	})
+	if err := listener.Listen(Channel); err != nil {
+		listener.Close()
+		return nil, err
+	}
+	p := &Postgres{local: NewMemory(), listener: listener, done: make(chan struct{})}
+	go p.run()
+	return p, nil
+}
+
+func (p *Postgres) run() {
+	defer close(p.done)
+	for n := range p.listener.Notify {
+		// a nil notification means the connection was re-established and
+		// notifications sent meanwhile are lost
+		if n == nil {
+			p.local.Publish(Change{Kind: Resync})
+			continue
+		}
+		change, err := decode(n.Extra)
+		if err != nil {
+			log.Printf("bus: decoding %q: %v", n.Extra, err)
+			continue
+		}
+		p.local.Publish(change)
+	}
+}
+
+func decode(payload string) (Change, error) {
+	var change Change
+	err := json.Unmarshal([]byte(payload), &change)
+	return change, err
+}
+
+// Publish delivers change to this replica's subscribers at once, so that it
+// reads its own writes without waiting for the database. Other replicas learn
+// of the write from the database triggers.
+func (p *Postgres) Publish(change Change) {
+	p.local.Publish(change)
+}
+
+func (p *Postgres) Subscribe(match func(Change) bool) (<-chan struct{}, func()) {
+	return p.local.Subscribe(match)
+}
+
+func (p *Postgres) Listen(fn func(Change)) func() {
+	return p.local.Listen(fn)
+}
+
+// Close stops listening and closes the channels of every subscriber.
+func (p *Postgres) Close() error {
+	err := p.listener.Close()
+	<-p.done
+	p.local.Close()
+	return err
+}
@@ -0,0 +1,47 @@
+package cache
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLRU_EvictsLeastRecentlyUsed(t *testing.T) {
+	c := NewLRU(2)
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+	c.Get("a")
+	c.Set("c", []byte("3"), time.Minute)
+
+	_, ok := c.Get("b")
+	assert.False(t, ok, "b was least recently used")
+	v, ok := c.Get("a")
+	assert.True(t, ok)
+	assert.Equal(t, "1", string(v))
+	assert.Equal(t, 2, c.Len())
+}
+
+func TestLRU_Expires(t *testing.T) {
+	now := time.Now()
+	c := NewLRU(10)
+	c.now = func() time.Time { return now }
+	c.Set("a", []byte("1"), time.Minute)
+	now = now.Add(time.Minute)
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 0, c.Len())
+}
+
+func TestLRU_DeleteAndClear(t *testing.T) {
+	c := NewLRU(10)
+	c.Set("a", []byte("1"), time.Minute)
+	c.Set("b", []byte("2"), time.Minute)
+	c.Set("c", []byte("3"), time.Minute)
+	c.Delete("a", "missing")
+	_, ok := c.Get("a")
+	assert.False(t, ok)
+	assert.Equal(t, 2, c.Len())
+	c.Clear()
+	assert.Equal(t, 0, c.Len())
+}
@@ -0,0 +1,399 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/julienschmidt/httprouter"
+	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/api/events"
+	"github.com/rsys-speerzad/stackgen/pkg/api/users"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore is a map backed implementation of the user and event stores.
+type fakeStore struct {
+	mu             sync.Mutex
+	users          map[string]*models.User
+	availabilities map[string]models.UserAvailability
+	events         map[string]*models.Event
+}
+
+func newFakeStore() *fakeStore {
+	return &fakeStore{
+		users:          map[string]*models.User{},
+		availabilities: map[string]models.UserAvailability{},
+		events:         map[string]*models.Event{},
+	}
+}
+
+type fakeUserStore struct{ *fakeStore }
+
+func (s fakeUserStore) Create(user *models.User) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.Email == user.Email {
+			return models.ErrConflict
+		}
+	}
+	user.ID = uuid.New()
+	copied := *user
+	s.users[user.ID.String()] = &copied
+	return nil
+}
+
+func (s fakeUserStore) Get(id string) (*models.User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	user, ok := s.users[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (s fakeUserStore) Update(id string, update *models.UserUpdate) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.users[id]
+	if !ok {
+		return models.ErrNotFound
+	}
+	if update.Name != nil {
+		existing.Name = *update.Name
+	}
+	if update.Email != nil {
+		existing.Email = *update.Email
+	}
+	return nil
+}
+
+func (s fakeUserStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(s.users, id)
+	return nil
+}
+
+func (s fakeUserStore) GetAvailability(userID string) ([]models.UserAvailability, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []models.UserAvailability
+	for _, a := range s.availabilities {
+		if a.UserID.String() == userID {
+			out = append(out, a)
+		}
+	}
+	return out, nil
+}
+
+func (s fakeUserStore) AddAvailability(slots []models.UserAvailability) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, slot := range slots {
+		if _, ok := s.users[slot.UserID.String()]; !ok {
+			return models.ErrNotFound
+		}
+		slot.ID = uuid.New()
+		s.availabilities[slot.ID.String()] = slot
+	}
+	return nil
+}
+
+func (s fakeUserStore) UpdateAvailability(slotID string, slot models.UserAvailability) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	existing, ok := s.availabilities[slotID]
+	if !ok {
+		return models.ErrNotFound
+	}
+	existing.Slot = slot.Slot
+	s.availabilities[slotID] = existing
+	return nil
+}
+
+func (s fakeUserStore) DeleteAvailability(userID, slotID string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if slot, ok := s.availabilities[slotID]; !ok || slot.UserID.String() != userID {
+		return models.ErrNotFound
+	}
+	delete(s.availabilities, slotID)
+	return nil
+}
+
+type fakeEventStore struct{ *fakeStore }
+
+func (s fakeEventStore) Create(event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event.ID = uuid.New()
+	for i := range event.EventSlots {
+		event.EventSlots[i].ID = uuid.New()
+	}
+	copied := *event
+	s.events[event.ID.String()] = &copied
+	return nil
+}
+
+func (s fakeEventStore) Get(id string) (*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	copied := *event
+	return &copied, nil
+}
+
+func (s fakeEventStore) Update(event *models.Event) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	copied := *event
+	s.events[event.ID.String()] = &copied
+	return nil
+}
+
+func (s fakeEventStore) Delete(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.events[id]; !ok {
+		return models.ErrNotFound
+	}
+	delete(s.events, id)
+	return nil
+}
+
+// GetRecommendations picks the first slot and reports every user as available.
+func (s fakeEventStore) GetRecommendations(eventID string) (*models.RecommendedSlot, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[eventID]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	rec := &models.RecommendedSlot{}
+	if len(event.EventSlots) > 0 {
+		rec.StartTime, rec.EndTime = event.EventSlots[0].StartTime, event.EventSlots[0].EndTime
+	}
+	for _, user := range s.users {
+		rec.UserIDs = append(rec.UserIDs, user.ID.String())
+	}
+	return rec, nil
+}
+
+// GetHeatmap reports every user as available for each slot, as one bucket.
+func (s fakeEventStore) GetHeatmap(eventID string, granularity time.Duration) (*models.Heatmap, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[eventID]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	heatmap := &models.Heatmap{Granularity: int(granularity / time.Minute), Participants: len(s.users)}
+	for _, slot := range event.EventSlots {
+		bucket := models.HeatmapBucket{StartTime: slot.StartTime, EndTime: slot.EndTime, Count: len(s.users)}
+		for _, user := range s.users {
+			bucket.UserIDs = append(bucket.UserIDs, user.ID.String())
+		}
+		heatmap.Buckets = append(heatmap.Buckets, bucket)
+	}
+	return heatmap, nil
+}
+
+func (s fakeEventStore) Finalize(id string, slot models.Slot) (*models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	event.FinalStartTime, event.FinalEndTime = &slot.StartTime, &slot.EndTime
+	copied := *event
+	return &copied, nil
+}
+
+func (s fakeEventStore) Vote(eventID, slotID string, vote *models.SlotVote) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	event, ok := s.events[eventID]
+	if !ok {
+		return models.ErrNotFound
+	}
+	for _, slot := range event.EventSlots {
+		if slot.ID.String() == slotID {
+			vote.SlotID, vote.EventID = slot.ID, event.ID
+			return nil
+		}
+	}
+	return models.ErrNotFound
+}
+
+func (s fakeEventStore) ListOpen(now time.Time) ([]models.Event, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var open []models.Event
+	for _, event := range s.events {
+		if !event.IsFinalized() {
+			open = append(open, *event)
+		}
+	}
+	return open, nil
+}
+
+func newTestServer(t *testing.T) *Client {
+	t.Helper()
+	fake := newFakeStore()
+	r := httprouter.New()
+	api.Register(r, users.Routes(users.NewHandlerWithStore(fakeUserStore{fake})))
+	api.Register(r, events.Routes(events.NewHandlerWithStore(fakeEventStore{fake})))
+	server := httptest.NewServer(r)
+	t.Cleanup(server.Close)
+	return New(server.URL, WithRetries(0, 0))
+}
+
+func TestClient_RoundTrip(t *testing.T) {
+	ctx := context.Background()
+	c := newTestServer(t)
+
+	// users
+	user, err := c.CreateUser(ctx, &models.User{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	assert.NotEqual(t, uuid.Nil, user.ID)
+	got, err := c.GetUser(ctx, user.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "alice@example.com", got.Email)
+	name := "Alice B"
+	require.NoError(t, c.UpdateUser(ctx, user.ID.String(), &models.UserUpdate{Name: &name}))
+	got, err = c.GetUser(ctx, user.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice B", got.Name)
+	assert.Equal(t, "alice@example.com", got.Email)
+
+	// availability
+	start := time.Date(2025, 1, 12, 14, 0, 0, 0, time.UTC)
+	slots := []models.Slot{{StartTime: start, EndTime: start.Add(2 * time.Hour)}}
+	require.NoError(t, c.AddAvailability(ctx, user.ID.String(), slots))
+	available, err := c.GetAvailability(ctx, user.ID.String())
+	require.NoError(t, err)
+	require.Len(t, available, 1)
+	assert.True(t, slots[0].StartTime.Equal(available[0].StartTime))
+
+	// events and recommendations
+	event, err := c.CreateEvent(ctx, &models.Event{
+		Title:             "Brainstorming meeting",
+		EstimatedDuration: 60,
+		EventSlots:        []models.EventSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+	})
+	require.NoError(t, err)
+	event.Description = "bring ideas"
+	updated, err := c.UpdateEvent(ctx, event)
+	require.NoError(t, err)
+	assert.Equal(t, "bring ideas", updated.Description)
+	vote, err := c.Vote(ctx, event.ID.String(), event.EventSlots[0].ID.String(), &models.SlotVote{UserID: user.ID, Vote: models.VoteYes})
+	require.NoError(t, err)
+	assert.Equal(t, event.EventSlots[0].ID, vote.SlotID)
+	_, err = c.Vote(ctx, event.ID.String(), uuid.NewString(), &models.SlotVote{UserID: user.ID, Vote: models.VoteYes})
+	assert.ErrorIs(t, err, models.ErrNotFound)
+	rec, err := c.GetRecommendations(ctx, event.ID.String())
+	require.NoError(t, err)
+	assert.True(t, start.Equal(rec.StartTime))
+	assert.Equal(t, []string{user.ID.String()}, rec.UserIDs)
+	heatmap, err := c.GetHeatmap(ctx, event.ID.String(), 30*time.Minute)
+	require.NoError(t, err)
+	assert.Equal(t, 30, heatmap.Granularity)
+	require.Len(t, heatmap.Buckets, 1)
+	assert.Equal(t, []string{user.ID.String()}, heatmap.Buckets[0].UserIDs)
+
+	finalized, err := c.FinalizeEvent(ctx, event.ID.String(), models.Slot{StartTime: rec.StartTime, EndTime: rec.EndTime})
+	require.NoError(t, err)
+	assert.True(t, finalized.IsFinalized())
+
+	// deletion
+	require.NoError(t, c.DeleteEvent(ctx, event.ID.String()))
+	_, err = c.GetEvent(ctx, event.ID.String())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+	require.NoError(t, c.DeleteUser(ctx, user.ID.String()))
+	err = c.DeleteUser(ctx, user.ID.String())
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestClient_TypedErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestServer(t)
+
+	_, err := c.CreateUser(ctx, &models.User{Name: "Alice", Email: "not-an-email"})
+	assert.ErrorIs(t, err, models.ErrValidation)
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusUnprocessableEntity, apiErr.StatusCode)
+	assert.Equal(t, []models.FieldError{{Field: "email", Message: "must be a valid email address"}}, apiErr.FieldErrors())
+
+	_, err = c.CreateUser(ctx, &models.User{Name: "Alice", Email: "alice@example.com"})
+	require.NoError(t, err)
+	_, err = c.CreateUser(ctx, &models.User{Name: "Alice", Email: "alice@example.com"})
+	assert.ErrorIs(t, err, models.ErrConflict)
+	assert.NotErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestClient_RetriesIdempotentRequests(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`{"name":"Alice","email":"alice@example.com"}`))
+	}))
+	defer server.Close()
+	c := New(server.URL, WithRetries(3, time.Millisecond))
+
+	user, err := c.GetUser(context.Background(), "1")
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", user.Name)
+	assert.EqualValues(t, 3, atomic.LoadInt32(&calls))
+}
+
+func TestClient_DoesNotRetryPost(t *testing.T) {
+	var calls int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&calls, 1)
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := New(server.URL, WithRetries(3, time.Millisecond))
+
+	_, err := c.CreateUser(context.Background(), &models.User{Name: "Alice", Email: "alice@example.com"})
+	var apiErr *Error
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, http.StatusServiceUnavailable, apiErr.StatusCode)
+	assert.EqualValues(t, 1, atomic.LoadInt32(&calls))
+}
+
+func TestClient_ContextCancelStopsRetries(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+	c := New(server.URL, WithRetries(10, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	_, err := c.GetEvent(ctx, "1")
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
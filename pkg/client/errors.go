@@ -0,0 +1,60 @@
+package client
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// Error is returned for every non-2xx response. It matches the sentinel
+// errors in models with errors.Is, e.g. errors.Is(err, models.ErrNotFound).
+type Error struct {
+	StatusCode int
+	Problem    models.Problem
+}
+
+func newError(resp *http.Response) *Error {
+	e := &Error{StatusCode: resp.StatusCode}
+	body, _ := io.ReadAll(io.LimitReader(resp.Body, 1<<20))
+	if err := json.Unmarshal(body, &e.Problem); err != nil || e.Problem.Status == 0 {
+		e.Problem = models.Problem{
+			Title:  http.StatusText(resp.StatusCode),
+			Status: resp.StatusCode,
+			Detail: string(body),
+		}
+	}
+	return e
+}
+
+func (e *Error) Error() string {
+	if e.Problem.Detail != "" {
+		return fmt.Sprintf("stackgen: %d %s: %s", e.StatusCode, e.Problem.Title, e.Problem.Detail)
+	}
+	return fmt.Sprintf("stackgen: %d %s", e.StatusCode, e.Problem.Title)
+}
+
+// FieldErrors returns the per-field validation errors reported by the server.
+func (e *Error) FieldErrors() []models.FieldError {
+	return e.Problem.Errors
+}
+
+func (e *Error) Is(target error) bool {
+	switch target {
+	case models.ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case models.ErrValidation:
+		return e.StatusCode == http.StatusUnprocessableEntity
+	case models.ErrInvalidPayload:
+		return e.StatusCode == http.StatusBadRequest
+	case models.ErrConflict:
+		return e.StatusCode == http.StatusConflict
+	case models.ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case models.ErrForbidden:
+		return e.StatusCode == http.StatusForbidden
+	}
+	return false
+}
@@ -0,0 +1,84 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// CreateEvent creates an event with its proposed slots.
+func (c *Client) CreateEvent(ctx context.Context, event *models.Event) (*models.Event, error) {
+	var created models.Event
+	if err := c.do(ctx, http.MethodPost, "/event", event, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetEvent gets the event by ID.
+func (c *Client) GetEvent(ctx context.Context, id string) (*models.Event, error) {
+	var event models.Event
+	if err := c.do(ctx, http.MethodGet, pathf("/event/%s", id), nil, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// UpdateEvent replaces the event identified by event.ID.
+func (c *Client) UpdateEvent(ctx context.Context, event *models.Event) (*models.Event, error) {
+	var updated models.Event
+	if err := c.do(ctx, http.MethodPut, pathf("/event/%s", event.ID.String()), event, &updated); err != nil {
+		return nil, err
+	}
+	return &updated, nil
+}
+
+// DeleteEvent deletes the event by ID.
+func (c *Client) DeleteEvent(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, pathf("/event/%s", id), nil, nil)
+}
+
+// GetRecommendations returns the slot of the event that works for the most users.
+func (c *Client) GetRecommendations(ctx context.Context, eventID string) (*models.RecommendedSlot, error) {
+	var recommendation models.RecommendedSlot
+	if err := c.do(ctx, http.MethodGet, pathf("/events/%s/recommendations", eventID), nil, &recommendation); err != nil {
+		return nil, err
+	}
+	return &recommendation, nil
+}
+
+// GetHeatmap returns how many, and which, users are available in every
+// bucket of granularity across the event's slots. A zero granularity leaves
+// it to the server.
+func (c *Client) GetHeatmap(ctx context.Context, eventID string, granularity time.Duration) (*models.Heatmap, error) {
+	path := pathf("/event/%s/heatmap", eventID)
+	if granularity > 0 {
+		path += "?granularity=" + url.QueryEscape(granularity.String())
+	}
+	var heatmap models.Heatmap
+	if err := c.do(ctx, http.MethodGet, path, nil, &heatmap); err != nil {
+		return nil, err
+	}
+	return &heatmap, nil
+}
+
+// FinalizeEvent fixes the time of the event to slot.
+func (c *Client) FinalizeEvent(ctx context.Context, eventID string, slot models.Slot) (*models.Event, error) {
+	var event models.Event
+	if err := c.do(ctx, http.MethodPost, pathf("/event/%s/finalize", eventID), slot, &event); err != nil {
+		return nil, err
+	}
+	return &event, nil
+}
+
+// Vote records a participant's answer for one of the event's slots.
+func (c *Client) Vote(ctx context.Context, eventID, slotID string, vote *models.SlotVote) (*models.SlotVote, error) {
+	var recorded models.SlotVote
+	if err := c.do(ctx, http.MethodPut, pathf("/event/%s/slots/%s/vote", eventID, slotID), vote, &recorded); err != nil {
+		return nil, err
+	}
+	return &recorded, nil
+}
@@ -0,0 +1,58 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// CreateResource creates a resource and returns it with its generated ID.
+func (c *Client) CreateResource(ctx context.Context, resource *models.Resource) (*models.Resource, error) {
+	var created models.Resource
+	if err := c.do(ctx, http.MethodPost, "/resource", resource, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetResource gets the resource by ID.
+func (c *Client) GetResource(ctx context.Context, id string) (*models.Resource, error) {
+	var resource models.Resource
+	if err := c.do(ctx, http.MethodGet, pathf("/resource/%s", id), nil, &resource); err != nil {
+		return nil, err
+	}
+	return &resource, nil
+}
+
+// UpdateResource replaces the details of the resource.
+func (c *Client) UpdateResource(ctx context.Context, id string, resource *models.Resource) error {
+	return c.do(ctx, http.MethodPut, pathf("/resource/%s", id), resource, nil)
+}
+
+// DeleteResource deletes the resource by ID, along with its availability and
+// bookings.
+func (c *Client) DeleteResource(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, pathf("/resource/%s", id), nil, nil)
+}
+
+// GetResourceSchedule lists when the resource is available and booked.
+func (c *Client) GetResourceSchedule(ctx context.Context, resourceID string) (*models.ResourceSchedule, error) {
+	var schedule models.ResourceSchedule
+	if err := c.do(ctx, http.MethodGet, pathf("/resource/%s/availability", resourceID), nil, &schedule); err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// AddResourceAvailability adds periods in which the resource can be booked.
+func (c *Client) AddResourceAvailability(ctx context.Context, resourceID string, slots []models.Period) error {
+	req := models.ResourceAvailabilityRequest{Slots: slots}
+	return c.do(ctx, http.MethodPost, pathf("/resource/%s/availability", resourceID), req, nil)
+}
+
+// DeleteResourceAvailability removes a single availability period of the
+// resource.
+func (c *Client) DeleteResourceAvailability(ctx context.Context, resourceID, availabilityID string) error {
+	return c.do(ctx, http.MethodDelete, pathf("/resource/%s/availability/%s", resourceID, availabilityID), nil, nil)
+}
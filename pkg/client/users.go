@@ -0,0 +1,61 @@
+package client
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// CreateUser creates a user and returns it with its generated ID.
+func (c *Client) CreateUser(ctx context.Context, user *models.User) (*models.User, error) {
+	var created models.User
+	if err := c.do(ctx, http.MethodPost, "/user", user, &created); err != nil {
+		return nil, err
+	}
+	return &created, nil
+}
+
+// GetUser gets the user by ID.
+func (c *Client) GetUser(ctx context.Context, id string) (*models.User, error) {
+	var user models.User
+	if err := c.do(ctx, http.MethodGet, pathf("/user/%s", id), nil, &user); err != nil {
+		return nil, err
+	}
+	return &user, nil
+}
+
+// UpdateUser changes the fields of the user that update sets.
+func (c *Client) UpdateUser(ctx context.Context, id string, update *models.UserUpdate) error {
+	return c.do(ctx, http.MethodPut, pathf("/user/%s", id), update, nil)
+}
+
+// DeleteUser deletes the user by ID.
+func (c *Client) DeleteUser(ctx context.Context, id string) error {
+	return c.do(ctx, http.MethodDelete, pathf("/user/%s", id), nil, nil)
+}
+
+// GetAvailability lists the general availability of the user.
+func (c *Client) GetAvailability(ctx context.Context, userID string) ([]models.Slot, error) {
+	var resp models.Availabilities
+	if err := c.do(ctx, http.MethodGet, pathf("/user/%s/availability", userID), nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Slots, nil
+}
+
+// AddAvailability adds slots in which the user is available.
+func (c *Client) AddAvailability(ctx context.Context, userID string, slots []models.Slot) error {
+	req := models.AvailabilityRequest{Slots: slots}
+	return c.do(ctx, http.MethodPost, pathf("/user/%s/availability", userID), req, nil)
+}
+
+// UpdateAvailability replaces a single availability slot of the user.
+func (c *Client) UpdateAvailability(ctx context.Context, userID, availabilityID string, slot models.Slot) error {
+	return c.do(ctx, http.MethodPut, pathf("/user/%s/availability/%s", userID, availabilityID), slot, nil)
+}
+
+// DeleteAvailability removes a single availability slot of the user.
+func (c *Client) DeleteAvailability(ctx context.Context, userID, availabilityID string) error {
+	return c.do(ctx, http.MethodDelete, pathf("/user/%s/availability/%s", userID, availabilityID), nil, nil)
+}
@@ -1,13 +1,55 @@
 package models
 
-import "errors"
+import (
+	"errors"
+	"strings"
+)
 
 var (
 	ErrMissingArgument    = errors.New("missing argument")
 	ErrInvalidMessageType = errors.New("invalid message-type")
+	ErrInvalidArgument    = errors.New("invalid argument")
+	ErrInvalidPayload     = errors.New("invalid request payload")
 	ErrNotFound           = errors.New("not found")
+	ErrValidation         = errors.New("validation failed")
+	ErrConflict           = errors.New("conflict")
+	ErrUnauthorized       = errors.New("unauthorized")
+	ErrForbidden          = errors.New("forbidden")
 )
 
-type ErrorResponse struct {
+// FieldError describes a single invalid field of a request payload.
+type FieldError struct {
+	Field   string `json:"field"`
 	Message string `json:"message"`
 }
+
+// ValidationError carries every field error found in a request payload.
+// It matches ErrValidation with errors.Is.
+type ValidationError struct {
+	Fields []FieldError
+}
+
+func (e *ValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return ErrValidation.Error()
+	}
+	msgs := make([]string, 0, len(e.Fields))
+	for _, f := range e.Fields {
+		msgs = append(msgs, f.Field+": "+f.Message)
+	}
+	return ErrValidation.Error() + ": " + strings.Join(msgs, "; ")
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrValidation
+}
+
+// Problem is an RFC 7807 problem details document.
+type Problem struct {
+	Type     string       `json:"type"`
+	Title    string       `json:"title"`
+	Status   int          `json:"status"`
+	Detail   string       `json:"detail,omitempty"`
+	Instance string       `json:"instance,omitempty"`
+	Errors   []FieldError `json:"errors,omitempty"`
+}
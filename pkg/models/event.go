@@ -1,31 +1,286 @@
 package models
 
 import (
+	"database/sql/driver"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/lib/pq"
 )
 
 type Event struct {
 	ID                uuid.UUID   `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Title             string      `gorm:"column:title;not null" json:"title"`
+	Title             string      `gorm:"column:title;not null" json:"title" validate:"required"`
 	Description       string      `gorm:"column:description;type:text" json:"description"`
-	EstimatedDuration int         `gorm:"column:estimated_duration;type:int;not null" json:"estimated_duration"`
+	EstimatedDuration int         `gorm:"column:estimated_duration;type:int;not null" json:"estimated_duration" validate:"gt=0"`
 	EventSlots        []EventSlot `gorm:"foreignKey:EventID;constraint:OnDelete:CASCADE" json:"event_slots"`
 	OrganizerID       *uuid.UUID  `gorm:"column:organizer_id;type:uuid" json:"organizer_id"`
 	Organizer         *User       `gorm:"foreignKey:ID" json:"-"`
+	FinalStartTime    *time.Time  `gorm:"column:final_start_time" json:"final_start_time"` // set once the event is finalized
+	FinalEndTime      *time.Time  `gorm:"column:final_end_time" json:"final_end_time"`
+	// ParticipantIDs are the users invited to the event; when empty every
+	// user is considered a participant.
+	ParticipantIDs   pq.StringArray `gorm:"column:participant_ids;type:uuid[]" json:"participant_ids" validate:"uuid"`
+	ResponseDeadline *time.Time     `gorm:"column:response_deadline" json:"response_deadline"` // participants are reminded before it passes
+	ReminderSentAt   *time.Time     `gorm:"column:reminder_sent_at" json:"-"`
+	// Search, when set, has the recommender find the slot itself instead of
+	// choosing among EventSlots.
+	Search *SlotSearch `gorm:"column:search;type:jsonb" json:"search"`
+	// Fairness ranks slots that suit the same number of participants by how
+	// little they fall outside the participants' working hours, and reports
+	// the best candidates.
+	Fairness bool `gorm:"column:fairness" json:"fairness"`
+	// Quorum, when set, is the attendance that makes a slot viable;
+	// recommendations prefer slots that meet it.
+	Quorum *Quorum `gorm:"column:quorum;type:jsonb" json:"quorum"`
+	// BufferBefore and BufferAfter are the minutes attendees need free
+	// around the event, e.g. to travel; users' own buffers apply if longer.
+	BufferBefore int `gorm:"column:buffer_before" json:"buffer_before" validate:"min=0"`
+	BufferAfter  int `gorm:"column:buffer_after" json:"buffer_after" validate:"min=0"`
+	// Sessions, when set, has the event held several times, such as a
+	// series of workshops; the recommender picks the best combination.
+	Sessions *Sessions `gorm:"column:sessions;type:jsonb" json:"sessions"`
+	// Recurrence, when set, makes the event a weekly series held at the
+	// time of its slot; recommendations count every occurrence.
+	Recurrence *Recurrence `gorm:"column:recurrence;type:jsonb" json:"recurrence"`
+	// ResourceIDs are the resources the event needs, every one of them,
+	// and RoomIDs the rooms that suit it, one of which it needs. Slots are
+	// only recommended when they are free, and finalizing books them.
+	ResourceIDs pq.StringArray `gorm:"column:resource_ids;type:uuid[]" json:"resource_ids" validate:"uuid"`
+	RoomIDs     pq.StringArray `gorm:"column:room_ids;type:uuid[]" json:"room_ids" validate:"uuid"`
+	RoomID      *uuid.UUID     `gorm:"column:room_id;type:uuid" json:"room_id"` // booked once the event is finalized
+}
+
+// IsFinalized reports whether a final time has been chosen for the event.
+func (e *Event) IsFinalized() bool {
+	return e.FinalStartTime != nil && e.FinalEndTime != nil
+}
+
+// MaxSearchRange bounds how far apart the From and To of a SlotSearch may be.
+const MaxSearchRange = 366 * 24 * time.Hour
+
+// SlotSearch describes where to look for an event's slot when the organizer
+// doesn't propose any: every EstimatedDuration long window between From and
+// To that lies within the daily hours, on the wall clock of TimeZone.
+type SlotSearch struct {
+	From         time.Time `json:"from" validate:"required"`
+	To           time.Time `json:"to" validate:"required,gtfield=From"`
+	TimeZone     string    `json:"time_zone" validate:"timezone"`                      // IANA name, UTC if empty
+	DayStart     string    `json:"day_start" validate:"required,clock"`                // such as 09:00
+	DayEnd       string    `json:"day_end" validate:"required,clock,gtfield=DayStart"` // such as 17:00, 24:00 for the whole day
+	WeekdaysOnly bool      `json:"weekdays_only"`
+}
+
+// Value stores the search as JSON.
+func (s SlotSearch) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads a search stored as JSON.
+func (s *SlotSearch) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("cannot scan %T into SlotSearch", value)
+}
+
+// Quorum is the attendance that makes an event viable: at least MinAttendees
+// participants, including every one of RequiredIDs.
+type Quorum struct {
+	MinAttendees int      `json:"min_attendees" validate:"gt=0"`
+	RequiredIDs  []string `json:"required_ids" validate:"uuid"`
+	// AutoFinalize has the event finalized on the best slot that meets the
+	// quorum once its response deadline passes.
+	AutoFinalize bool `json:"auto_finalize"`
+}
+
+// Value stores the quorum as JSON.
+func (q Quorum) Value() (driver.Value, error) {
+	return json.Marshal(q)
+}
+
+// Scan reads a quorum stored as JSON.
+func (q *Quorum) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, q)
+	case string:
+		return json.Unmarshal([]byte(v), q)
+	}
+	return fmt.Errorf("cannot scan %T into Quorum", value)
+}
+
+// Sessions asks for Count non-overlapping slots of the event's duration, up
+// to 20.
+type Sessions struct {
+	Count        int    `json:"count" validate:"gt=0,max=20"`
+	DistinctDays bool   `json:"distinct_days"`                 // at most one session a day
+	MinGap       int    `json:"min_gap" validate:"min=0"`      // minutes between the end of a session and the start of the next
+	TimeZone     string `json:"time_zone" validate:"timezone"` // IANA name days are told apart in, that of the search or UTC if empty
+}
+
+// Value stores the sessions as JSON.
+func (s Sessions) Value() (driver.Value, error) {
+	return json.Marshal(s)
+}
+
+// Scan reads sessions stored as JSON.
+func (s *Sessions) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, s)
+	case string:
+		return json.Unmarshal([]byte(v), s)
+	}
+	return fmt.Errorf("cannot scan %T into Sessions", value)
+}
+
+// Recurrence repeats an event every week, Weeks times in all, at the same
+// time on the wall clock of TimeZone.
+type Recurrence struct {
+	Weeks    int    `json:"weeks" validate:"gt=0,max=52"`
+	TimeZone string `json:"time_zone" validate:"timezone"` // IANA name, that of the search or UTC if empty
+}
+
+// Value stores the recurrence as JSON.
+func (r Recurrence) Value() (driver.Value, error) {
+	return json.Marshal(r)
+}
+
+// Scan reads a recurrence stored as JSON.
+func (r *Recurrence) Scan(value interface{}) error {
+	switch v := value.(type) {
+	case []byte:
+		return json.Unmarshal(v, r)
+	case string:
+		return json.Unmarshal([]byte(v), r)
+	}
+	return fmt.Errorf("cannot scan %T into Recurrence", value)
+}
+
+// ParseClock parses a time of day such as 09:00 or 24:00 into an offset from
+// midnight.
+func ParseClock(clock string) (time.Duration, error) {
+	hours, minutes, ok := strings.Cut(clock, ":")
+	h, errH := strconv.Atoi(hours)
+	m, errM := strconv.Atoi(minutes)
+	if !ok || len(hours) != 2 || len(minutes) != 2 || errH != nil || errM != nil ||
+		h < 0 || m < 0 || m > 59 || h > 24 || (h == 24 && m > 0) {
+		return 0, fmt.Errorf("invalid time of day %q", clock)
+	}
+	return time.Duration(h)*time.Hour + time.Duration(m)*time.Minute, nil
 }
 
 type EventSlot struct {
 	ID        uuid.UUID  `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
 	EventID   *uuid.UUID `gorm:"column:event_id;type:uuid" json:"event_id"`
-	StartTime time.Time  `gorm:"column:start_time;not null" json:"start_time"`
-	EndTime   time.Time  `gorm:"column:end_time;not null" json:"end_time"`
+	StartTime time.Time  `gorm:"column:start_time;not null" json:"start_time" validate:"required"`
+	EndTime   time.Time  `gorm:"column:end_time;not null" json:"end_time" validate:"required,gtfield=StartTime"`
+	Votes     []SlotVote `gorm:"foreignKey:SlotID;constraint:OnDelete:CASCADE" json:"-"`
 }
 
+// Answers participants can vote for an event slot.
+const (
+	VoteYes   = "yes"
+	VoteMaybe = "maybe" // counts as availability given only if needed
+	VoteNo    = "no"
+)
+
+// SlotVote is a participant's answer for one of an event's proposed slots.
+// For that slot it overrides whatever the participant's availability implies.
+type SlotVote struct {
+	SlotID  uuid.UUID `gorm:"column:slot_id;type:uuid;primaryKey" json:"slot_id"`
+	UserID  uuid.UUID `gorm:"column:user_id;type:uuid;primaryKey" json:"user_id" validate:"required"`
+	EventID uuid.UUID `gorm:"column:event_id;type:uuid;not null" json:"event_id"`
+	Vote    string    `gorm:"column:vote;not null" json:"vote" validate:"required,oneof=yes maybe no"`
+}
+
+// RecommendedSlot is the slot that suits the most participants of an event.
+// Users are identified by ID throughout.
 type RecommendedSlot struct {
-	StartTime      time.Time `json:"start_time"`
-	EndTime        time.Time `json:"end_time"`
-	UserIDs        []string  `json:"user_ids"`         // users who can attend
-	MissingUserIDs []string  `json:"missing_user_ids"` // users who can't
+	StartTime       time.Time   `json:"start_time"`
+	EndTime         time.Time   `json:"end_time"`
+	UserIDs         []string    `json:"user_ids"`                     // users who can attend
+	IfNeededUserIDs []string    `json:"if_needed_user_ids,omitempty"` // those of them who can only if needed
+	MissingUserIDs  []string    `json:"missing_user_ids"`             // users who can't
+	MeetsQuorum     *bool       `json:"meets_quorum,omitempty"`       // for events with a quorum
+	Candidates      []Candidate `json:"candidates,omitempty"`         // best first, for events with fairness or a quorum
+	Sessions        []Session   `json:"sessions,omitempty"`           // by start time, for events with sessions; the first is the slot above
+	// Occurrences are, for recurring events, the weeks of the series,
+	// starting with the slot above. UserIDs above are then the users who
+	// can attend every week, and each occurrence's MissingUserIDs are that
+	// week's conflicts.
+	Occurrences []Occurrence `json:"occurrences,omitempty"`
+	// RoomID is, for events with rooms, one that is free for the slot.
+	RoomID string `json:"room_id,omitempty"`
+	// Missing explains why each user in MissingUserIDs can't attend.
+	Missing []MissingUser `json:"missing,omitempty"`
+}
+
+// Reasons a user can't attend a recommended slot, in the order they are
+// told apart: only the first that applies is given.
+const (
+	ReasonDeclined       = "declined"              // voted no for the slot
+	ReasonConflict       = "conflicting_event"     // busy with another finalized event
+	ReasonNoAvailability = "no_availability"       // hasn't submitted any availability
+	ReasonPartial        = "partially_available"   // available for only some of the slot
+	ReasonOutsideHours   = "outside_working_hours" // the slot falls outside their working hours
+	ReasonUnavailable    = "unavailable"           // not available at that time
+)
+
+// MissingUser is a user who can't attend a recommended slot, and why.
+type MissingUser struct {
+	UserID string `json:"user_id"` // as in MissingUserIDs
+	Reason string `json:"reason"`
+	// OverlapMinutes is, for those partially available, how much of the
+	// slot they are available for.
+	OverlapMinutes int `json:"overlap_minutes,omitempty"`
+	// EventID and EventTitle are those of the conflicting event.
+	EventID    string `json:"event_id,omitempty"`
+	EventTitle string `json:"event_title,omitempty"`
+}
+
+// Occurrence is one week of a recommended recurring event.
+type Occurrence struct {
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	UserIDs         []string  `json:"user_ids"`
+	IfNeededUserIDs []string  `json:"if_needed_user_ids,omitempty"`
+	MissingUserIDs  []string  `json:"missing_user_ids"`
+}
+
+// Session is one of the slots recommended for an event with sessions.
+type Session struct {
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	UserIDs         []string  `json:"user_ids"`
+	IfNeededUserIDs []string  `json:"if_needed_user_ids,omitempty"`
+	MissingUserIDs  []string  `json:"missing_user_ids"`
+}
+
+// Candidate is a slot scored for fairness.
+type Candidate struct {
+	StartTime       time.Time      `json:"start_time"`
+	EndTime         time.Time      `json:"end_time"`
+	UserIDs         []string       `json:"user_ids"`
+	IfNeededUserIDs []string       `json:"if_needed_user_ids,omitempty"`
+	MissingUserIDs  []string       `json:"missing_user_ids"`
+	MeetsQuorum     *bool          `json:"meets_quorum,omitempty"` // for events with a quorum
+	PainScore       int            `json:"pain_score"`             // minutes attendees spend outside their working hours
+	Attendees       []AttendeeSlot `json:"attendees"`
+}
+
+// AttendeeSlot is how a candidate slot lands for one attendee.
+type AttendeeSlot struct {
+	UserID    string    `json:"user_id"`
+	StartTime time.Time `json:"start_time"` // on the attendee's wall clock
+	EndTime   time.Time `json:"end_time"`
+	PainScore int       `json:"pain_score"` // minutes outside the attendee's working hours
 }
@@ -0,0 +1,30 @@
+package models
+
+import "time"
+
+// Bounds of the granularity of a Heatmap.
+const (
+	MinHeatmapGranularity = 5 * time.Minute
+	MaxHeatmapGranularity = 24 * time.Hour
+	// MaxHeatmapBuckets bounds the buckets of a Heatmap, so that a fine
+	// granularity over a long search is refused rather than computed.
+	MaxHeatmapBuckets = 5000
+)
+
+// Heatmap is the availability of an event's participants over the range of
+// its slots, in buckets of Granularity minutes.
+type Heatmap struct {
+	Granularity  int             `json:"granularity"`  // minutes
+	Participants int             `json:"participants"` // how many were considered, the most a bucket can count
+	Buckets      []HeatmapBucket `json:"buckets"`
+}
+
+// HeatmapBucket tells who is available for all of a span of a Heatmap.
+// Users are identified by ID, as in recommendations.
+type HeatmapBucket struct {
+	StartTime       time.Time `json:"start_time"`
+	EndTime         time.Time `json:"end_time"`
+	Count           int       `json:"count"`
+	UserIDs         []string  `json:"user_ids"`
+	IfNeededUserIDs []string  `json:"if_needed_user_ids,omitempty"` // those of them who are only if needed
+}
@@ -0,0 +1,57 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// Kinds of resources.
+const (
+	ResourceRoom      = "room"
+	ResourceEquipment = "equipment"
+)
+
+// Resource is something events need besides their participants, such as a
+// room or a projector. It can be booked by one event at a time, and only
+// within its availability if it has any.
+type Resource struct {
+	ID             uuid.UUID               `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name           string                  `gorm:"column:name;not null" json:"name" validate:"required"`
+	Kind           string                  `gorm:"column:kind;not null" json:"kind" validate:"required,oneof=room equipment"`
+	Capacity       int                     `gorm:"column:capacity" json:"capacity" validate:"min=0"` // seats of a room, 0 if unknown
+	Availabilities []*ResourceAvailability `gorm:"foreignKey:ResourceID;constraint:OnDelete:CASCADE" json:"-"`
+}
+
+// Period is a span of time a resource is available or booked for.
+type Period struct {
+	StartTime time.Time `gorm:"column:start_time;not null" json:"start_time" validate:"required"`
+	EndTime   time.Time `gorm:"column:end_time;not null" json:"end_time" validate:"required,gtfield=StartTime"`
+}
+
+// ResourceAvailability is a period a resource can be booked in.
+type ResourceAvailability struct {
+	ID         uuid.UUID `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ResourceID uuid.UUID `gorm:"column:resource_id;type:uuid;not null" json:"-"`
+	Period
+}
+
+// ResourceBooking holds a resource for a finalized event.
+type ResourceBooking struct {
+	ID         uuid.UUID `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	ResourceID uuid.UUID `gorm:"column:resource_id;type:uuid;not null;index" json:"resource_id"`
+	EventID    uuid.UUID `gorm:"column:event_id;type:uuid;not null;index" json:"event_id"`
+	Period
+}
+
+// ResourceAvailabilityRequest is the payload for adding availability to a
+// resource.
+type ResourceAvailabilityRequest struct {
+	Slots []Period `json:"slots" validate:"required"`
+}
+
+// ResourceSchedule lists when a resource is available and booked.
+type ResourceSchedule struct {
+	Availability []ResourceAvailability `json:"available_slots"`
+	Bookings     []ResourceBooking      `json:"bookings"`
+}
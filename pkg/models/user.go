@@ -7,10 +7,56 @@ import (
 )
 
 type User struct {
-	ID             uuid.UUID           `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
-	Name           string              `gorm:"column:name;not null" json:"name"`
-	Email          string              `gorm:"column:email;unique;not null" json:"email"`
+	ID    uuid.UUID `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	Name  string    `gorm:"column:name;not null" json:"name" validate:"required"`
+	Email string    `gorm:"column:email;unique;not null" json:"email" validate:"required,email"`
+	// TimeZone and the working hours on its wall clock are when the user
+	// prefers to meet; fair recommendations avoid slots outside them.
+	TimeZone       string              `gorm:"column:time_zone" json:"time_zone" validate:"timezone"`
+	WorkdayStart   string              `gorm:"column:workday_start" json:"workday_start" validate:"clock"`                  // such as 09:00
+	WorkdayEnd     string              `gorm:"column:workday_end" json:"workday_end" validate:"clock,gtfield=WorkdayStart"` // such as 17:30
 	Availabilities []*UserAvailability `gorm:"foreignKey:UserID;constraint:OnDelete:CASCADE" json:"-"`
+	// BufferBefore and BufferAfter are the minutes the user keeps free
+	// around their meetings.
+	BufferBefore int `gorm:"column:buffer_before" json:"buffer_before" validate:"min=0"`
+	BufferAfter  int `gorm:"column:buffer_after" json:"buffer_after" validate:"min=0"`
+}
+
+// UserUpdate is the payload for updating a user. Fields left out keep their
+// value; those given replace it, even if empty or zero.
+type UserUpdate struct {
+	Name         *string `json:"name" validate:"nonempty"`
+	Email        *string `json:"email" validate:"nonempty,email"`
+	TimeZone     *string `json:"time_zone" validate:"timezone"`
+	WorkdayStart *string `json:"workday_start" validate:"clock"`
+	WorkdayEnd   *string `json:"workday_end" validate:"clock,gtfield=WorkdayStart"`
+	BufferBefore *int    `json:"buffer_before" validate:"min=0"`
+	BufferAfter  *int    `json:"buffer_after" validate:"min=0"`
+}
+
+// Columns returns the columns of the users table the update sets, by name.
+func (u *UserUpdate) Columns() map[string]interface{} {
+	columns := map[string]interface{}{}
+	for column, value := range map[string]*string{
+		"name":          u.Name,
+		"email":         u.Email,
+		"time_zone":     u.TimeZone,
+		"workday_start": u.WorkdayStart,
+		"workday_end":   u.WorkdayEnd,
+	} {
+		if value != nil {
+			columns[column] = *value
+		}
+	}
+	for column, value := range map[string]*int{
+		"buffer_before": u.BufferBefore,
+		"buffer_after":  u.BufferAfter,
+	} {
+		if value != nil {
+			columns[column] = *value
+		}
+	}
+	return columns
 }
 
 type UserAvailability struct {
@@ -20,7 +66,24 @@ type UserAvailability struct {
 	Slot
 }
 
+// Preference levels of availability.
+const (
+	PreferenceYes      = "yes"       // the default
+	PreferenceIfNeeded = "if_needed" // can, but would rather not
+)
+
 type Slot struct {
-	StartTime time.Time `gorm:"column:start_time;not null" json:"start_time"`
-	EndTime   time.Time `gorm:"column:end_time;not null" json:"end_time"`
+	StartTime  time.Time `gorm:"column:start_time;not null" json:"start_time" validate:"required"`
+	EndTime    time.Time `gorm:"column:end_time;not null" json:"end_time" validate:"required,gtfield=StartTime"`
+	Preference string    `gorm:"column:preference" json:"preference,omitempty" validate:"oneof=yes if_needed"` // of availability
+}
+
+// AvailabilityRequest is the payload for adding availability slots to a user.
+type AvailabilityRequest struct {
+	Slots []Slot `json:"slots" validate:"required"`
+}
+
+// Availabilities lists the general availability slots of a user.
+type Availabilities struct {
+	Slots []Slot `json:"available_slots"`
 }
@@ -0,0 +1,76 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+)
+
+// Webhook event types.
+const (
+	WebhookEventCreated           = "event.created"
+	WebhookEventUpdated           = "event.updated"
+	WebhookEventDeleted           = "event.deleted"
+	WebhookEventFinalized         = "event.finalized"
+	WebhookAvailabilitySubmitted  = "availability.submitted"
+	WebhookAvailabilityDeleted    = "availability.deleted"
+	WebhookRecommendationsChanged = "recommendation.changed"
+)
+
+// Webhook delivery states.
+const (
+	DeliveryPending   = "pending"
+	DeliverySucceeded = "succeeded"
+	DeliveryFailed    = "failed"
+)
+
+type WebhookSubscription struct {
+	ID         uuid.UUID      `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	URL        string         `gorm:"column:url;not null" json:"url" validate:"required,url"`
+	Secret     string         `gorm:"column:secret;not null" json:"secret,omitempty"` // only returned on creation
+	EventTypes pq.StringArray `gorm:"column:event_types;type:text[];not null" json:"event_types" validate:"required,oneof=event.created event.updated event.deleted event.finalized availability.submitted availability.deleted recommendation.changed"`
+	CreatedAt  time.Time      `gorm:"column:created_at" json:"created_at"`
+}
+
+// Subscribes reports whether the subscription wants events of eventType.
+func (s *WebhookSubscription) Subscribes(eventType string) bool {
+	for _, t := range s.EventTypes {
+		if t == eventType {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery is a row of the webhook outbox; it doubles as the delivery log.
+type WebhookDelivery struct {
+	ID             uuid.UUID  `gorm:"column:id;type:uuid;default:gen_random_uuid();primaryKey" json:"id"`
+	SubscriptionID uuid.UUID  `gorm:"column:subscription_id;type:uuid;not null;index" json:"subscription_id"`
+	EventType      string     `gorm:"column:event_type;not null" json:"event_type"`
+	Payload        string     `gorm:"column:payload;type:text;not null" json:"payload"`
+	Status         string     `gorm:"column:status;not null;index:idx_webhook_deliveries_due" json:"status"`
+	Attempts       int        `gorm:"column:attempts;not null" json:"attempts"`
+	NextAttemptAt  time.Time  `gorm:"column:next_attempt_at;not null;index:idx_webhook_deliveries_due" json:"next_attempt_at"`
+	ResponseStatus int        `gorm:"column:response_status" json:"response_status,omitempty"`
+	LastError      string     `gorm:"column:last_error;type:text" json:"last_error,omitempty"`
+	CreatedAt      time.Time  `gorm:"column:created_at" json:"created_at"`
+	DeliveredAt    *time.Time `gorm:"column:delivered_at" json:"delivered_at,omitempty"`
+}
+
+// WebhookEvent is the JSON body posted to subscribers.
+type WebhookEvent struct {
+	ID        uuid.UUID       `json:"id"`
+	Type      string          `json:"type"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
+
+// RecommendationSnapshot remembers the last best slot announced for an event,
+// so that recommendation.changed is only sent when it actually changes.
+type RecommendationSnapshot struct {
+	EventID   uuid.UUID `gorm:"column:event_id;type:uuid;primary_key"`
+	StartTime time.Time `gorm:"column:start_time"`
+	EndTime   time.Time `gorm:"column:end_time"`
+}
@@ -0,0 +1,73 @@
+package notify
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+const icsTime = "20060102T150405Z"
+
+// Calendar returns an iCalendar (RFC 5545) invitation for a finalized event.
+func Calendar(event *models.Event, organizer *models.User, attendees []models.User, now time.Time) []byte {
+	var b strings.Builder
+	line := func(format string, args ...interface{}) {
+		b.WriteString(fold(fmt.Sprintf(format, args...)))
+		b.WriteString("\r\n")
+	}
+	line("BEGIN:VCALENDAR")
+	line("VERSION:2.0")
+	line("PRODID:-//stackgen//stackgen//EN")
+	line("METHOD:REQUEST")
+	line("BEGIN:VEVENT")
+	line("UID:%s@stackgen", event.ID)
+	line("DTSTAMP:%s", now.UTC().Format(icsTime))
+	if event.IsFinalized() {
+		line("DTSTART:%s", event.FinalStartTime.UTC().Format(icsTime))
+		line("DTEND:%s", event.FinalEndTime.UTC().Format(icsTime))
+	}
+	line("SUMMARY:%s", escapeText(event.Title))
+	if event.Description != "" {
+		line("DESCRIPTION:%s", escapeText(event.Description))
+	}
+	if organizer != nil {
+		line("ORGANIZER;CN=%s:mailto:%s", escapeParam(organizer.Name), organizer.Email)
+	}
+	for _, a := range attendees {
+		line("ATTENDEE;CN=%s;ROLE=REQ-PARTICIPANT;RSVP=TRUE:mailto:%s", escapeParam(a.Name), a.Email)
+	}
+	line("STATUS:CONFIRMED")
+	line("END:VEVENT")
+	line("END:VCALENDAR")
+	return []byte(b.String())
+}
+
+func escapeText(s string) string {
+	return strings.NewReplacer(`\`, `\\`, ";", `\;`, ",", `\,`, "\r\n", `\n`, "\n", `\n`).Replace(s)
+}
+
+func escapeParam(s string) string {
+	if strings.ContainsAny(s, ":;,") {
+		return `"` + strings.ReplaceAll(s, `"`, "") + `"`
+	}
+	return s
+}
+
+// fold splits content lines longer than 75 octets, as RFC 5545 requires,
+// without breaking multi-byte characters.
+func fold(s string) string {
+	var b strings.Builder
+	width := 0
+	for _, r := range s {
+		n := len(string(r))
+		if width+n > 75 {
+			b.WriteString("\r\n ")
+			width = 1
+		}
+		b.WriteRune(r)
+		width += n
+	}
+	return b.String()
+}
@@ -0,0 +1,67 @@
+// Package notify emails participants when they are invited to an event, when
+// its response deadline approaches and when its final time is chosen.
+package notify
+
+import (
+	"context"
+	"log"
+	"os"
+	"strings"
+)
+
+// Message is a single email.
+type Message struct {
+	To          []string
+	Subject     string
+	Body        string // plain text
+	Attachments []Attachment
+}
+
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Notifier delivers messages. Implementations must be safe for concurrent use.
+type Notifier interface {
+	Send(ctx context.Context, msg *Message) error
+}
+
+// Discard is a Notifier that drops every message.
+var Discard Notifier = discard{}
+
+type discard struct{}
+
+func (discard) Send(context.Context, *Message) error { return nil }
+
+// Log is a Notifier that only logs the messages it is given, for development
+// setups without a mail server.
+var Log Notifier = logNotifier{}
+
+type logNotifier struct{}
+
+func (logNotifier) Send(_ context.Context, msg *Message) error {
+	log.Printf("notify: %q to %s", msg.Subject, strings.Join(msg.To, ", "))
+	return nil
+}
+
+// FromEnv returns an SMTP notifier configured by the SMTP_* environment
+// variables, or Log when SMTP_HOST is not set.
+func FromEnv() Notifier {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return Log
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	return NewSMTP(SMTPConfig{
+		Host:     host,
+		Port:     port,
+		Username: os.Getenv("SMTP_USERNAME"),
+		Password: os.Getenv("SMTP_PASSWORD"),
+		From:     os.Getenv("SMTP_FROM"),
+	})
+}
@@ -0,0 +1,264 @@
+package notify
+
+import (
+	"context"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/cache"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+	"github.com/rsys-speerzad/stackgen/pkg/store/notifications"
+	"github.com/rsys-speerzad/stackgen/pkg/webhooks"
+)
+
+const (
+	sendTimeout       = 30 * time.Second
+	reminderInterval  = time.Minute
+	defaultReminderIn = 24 * time.Hour
+	// recommendationCacheSize bounds the recommendations kept for events
+	// due to be finalized automatically
+	recommendationCacheSize = 1000
+)
+
+// Notifications is told about event changes that participants should hear of.
+type Notifications interface {
+	EventCreated(event *models.Event)
+	EventFinalized(event *models.Event)
+}
+
+// Disabled is a Notifications that sends nothing.
+var Disabled Notifications = disabled{}
+
+type disabled struct{}
+
+func (disabled) EventCreated(*models.Event)   {}
+func (disabled) EventFinalized(*models.Event) {}
+
+// Service renders emails for events and hands them to a Notifier. Sending
+// happens in the background: failures are logged, never returned to the
+// request that triggered them.
+type Service struct {
+	store    notifications.Store
+	notifier Notifier
+	now      func() time.Time
+	// lead is how long before the response deadline reminders go out
+	lead time.Duration
+	// recommend returns the recommendation for an event, which events due
+	// to be finalized automatically are finalized on
+	recommend func(eventID string) (*models.RecommendedSlot, error)
+	// publisher announces events finalized automatically to webhooks
+	publisher webhooks.Publisher
+	// changes announces events finalized automatically to streams and caches
+	changes bus.Bus
+	// async runs sends; tests run them inline
+	async func(func())
+}
+
+// NewService returns a service that sends with notifier and announces the
+// events it finalizes on changes.
+func NewService(db *gorm.DB, notifier Notifier, changes bus.Bus) *Service {
+	s := newService(notifications.NewStore(db), notifier)
+	s.recommend = cachedRecommendations(db, changes)
+	s.publisher = webhooks.NewService(db)
+	s.changes = changes
+	if lead, err := time.ParseDuration(os.Getenv("REMINDER_LEAD")); err == nil && lead > 0 {
+		s.lead = lead
+	}
+	return s
+}
+
+func newService(store notifications.Store, notifier Notifier) *Service {
+	return &Service{
+		store:     store,
+		notifier:  notifier,
+		now:       time.Now,
+		lead:      defaultReminderIn,
+		publisher: webhooks.Discard,
+		changes:   bus.Discard,
+		async:     func(f func()) { go f() },
+	}
+}
+
+// cachedRecommendations returns recommendations that are only recomputed once
+// something they depend on changes, so that events which miss their quorum
+// aren't recomputed on every tick. The cache is set up on first use: only the
+// service running the reminders needs it.
+func cachedRecommendations(db *gorm.DB, changes bus.Bus) func(eventID string) (*models.RecommendedSlot, error) {
+	var (
+		once  sync.Once
+		store events.Store
+	)
+	return func(eventID string) (*models.RecommendedSlot, error) {
+		once.Do(func() {
+			store = events.NewCachedStore(db, cache.NewLRU(recommendationCacheSize), changes)
+		})
+		return store.GetRecommendations(eventID)
+	}
+}
+
+// EventCreated invites the participants of a new event.
+func (s *Service) EventCreated(event *models.Event) {
+	if len(event.ParticipantIDs) == 0 {
+		return
+	}
+	s.async(func() {
+		participants, err := s.store.Users(event.ParticipantIDs)
+		if err != nil {
+			log.Printf("notify: participants of event %s: %v", event.ID, err)
+			return
+		}
+		s.sendAll(Invitation, event, s.organizer(event), participants, nil)
+	})
+}
+
+// EventFinalized tells the participants and the organizer the final time,
+// with a calendar invitation attached.
+func (s *Service) EventFinalized(event *models.Event) {
+	s.async(func() {
+		participants, err := s.store.Users(event.ParticipantIDs)
+		if err != nil {
+			log.Printf("notify: participants of event %s: %v", event.ID, err)
+			return
+		}
+		organizer := s.organizer(event)
+		recipients := participants
+		if organizer != nil && !includes(participants, organizer.ID.String()) {
+			recipients = append(recipients, *organizer)
+		}
+		invite := Attachment{
+			Filename:    "invite.ics",
+			ContentType: "text/calendar; charset=utf-8; method=REQUEST",
+			Data:        Calendar(event, organizer, participants, s.now()),
+		}
+		s.sendAll(Finalized, event, organizer, recipients, []Attachment{invite})
+	})
+}
+
+// RunReminders sends deadline reminders, and finalizes the events due to be
+// finalized once their deadline passes, until ctx is done.
+func (s *Service) RunReminders(ctx context.Context) {
+	ticker := time.NewTicker(reminderInterval)
+	defer ticker.Stop()
+	for {
+		if err := s.SendReminders(); err != nil {
+			log.Printf("notify: reminders: %v", err)
+		}
+		if err := s.FinalizeDue(); err != nil {
+			log.Printf("notify: finalizing: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// SendReminders reminds the participants that have not shared their
+// availability of every event whose response deadline is near. Each event is
+// reminded about once, even with several replicas running.
+func (s *Service) SendReminders() error {
+	now := s.now()
+	due, err := s.store.DueReminders(now, s.lead)
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		event := &due[i]
+		claimed, err := s.store.ClaimReminder(event.ID.String(), now)
+		if err != nil {
+			return err
+		}
+		if !claimed {
+			continue
+		}
+		pending, err := s.store.Unresponsive(event)
+		if err != nil {
+			return err
+		}
+		s.sendAll(Reminder, event, s.organizer(event), pending, nil)
+	}
+	return nil
+}
+
+// FinalizeDue finalizes every event set to be finalized automatically whose
+// response deadline has passed, on its recommended slot if that meets the
+// quorum, and tells the participants. Events without such a slot are left
+// to the organizer. Each event is finalized once, even with several replicas
+// running. An event that fails is logged and retried on the next call.
+func (s *Service) FinalizeDue() error {
+	due, err := s.store.DueFinalizations(s.now())
+	if err != nil {
+		return err
+	}
+	for i := range due {
+		if err := s.finalize(&due[i]); err != nil {
+			log.Printf("notify: finalizing event %s: %v", due[i].ID, err)
+		}
+	}
+	return nil
+}
+
+// finalize finalizes event on its recommended slot if that meets the quorum.
+func (s *Service) finalize(event *models.Event) error {
+	rec, err := s.recommend(event.ID.String())
+	if err != nil {
+		return err
+	}
+	if rec.MeetsQuorum == nil || !*rec.MeetsQuorum {
+		return nil
+	}
+	// the recommended slot may be longer than the meeting itself
+	slot := models.Slot{StartTime: rec.StartTime, EndTime: rec.StartTime.Add(time.Duration(event.EstimatedDuration) * time.Minute)}
+	room, claimed, err := s.store.ClaimFinalization(event.ID.String(), slot)
+	if err != nil || !claimed {
+		return err
+	}
+	event.FinalStartTime, event.FinalEndTime, event.RoomID = &slot.StartTime, &slot.EndTime, room
+	s.publisher.Publish(models.WebhookEventFinalized, event)
+	s.changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: event.ID.String()})
+	s.EventFinalized(event)
+	return nil
+}
+
+func (s *Service) organizer(event *models.Event) *models.User {
+	if event.OrganizerID == nil {
+		return nil
+	}
+	users, err := s.store.Users([]string{event.OrganizerID.String()})
+	if err != nil || len(users) == 0 {
+		return nil
+	}
+	return &users[0]
+}
+
+// sendAll sends a personal copy of the email to every recipient.
+func (s *Service) sendAll(kind string, event *models.Event, organizer *models.User, recipients []models.User, attachments []Attachment) {
+	for _, recipient := range recipients {
+		subject, body, err := Render(kind, TemplateData{Recipient: recipient, Organizer: organizer, Event: event})
+		if err != nil {
+			log.Printf("notify: rendering %s: %v", kind, err)
+			return
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), sendTimeout)
+		err = s.notifier.Send(ctx, &Message{To: []string{recipient.Email}, Subject: subject, Body: body, Attachments: attachments})
+		cancel()
+		if err != nil {
+			log.Printf("notify: sending %s for event %s to %s: %v", kind, event.ID, recipient.Email, err)
+		}
+	}
+}
+
+func includes(users []models.User, id string) bool {
+	for _, u := range users {
+		if u.ID.String() == id {
+			return true
+		}
+	}
+	return false
+}
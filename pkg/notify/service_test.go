@@ -0,0 +1,233 @@
+package notify
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recorder is a Notifier that keeps the messages it is given.
+type recorder struct {
+	mu       sync.Mutex
+	messages []*Message
+}
+
+func (r *recorder) Send(_ context.Context, msg *Message) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.messages = append(r.messages, msg)
+	return nil
+}
+
+func (r *recorder) to() []string {
+	var to []string
+	for _, msg := range r.messages {
+		to = append(to, msg.To...)
+	}
+	return to
+}
+
+type fakeStore struct {
+	users       map[string]models.User
+	due         []models.Event
+	claimed     map[string]bool
+	responsive  map[string]bool
+	lastLookout time.Time
+	finalizable []models.Event
+	finalized   map[string]models.Slot
+	room        *uuid.UUID
+}
+
+func (f *fakeStore) Users(ids []string) ([]models.User, error) {
+	var users []models.User
+	for _, id := range ids {
+		if u, ok := f.users[id]; ok {
+			users = append(users, u)
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeStore) DueReminders(now time.Time, lead time.Duration) ([]models.Event, error) {
+	f.lastLookout = now.Add(lead)
+	return f.due, nil
+}
+
+func (f *fakeStore) ClaimReminder(eventID string, at time.Time) (bool, error) {
+	if f.claimed[eventID] {
+		return false, nil
+	}
+	f.claimed[eventID] = true
+	return true, nil
+}
+
+func (f *fakeStore) Unresponsive(event *models.Event) ([]models.User, error) {
+	var users []models.User
+	for _, id := range event.ParticipantIDs {
+		if !f.responsive[id] {
+			users = append(users, f.users[id])
+		}
+	}
+	return users, nil
+}
+
+func (f *fakeStore) DueFinalizations(now time.Time) ([]models.Event, error) {
+	return f.finalizable, nil
+}
+
+func (f *fakeStore) ClaimFinalization(eventID string, slot models.Slot) (*uuid.UUID, bool, error) {
+	if _, ok := f.finalized[eventID]; ok {
+		return nil, false, nil
+	}
+	f.finalized[eventID] = slot
+	return f.room, true, nil
+}
+
+// published is a webhooks.Publisher that keeps the events it is given.
+type published []*models.Event
+
+func (p *published) Publish(_ string, data interface{}) {
+	*p = append(*p, data.(*models.Event))
+}
+
+func newTestService() (*Service, *fakeStore, *recorder, []models.User) {
+	users := []models.User{
+		{ID: uuid.New(), Name: "Olga", Email: "olga@example.com"},
+		{ID: uuid.New(), Name: "Alice", Email: "alice@example.com"},
+		{ID: uuid.New(), Name: "Bob", Email: "bob@example.com"},
+	}
+	store := &fakeStore{users: map[string]models.User{}, claimed: map[string]bool{}, responsive: map[string]bool{}, finalized: map[string]models.Slot{}}
+	for _, u := range users {
+		store.users[u.ID.String()] = u
+	}
+	rec := &recorder{}
+	s := newService(store, rec)
+	s.async = func(f func()) { f() }
+	return s, store, rec, users
+}
+
+func TestService_EventCreatedInvitesParticipants(t *testing.T) {
+	s, _, rec, users := newTestService()
+	event := testEvent()
+	event.OrganizerID = &users[0].ID
+	event.ParticipantIDs = []string{users[1].ID.String(), users[2].ID.String()}
+
+	s.EventCreated(event)
+
+	assert.Equal(t, []string{"alice@example.com", "bob@example.com"}, rec.to())
+	assert.Equal(t, "Invitation: Quarterly planning", rec.messages[0].Subject)
+	assert.Contains(t, rec.messages[0].Body, "Olga has invited you")
+}
+
+func TestService_EventCreatedWithoutParticipants(t *testing.T) {
+	s, _, rec, _ := newTestService()
+	s.EventCreated(testEvent())
+	assert.Empty(t, rec.messages)
+}
+
+func TestService_EventFinalizedAttachesCalendar(t *testing.T) {
+	s, _, rec, users := newTestService()
+	event := testEvent()
+	event.OrganizerID = &users[0].ID
+	event.ParticipantIDs = []string{users[1].ID.String()}
+	start, end := event.EventSlots[0].StartTime, event.EventSlots[0].StartTime.Add(time.Hour)
+	event.FinalStartTime, event.FinalEndTime = &start, &end
+
+	s.EventFinalized(event)
+
+	assert.Equal(t, []string{"alice@example.com", "olga@example.com"}, rec.to())
+	require.Len(t, rec.messages[0].Attachments, 1)
+	assert.Equal(t, "invite.ics", rec.messages[0].Attachments[0].Filename)
+	assert.Contains(t, string(rec.messages[0].Attachments[0].Data), "ATTENDEE;CN=Alice")
+}
+
+func TestService_SendRemindersOnlyToUnresponsiveOnce(t *testing.T) {
+	s, store, rec, users := newTestService()
+	now := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+	s.now = func() time.Time { return now }
+	event := testEvent()
+	event.ParticipantIDs = []string{users[1].ID.String(), users[2].ID.String()}
+	store.due = []models.Event{*event}
+	store.responsive[users[1].ID.String()] = true
+
+	require.NoError(t, s.SendReminders())
+	assert.Equal(t, now.Add(defaultReminderIn), store.lastLookout)
+	assert.Equal(t, []string{"bob@example.com"}, rec.to())
+	assert.Equal(t, "Reminder: share your availability for Quarterly planning", rec.messages[0].Subject)
+
+	// already claimed, e.g. by another replica
+	require.NoError(t, s.SendReminders())
+	assert.Len(t, rec.messages, 1)
+}
+
+func TestService_FinalizeDueOnlyOnQuorumOnce(t *testing.T) {
+	s, store, rec, users := newTestService()
+	event := testEvent()
+	event.ParticipantIDs = []string{users[1].ID.String()}
+	event.Quorum = &models.Quorum{MinAttendees: 1, AutoFinalize: true}
+	stalled := testEvent()
+	stalled.ID = uuid.New()
+	stalled.Quorum = event.Quorum
+	store.finalizable = []models.Event{*event, *stalled}
+	start := event.EventSlots[0].StartTime
+	meets, misses := true, false
+	s.recommend = func(eventID string) (*models.RecommendedSlot, error) {
+		if eventID == stalled.ID.String() {
+			return &models.RecommendedSlot{StartTime: start, EndTime: start.Add(2 * time.Hour), MeetsQuorum: &misses}, nil
+		}
+		return &models.RecommendedSlot{StartTime: start, EndTime: start.Add(2 * time.Hour), MeetsQuorum: &meets}, nil
+	}
+
+	room := uuid.New()
+	store.room = &room
+	var webhooks published
+	s.publisher = &webhooks
+	changes := bus.NewMemory()
+	var announced []bus.Change
+	changes.Listen(func(c bus.Change) { announced = append(announced, c) })
+	s.changes = changes
+
+	require.NoError(t, s.FinalizeDue())
+	assert.Equal(t, map[string]models.Slot{
+		event.ID.String(): {StartTime: start, EndTime: start.Add(time.Hour)},
+	}, store.finalized)
+	assert.Equal(t, []string{"alice@example.com"}, rec.to())
+	assert.Equal(t, "Scheduled: Quarterly planning on Sun Jan 12 2025 14:00 UTC", rec.messages[0].Subject)
+	require.Len(t, webhooks, 1)
+	assert.Equal(t, &room, webhooks[0].RoomID)
+	assert.Equal(t, []bus.Change{{Kind: bus.EventChanged, EventID: event.ID.String()}}, announced)
+
+	// already finalized, e.g. by another replica
+	require.NoError(t, s.FinalizeDue())
+	assert.Len(t, rec.messages, 1)
+	assert.Len(t, announced, 1)
+}
+
+func TestService_FinalizeDueContinuesPastFailures(t *testing.T) {
+	s, store, _, _ := newTestService()
+	failing, event := testEvent(), testEvent()
+	failing.ID = uuid.New()
+	failing.Quorum = &models.Quorum{MinAttendees: 1, AutoFinalize: true}
+	event.Quorum = failing.Quorum
+	store.finalizable = []models.Event{*failing, *event}
+	start := event.EventSlots[0].StartTime
+	meets := true
+	s.recommend = func(eventID string) (*models.RecommendedSlot, error) {
+		if eventID == failing.ID.String() {
+			return nil, errors.New("connection reset")
+		}
+		return &models.RecommendedSlot{StartTime: start, EndTime: start.Add(time.Hour), MeetsQuorum: &meets}, nil
+	}
+
+	require.NoError(t, s.FinalizeDue())
+	assert.Contains(t, store.finalized, event.ID.String())
+	assert.NotContains(t, store.finalized, failing.ID.String())
+}
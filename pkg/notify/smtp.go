@@ -0,0 +1,154 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/base64"
+	"fmt"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"time"
+)
+
+type SMTPConfig struct {
+	Host     string
+	Port     string
+	Username string // authentication is skipped when empty
+	Password string
+	From     string
+}
+
+// SMTP sends messages through a mail server, upgrading to TLS when the server
+// offers STARTTLS.
+type SMTP struct {
+	config SMTPConfig
+	// tls is used for STARTTLS; tests override it to trust their server
+	tls  *tls.Config
+	now  func() time.Time
+	dial func(ctx context.Context, network, addr string) (net.Conn, error)
+}
+
+func NewSMTP(config SMTPConfig) *SMTP {
+	if config.From == "" {
+		config.From = "stackgen@" + config.Host
+	}
+	return &SMTP{
+		config: config,
+		tls:    &tls.Config{ServerName: config.Host},
+		now:    time.Now,
+		dial:   (&net.Dialer{Timeout: 10 * time.Second}).DialContext,
+	}
+}
+
+// Send delivers msg, giving up when ctx is done.
+func (s *SMTP) Send(ctx context.Context, msg *Message) error {
+	body, err := s.encode(msg)
+	if err != nil {
+		return err
+	}
+	conn, err := s.dial(ctx, "tcp", net.JoinHostPort(s.config.Host, s.config.Port))
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	c, err := smtp.NewClient(conn, s.config.Host)
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	defer c.Close()
+	if ok, _ := c.Extension("STARTTLS"); ok {
+		if err := c.StartTLS(s.tls); err != nil {
+			return fmt.Errorf("smtp: starttls: %w", err)
+		}
+	}
+	if s.config.Username != "" {
+		auth := smtp.PlainAuth("", s.config.Username, s.config.Password, s.config.Host)
+		if err := c.Auth(auth); err != nil {
+			return fmt.Errorf("smtp: auth: %w", err)
+		}
+	}
+	if err := c.Mail(address(s.config.From)); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	for _, to := range msg.To {
+		if err := c.Rcpt(address(to)); err != nil {
+			return fmt.Errorf("smtp: recipient %s: %w", to, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if _, err := w.Write(body); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	if err := w.Close(); err != nil {
+		return fmt.Errorf("smtp: %w", err)
+	}
+	return c.Quit()
+}
+
+// encode renders msg as a MIME message: plain text, or multipart/mixed when it
+// has attachments.
+func (s *SMTP) encode(msg *Message) ([]byte, error) {
+	var buf bytes.Buffer
+	header := func(key, value string) {
+		fmt.Fprintf(&buf, "%s: %s\r\n", key, value)
+	}
+	header("From", s.config.From)
+	header("To", strings.Join(msg.To, ", "))
+	header("Subject", mime.QEncoding.Encode("utf-8", msg.Subject))
+	header("Date", s.now().Format(time.RFC1123Z))
+	header("MIME-Version", "1.0")
+	text := strings.ReplaceAll(msg.Body, "\n", "\r\n")
+	if len(msg.Attachments) == 0 {
+		header("Content-Type", "text/plain; charset=utf-8")
+		buf.WriteString("\r\n" + text)
+		return buf.Bytes(), nil
+	}
+	mw := multipart.NewWriter(&buf)
+	header("Content-Type", "multipart/mixed; boundary="+mw.Boundary())
+	buf.WriteString("\r\n")
+	part, err := mw.CreatePart(textproto.MIMEHeader{"Content-Type": {"text/plain; charset=utf-8"}})
+	if err != nil {
+		return nil, err
+	}
+	part.Write([]byte(text))
+	for _, a := range msg.Attachments {
+		part, err := mw.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {a.ContentType},
+			"Content-Transfer-Encoding": {"base64"},
+			"Content-Disposition":       {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+		})
+		if err != nil {
+			return nil, err
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Data)
+		for len(encoded) > 76 {
+			part.Write([]byte(encoded[:76] + "\r\n"))
+			encoded = encoded[76:]
+		}
+		part.Write([]byte(encoded + "\r\n"))
+	}
+	if err := mw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// address strips the display name from an address such as "Bot <bot@example.com>".
+func address(s string) string {
+	if addr, err := mail.ParseAddress(s); err == nil {
+		return addr.Address
+	}
+	return s
+}
@@ -0,0 +1,162 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net"
+	"net/mail"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeSMTPServer is a minimal SMTP server that records what it receives.
+type fakeSMTPServer struct {
+	listener net.Listener
+	mu       sync.Mutex
+	auth     string
+	from     string
+	rcpt     []string
+	data     string
+}
+
+func newFakeSMTPServer(t *testing.T) *fakeSMTPServer {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	s := &fakeSMTPServer{listener: l}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			go s.serve(conn)
+		}
+	}()
+	return s
+}
+
+func (s *fakeSMTPServer) port() string {
+	_, port, _ := net.SplitHostPort(s.listener.Addr().String())
+	return port
+}
+
+func (s *fakeSMTPServer) serve(conn net.Conn) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(line string) { io.WriteString(conn, line+"\r\n") }
+	reply("220 localhost ESMTP fake")
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb := strings.ToUpper(strings.SplitN(line, " ", 2)[0])
+		s.mu.Lock()
+		switch verb {
+		case "EHLO", "HELO":
+			reply("250-localhost")
+			reply("250 AUTH PLAIN")
+		case "AUTH":
+			s.auth = line
+			reply("235 2.7.0 Authentication successful")
+		case "MAIL":
+			s.from = line
+			reply("250 OK")
+		case "RCPT":
+			s.rcpt = append(s.rcpt, line)
+			reply("250 OK")
+		case "DATA":
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			var data strings.Builder
+			for {
+				l, err := r.ReadString('\n')
+				if err != nil || l == ".\r\n" {
+					break
+				}
+				data.WriteString(l)
+			}
+			s.data = data.String()
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			s.mu.Unlock()
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+		s.mu.Unlock()
+	}
+}
+
+func TestSMTP_SendsMessageWithAttachment(t *testing.T) {
+	server := newFakeSMTPServer(t)
+	notifier := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: server.port(), Username: "bot", Password: "pw", From: "Stackgen <bot@example.com>"})
+
+	err := notifier.Send(context.Background(), &Message{
+		To:          []string{"alice@example.com"},
+		Subject:     "Scheduled: Sync",
+		Body:        "See you there.\n",
+		Attachments: []Attachment{{Filename: "invite.ics", ContentType: "text/calendar", Data: []byte("BEGIN:VCALENDAR\r\n")}},
+	})
+	require.NoError(t, err)
+
+	server.mu.Lock()
+	defer server.mu.Unlock()
+	assert.Equal(t, "AUTH PLAIN "+base64.StdEncoding.EncodeToString([]byte("\x00bot\x00pw")), server.auth)
+	assert.Equal(t, "MAIL FROM:<bot@example.com>", strings.SplitN(server.from, " BODY", 2)[0])
+	assert.Equal(t, []string{"RCPT TO:<alice@example.com>"}, server.rcpt)
+
+	msg, err := mail.ReadMessage(strings.NewReader(server.data))
+	require.NoError(t, err)
+	assert.Equal(t, "Scheduled: Sync", msg.Header.Get("Subject"))
+	assert.Equal(t, "alice@example.com", msg.Header.Get("To"))
+	mediaType, params, err := mime.ParseMediaType(msg.Header.Get("Content-Type"))
+	require.NoError(t, err)
+	assert.Equal(t, "multipart/mixed", mediaType)
+
+	mr := multipart.NewReader(msg.Body, params["boundary"])
+	text, err := mr.NextPart()
+	require.NoError(t, err)
+	body, _ := io.ReadAll(text)
+	assert.Equal(t, "See you there.\r\n", string(body))
+	attachment, err := mr.NextPart()
+	require.NoError(t, err)
+	assert.Equal(t, "invite.ics", attachment.FileName())
+	encoded, _ := io.ReadAll(attachment)
+	decoded, err := base64.StdEncoding.DecodeString(strings.ReplaceAll(string(encoded), "\r\n", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "BEGIN:VCALENDAR\r\n", string(decoded))
+}
+
+func TestSMTP_HonoursContext(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer l.Close()
+	// accept but never greet
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second)
+		}
+	}()
+	_, port, _ := net.SplitHostPort(l.Addr().String())
+	notifier := NewSMTP(SMTPConfig{Host: "127.0.0.1", Port: port})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	err = notifier.Send(ctx, &Message{To: []string{"alice@example.com"}, Subject: "hi"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,65 @@
+package notify
+
+import (
+	"embed"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+//go:embed templates/*.tmpl
+var templateFS embed.FS
+
+// Email kinds, named after their template in templates/.
+const (
+	Invitation = "invitation"
+	Reminder   = "reminder"
+	Finalized  = "finalized"
+)
+
+var templates = map[string]*template.Template{}
+
+func init() {
+	funcs := template.FuncMap{"fmtTime": fmtTime}
+	for _, kind := range []string{Invitation, Reminder, Finalized} {
+		templates[kind] = template.Must(template.New(kind).Funcs(funcs).ParseFS(templateFS, "templates/"+kind+".tmpl"))
+	}
+}
+
+// TemplateData is what email templates are rendered with.
+type TemplateData struct {
+	Recipient models.User
+	Organizer *models.User
+	Event     *models.Event
+}
+
+// Render returns the subject and body of an email of the given kind.
+func Render(kind string, data TemplateData) (subject, body string, err error) {
+	tmpl, ok := templates[kind]
+	if !ok {
+		return "", "", fmt.Errorf("notify: unknown template %q", kind)
+	}
+	var s, b strings.Builder
+	if err := tmpl.ExecuteTemplate(&s, "subject", data); err != nil {
+		return "", "", err
+	}
+	if err := tmpl.ExecuteTemplate(&b, "body", data); err != nil {
+		return "", "", err
+	}
+	return s.String(), b.String(), nil
+}
+
+func fmtTime(t interface{}) string {
+	switch t := t.(type) {
+	case time.Time:
+		return t.UTC().Format("Mon Jan 2 2006 15:04")
+	case *time.Time:
+		if t != nil {
+			return fmtTime(*t)
+		}
+	}
+	return ""
+}
@@ -0,0 +1,102 @@
+package notify
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func testEvent() *models.Event {
+	start := time.Date(2025, 1, 12, 14, 0, 0, 0, time.UTC)
+	deadline := start.Add(-48 * time.Hour)
+	return &models.Event{
+		ID:                uuid.MustParse("7f1c2a8e-3a5b-4d7e-9c1f-2b3c4d5e6f70"),
+		Title:             "Quarterly planning",
+		Description:       "Agenda to follow, bring ideas; all welcome",
+		EstimatedDuration: 60,
+		EventSlots: []models.EventSlot{
+			{StartTime: start, EndTime: start.Add(2 * time.Hour)},
+			{StartTime: start.Add(24 * time.Hour), EndTime: start.Add(26 * time.Hour)},
+		},
+		ResponseDeadline: &deadline,
+	}
+}
+
+func TestRender_Invitation(t *testing.T) {
+	organizer := &models.User{Name: "Olga"}
+	subject, body, err := Render(Invitation, TemplateData{Recipient: models.User{Name: "Alice"}, Organizer: organizer, Event: testEvent()})
+	require.NoError(t, err)
+	assert.Equal(t, "Invitation: Quarterly planning", subject)
+	assert.Contains(t, body, "Hi Alice,")
+	assert.Contains(t, body, `Olga has invited you to "Quarterly planning" (60 minutes).`)
+	assert.Contains(t, body, "  - Sun Jan 12 2025 14:00 to Sun Jan 12 2025 16:00\n")
+	assert.Contains(t, body, "by Fri Jan 10 2025 14:00 UTC")
+
+	event := testEvent()
+	event.Search = &models.SlotSearch{
+		From:     time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC),
+		TimeZone: "Europe/Berlin", DayStart: "09:00", DayEnd: "17:00", WeekdaysOnly: true,
+	}
+	_, body, err = Render(Invitation, TemplateData{Recipient: models.User{Name: "Alice"}, Event: event})
+	require.NoError(t, err)
+	assert.Contains(t, body, "The time will be found between Mon Jan 13 2025 00:00 and Sat Jan 25 2025 00:00 UTC, within 09:00 to 17:00 Europe/Berlin on weekdays.")
+	assert.NotContains(t, body, "Proposed times")
+}
+
+func TestRender_ReminderAndFinalized(t *testing.T) {
+	event := testEvent()
+	subject, body, err := Render(Reminder, TemplateData{Recipient: models.User{Name: "Bob"}, Event: event})
+	require.NoError(t, err)
+	assert.Equal(t, "Reminder: share your availability for Quarterly planning", subject)
+	assert.Contains(t, body, "Proposed times (UTC):\n  - Sun Jan 12 2025 14:00 to Sun Jan 12 2025 16:00\n")
+
+	search := testEvent()
+	search.EventSlots = nil
+	search.Search = &models.SlotSearch{
+		From:     time.Date(2025, 1, 13, 0, 0, 0, 0, time.UTC),
+		To:       time.Date(2025, 1, 25, 0, 0, 0, 0, time.UTC),
+		DayStart: "09:00", DayEnd: "17:00",
+	}
+	_, body, err = Render(Reminder, TemplateData{Recipient: models.User{Name: "Bob"}, Event: search})
+	require.NoError(t, err)
+	assert.Contains(t, body, "The time will be found between Mon Jan 13 2025 00:00 and Sat Jan 25 2025 00:00 UTC, within 09:00 to 17:00 UTC.")
+	assert.NotContains(t, body, "Proposed times")
+
+	start, end := event.EventSlots[0].StartTime, event.EventSlots[0].StartTime.Add(time.Hour)
+	event.FinalStartTime, event.FinalEndTime = &start, &end
+	subject, body, err = Render(Finalized, TemplateData{Recipient: models.User{Name: "Bob"}, Event: event})
+	require.NoError(t, err)
+	assert.Equal(t, "Scheduled: Quarterly planning on Sun Jan 12 2025 14:00 UTC", subject)
+	assert.Contains(t, body, "from Sun Jan 12 2025 14:00 to Sun Jan 12 2025 15:00 UTC")
+
+	_, _, err = Render("nope", TemplateData{})
+	assert.Error(t, err)
+}
+
+func TestCalendar(t *testing.T) {
+	event := testEvent()
+	start, end := event.EventSlots[0].StartTime, event.EventSlots[0].StartTime.Add(time.Hour)
+	event.FinalStartTime, event.FinalEndTime = &start, &end
+	organizer := &models.User{Name: "Olga", Email: "olga@example.com"}
+	attendees := []models.User{{Name: "Doe, Jane", Email: "jane@example.com"}}
+
+	ics := string(Calendar(event, organizer, attendees, time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)))
+
+	assert.True(t, strings.HasPrefix(ics, "BEGIN:VCALENDAR\r\nVERSION:2.0\r\n"))
+	assert.True(t, strings.HasSuffix(ics, "END:VEVENT\r\nEND:VCALENDAR\r\n"))
+	assert.Contains(t, ics, "UID:7f1c2a8e-3a5b-4d7e-9c1f-2b3c4d5e6f70@stackgen\r\n")
+	assert.Contains(t, ics, "DTSTART:20250112T140000Z\r\nDTEND:20250112T150000Z\r\n")
+	assert.Contains(t, ics, `DESCRIPTION:Agenda to follow\, bring ideas\; all welcome`)
+	assert.Contains(t, ics, "ORGANIZER;CN=Olga:mailto:olga@example.com\r\n")
+	for _, line := range strings.Split(ics, "\r\n") {
+		assert.LessOrEqual(t, len(line), 75, line)
+	}
+	unfolded := strings.ReplaceAll(ics, "\r\n ", "")
+	assert.Contains(t, unfolded, `ATTENDEE;CN="Doe, Jane";ROLE=REQ-PARTICIPANT;RSVP=TRUE:mailto:jane@example.com`)
+}
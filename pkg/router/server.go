@@ -1,34 +1,70 @@
 package router
 
 import (
+	"expvar"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os"
 
 	"github.com/julienschmidt/httprouter"
 	"github.com/rsys-speerzad/stackgen/pkg/api"
+	"github.com/rsys-speerzad/stackgen/pkg/api/docs"
 	"github.com/rsys-speerzad/stackgen/pkg/api/events"
+	"github.com/rsys-speerzad/stackgen/pkg/api/resources"
 	"github.com/rsys-speerzad/stackgen/pkg/api/users"
+	"github.com/rsys-speerzad/stackgen/pkg/api/webhooks"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
 	"github.com/rsys-speerzad/stackgen/pkg/store"
 )
 
-func NewServer() *http.Server {
+// NewServer returns the API server, which announces writes on changes and
+// closes it on shutdown.
+func NewServer(changes bus.Bus) *http.Server {
 	port := os.Getenv("PORT")
 	if port == "" {
 		port = "8080"
 		log.Printf("defaulting to port %s", port)
 	}
-	return &http.Server{Addr: "localhost:" + port, Handler: newHandler()}
+	// reject unknown JSON fields in the bodies of requests other than updates
+	// when enabled
+	api.DisallowUnknownFields = os.Getenv("DISALLOW_UNKNOWN_FIELDS") == "true"
+	server := &http.Server{Addr: "localhost:" + port, Handler: newHandler(changes)}
+	server.RegisterOnShutdown(func() {
+		if closer, ok := changes.(io.Closer); ok {
+			closer.Close()
+		}
+	})
+	return server
+}
+
+// NewBus returns the bus that tells live streams and caches about writes made
+// by any replica, or an in-process bus when the database can't be listened to.
+func NewBus() bus.Bus {
+	changes, err := bus.NewPostgres(store.DSN())
+	if err != nil {
+		log.Printf("listening for database changes failed, only local changes will be seen: %v", err)
+		return bus.NewMemory()
+	}
+	return changes
 }
 
-func newHandler() http.Handler {
+func newHandler(changes bus.Bus) http.Handler {
 	// initialize the router
 	r := httprouter.New()
 	// add user routes
-	users.InitializeRouter(r, store.GetDB())
+	users.InitializeRouter(r, store.GetDB(), changes)
 	// add event routes
-	events.InitializeRouter(r, store.GetDB())
+	events.InitializeRouter(r, store.GetDB(), changes)
+	// add resource routes
+	resources.InitializeRouter(r, store.GetDB(), changes)
+	// add webhook subscription routes
+	webhooks.InitializeRouter(r, store.GetDB())
+	// add API documentation routes
+	docs.InitializeRouter(r)
+	// expose runtime counters, such as recommendation cache hits and misses
+	r.Handler(http.MethodGet, "/debug/vars", expvar.Handler())
 	// add gloabal options
 	r.GlobalOPTIONS = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		if r.Header.Get("Access-Control-Request-Method") != "" {
@@ -0,0 +1,46 @@
+package scheduler
+
+import "sort"
+
+// Calendar tells when a resource, such as a room, can be booked.
+type Calendar struct {
+	restricted bool       // whether the resource can only be booked within available
+	available  []Interval // merged
+	booked     []Interval // merged
+}
+
+// NewCalendar returns the calendar of a resource that can be booked any
+// time, or only within availability if restricted, except when already
+// booked. A restricted resource without availability is never free.
+func NewCalendar(availability, booked []Interval, restricted bool) Calendar {
+	c := Calendar{restricted: restricted, booked: Merge(booked)}
+	if restricted {
+		c.available = Merge(availability)
+	}
+	return c
+}
+
+// Free reports whether the resource can be booked for every one of slots.
+func (c Calendar) Free(slots ...Interval) bool {
+	for _, slot := range slots {
+		if c.restricted && !containing(c.available, slot) {
+			return false
+		}
+		next := sort.Search(len(c.booked), func(i int) bool {
+			return c.booked[i].End.After(slot.Start)
+		})
+		if next < len(c.booked) && c.booked[next].Overlaps(slot) {
+			return false
+		}
+	}
+	return true
+}
+
+// containing reports whether one of the sorted, disjoint intervals contains
+// slot.
+func containing(intervals []Interval, slot Interval) bool {
+	next := sort.Search(len(intervals), func(i int) bool {
+		return intervals[i].End.After(slot.Start)
+	})
+	return next < len(intervals) && intervals[next].Contains(slot)
+}
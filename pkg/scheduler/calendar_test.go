@@ -0,0 +1,33 @@
+package scheduler
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCalendar_Free(t *testing.T) {
+	t.Run("any time unless booked", func(t *testing.T) {
+		c := NewCalendar(nil, []Interval{span(12, 13), span(9, 10)}, false)
+		assert.True(t, c.Free(span(10, 12)))
+		assert.True(t, c.Free(span(10, 11), span(13, 14)))
+		assert.False(t, c.Free(span(11, 13)))
+		assert.False(t, c.Free(span(10, 11), span(9, 10)))
+		assert.True(t, c.Free())
+	})
+
+	t.Run("within availability", func(t *testing.T) {
+		// adjacent availability is joined
+		c := NewCalendar([]Interval{span(9, 12), span(12, 17)}, []Interval{span(14, 15)}, true)
+		assert.True(t, c.Free(span(11, 13)))
+		assert.False(t, c.Free(span(8, 10)))
+		assert.False(t, c.Free(span(14, 16)))
+		assert.False(t, c.Free(span(17, 18)))
+	})
+
+	t.Run("restricted without availability", func(t *testing.T) {
+		c := NewCalendar(nil, nil, true)
+		assert.False(t, c.Free(span(9, 10)))
+		assert.True(t, c.Free())
+	})
+}
@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"cmp"
+	"slices"
+	"time"
+)
+
+// Workday is the hours someone prefers to meet in, on the wall clock of
+// Location, every day.
+type Workday struct {
+	Location   *time.Location
+	Start, End time.Duration // offsets from midnight
+}
+
+// Outside returns how much of slot falls outside the working hours.
+func (w Workday) Outside(slot Interval) time.Duration {
+	window := Window{Location: w.Location, DayStart: w.Start, DayEnd: w.End}
+	inside := time.Duration(0)
+	for day := window.day(slot.Start); day.Before(slot.End); day = day.AddDate(0, 0, 1) {
+		hours, _ := window.hours(day)
+		inside += slot.Intersection(hours).Duration()
+	}
+	return slot.Duration() - inside
+}
+
+// Quorum is the attendance that makes a slot viable. The zero Quorum is
+// met by any slot.
+type Quorum struct {
+	Min      int      // attendees who must be able to attend
+	Required []string // IDs of attendees who must be among them
+}
+
+// Met reports whether the attendees with the available IDs make quorum.
+func (q Quorum) Met(available []string) bool {
+	if len(available) < q.Min {
+		return false
+	}
+	for _, id := range q.Required {
+		if !slices.Contains(available, id) {
+			return false
+		}
+	}
+	return true
+}
+
+// Candidate is a slot scored for fairness.
+type Candidate struct {
+	Index       int // position of Slot among the slots ranked
+	Slot        Interval
+	Available   []string
+	IfNeeded    []string // those of Available who can only if needed
+	Missing     []string
+	MeetsQuorum bool
+	Pain        time.Duration // total time the attendees spend outside their working hours
+	Attendees   []Experience  // how the slot lands for each available attendee
+}
+
+// Experience is how a slot lands for one attendee.
+type Experience struct {
+	ID    string
+	Local Interval      // the slot on the attendee's wall clock
+	Pain  time.Duration // time outside the attendee's working hours
+}
+
+// Rank scores slots that somebody can attend and orders them best first:
+// those that meet quorum before those that don't, then by the number of
+// attendees who can attend, then by the number who can without resorting to
+// availability given only if needed, then by the least pain, then by
+// position. Votes count as they do for Recommend. At most limit candidates
+// are returned.
+func Rank(slots []Interval, attendees []Attendee, fit Fit, quorum Quorum, limit int) []Candidate {
+	a := newAnswers(attendees, fit)
+
+	// score every slot first, and describe only those that make the cut
+	type score struct {
+		index, count, preferred int
+		quorum                  bool
+		pain                    time.Duration
+	}
+	var scores []score
+	available := make([]string, 0, len(attendees))
+	for s, slot := range slots {
+		score := score{index: s}
+		available = available[:0]
+		for i, attendee := range attendees {
+			if can, prefers := a.attends(i, s, slot); can {
+				available = append(available, attendee.ID)
+				score.count++
+				score.pain += pain(attendee, slot)
+				if prefers {
+					score.preferred++
+				}
+			}
+		}
+		if score.count > 0 {
+			score.quorum = quorum.Met(available)
+			scores = append(scores, score)
+		}
+	}
+	slices.SortStableFunc(scores, func(a, b score) int {
+		if a.quorum != b.quorum {
+			if a.quorum {
+				return -1
+			}
+			return 1
+		}
+		if a.count != b.count {
+			return cmp.Compare(b.count, a.count)
+		}
+		if a.preferred != b.preferred {
+			return cmp.Compare(b.preferred, a.preferred)
+		}
+		return cmp.Compare(a.pain, b.pain)
+	})
+	if len(scores) > limit {
+		scores = scores[:limit]
+	}
+
+	ranked := make([]Candidate, len(scores))
+	for c, score := range scores {
+		ranked[c] = Candidate{
+			Index:       score.index,
+			Slot:        slots[score.index],
+			Available:   make([]string, 0, score.count),
+			MeetsQuorum: score.quorum,
+			Pain:        score.pain,
+		}
+	}
+	for c := range ranked {
+		candidate := &ranked[c]
+		for i, attendee := range attendees {
+			can, prefers := a.attends(i, candidate.Index, candidate.Slot)
+			if !can {
+				candidate.Missing = append(candidate.Missing, attendee.ID)
+				continue
+			}
+			local := candidate.Slot
+			if attendee.Workday != nil && attendee.Workday.Location != nil {
+				local = Interval{Start: local.Start.In(attendee.Workday.Location), End: local.End.In(attendee.Workday.Location)}
+			}
+			candidate.Available = append(candidate.Available, attendee.ID)
+			if !prefers {
+				candidate.IfNeeded = append(candidate.IfNeeded, attendee.ID)
+			}
+			candidate.Attendees = append(candidate.Attendees, Experience{ID: attendee.ID, Local: local, Pain: pain(attendee, candidate.Slot)})
+		}
+	}
+	return ranked
+}
+
+// pain is the time of slot outside the attendee's working hours, if known.
+func pain(attendee Attendee, slot Interval) time.Duration {
+	if attendee.Workday == nil {
+		return 0
+	}
+	return attendee.Workday.Outside(slot)
+}
@@ -0,0 +1,114 @@
+package scheduler
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWorkday_Outside(t *testing.T) {
+	workday := Workday{Start: 9 * time.Hour, End: 17 * time.Hour}
+	assert.Equal(t, time.Duration(0), workday.Outside(span(9, 17)))
+	assert.Equal(t, time.Hour, workday.Outside(span(8, 10)))
+	assert.Equal(t, 2*time.Hour, workday.Outside(span(16, 18).shift(time.Hour)))
+	assert.Equal(t, 16*time.Hour, workday.Outside(span(0, 24)))
+	assert.Equal(t, 16*time.Hour, workday.Outside(span(15, 24+10)), "inside 15 to 17 and 9 to 10 the next day")
+
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	// 03:00 UTC is 08:30 in Kolkata
+	workday.Location = kolkata
+	assert.Equal(t, 30*time.Minute, workday.Outside(span(3, 4)))
+}
+
+func TestRank(t *testing.T) {
+	kolkata, err := time.LoadLocation("Asia/Kolkata")
+	require.NoError(t, err)
+	newYork, err := time.LoadLocation("America/New_York")
+	require.NoError(t, err)
+	allDay := []Interval{span(0, 24)}
+	attendees := []Attendee{
+		{ID: "asha", Availability: allDay, Workday: &Workday{Location: kolkata, Start: 9 * time.Hour, End: 18 * time.Hour}},
+		{ID: "bob", Availability: allDay, Workday: &Workday{Location: newYork, Start: 9 * time.Hour, End: 17 * time.Hour}},
+	}
+	slots := []Interval{
+		span(3, 4),   // 08:30 for asha, 22:00 for bob
+		span(14, 15), // 19:30 for asha, 09:00 for bob
+		span(12, 13), // 17:30 for asha, 07:00 for bob
+	}
+
+	ranked := Rank(slots, attendees, Overlaps, Quorum{}, 2)
+
+	require.Len(t, ranked, 2)
+	assert.Equal(t, 1, ranked[0].Index)
+	assert.Equal(t, time.Hour, ranked[0].Pain)
+	assert.Equal(t, []string{"asha", "bob"}, ranked[0].Available)
+	assert.Nil(t, ranked[0].Missing)
+	require.Len(t, ranked[0].Attendees, 2)
+	assert.Equal(t, "19:30", ranked[0].Attendees[0].Local.Start.Format("15:04"))
+	assert.Equal(t, time.Hour, ranked[0].Attendees[0].Pain)
+	assert.Equal(t, "09:00", ranked[0].Attendees[1].Local.Start.Format("15:04"))
+	assert.Equal(t, time.Duration(0), ranked[0].Attendees[1].Pain)
+	// equal pain goes to the earlier position
+	assert.Equal(t, 0, ranked[1].Index)
+	assert.Equal(t, 90*time.Minute, ranked[1].Pain)
+
+	t.Run("attendance comes first", func(t *testing.T) {
+		carol := Attendee{ID: "carol", Availability: []Interval{span(12, 13)}}
+		ranked := Rank(slots, append(attendees, carol), Overlaps, Quorum{}, 1)
+		require.Len(t, ranked, 1)
+		assert.Equal(t, 2, ranked[0].Index)
+		assert.Equal(t, []Experience{
+			{ID: "asha", Local: Interval{Start: at(12).In(kolkata), End: at(13).In(kolkata)}, Pain: 30 * time.Minute},
+			{ID: "bob", Local: Interval{Start: at(12).In(newYork), End: at(13).In(newYork)}, Pain: time.Hour},
+			{ID: "carol", Local: span(12, 13)},
+		}, ranked[0].Attendees)
+	})
+
+	t.Run("preference comes before pain", func(t *testing.T) {
+		reluctant := []Attendee{attendees[0], {ID: "bob", Availability: []Interval{span(0, 12)}, IfNeeded: []Interval{span(12, 24)}, Workday: attendees[1].Workday}}
+		ranked := Rank(slots, reluctant, Overlaps, Quorum{}, 3)
+		require.Len(t, ranked, 3)
+		assert.Equal(t, 0, ranked[0].Index, "bob prefers the slot at 22:00 for him")
+		assert.Nil(t, ranked[0].IfNeeded)
+		assert.Equal(t, []string{"bob"}, ranked[1].IfNeeded)
+	})
+
+	t.Run("votes override availability", func(t *testing.T) {
+		voted := slices.Clone(attendees)
+		voted[0].Votes = map[int]Vote{1: VoteNo, 2: VoteMaybe}
+		ranked := Rank(slots, voted, Overlaps, Quorum{}, 3)
+		require.Len(t, ranked, 3)
+		assert.Equal(t, []int{0, 2, 1}, []int{ranked[0].Index, ranked[1].Index, ranked[2].Index})
+		assert.Equal(t, []string{"asha"}, ranked[1].IfNeeded)
+		assert.Equal(t, []string{"asha"}, ranked[2].Missing)
+	})
+
+	t.Run("quorum comes first", func(t *testing.T) {
+		lead := Attendee{ID: "carol", Availability: []Interval{span(12, 13)}}
+		others := []Interval{span(3, 4), span(14, 15)}
+		everyone := append(slices.Clone(attendees), lead, Attendee{ID: "dave", Availability: others}, Attendee{ID: "erin", Availability: others})
+		ranked := Rank(slots, everyone, Overlaps, Quorum{Min: 3, Required: []string{"carol"}}, 3)
+		require.Len(t, ranked, 3)
+		assert.Equal(t, 2, ranked[0].Index)
+		assert.True(t, ranked[0].MeetsQuorum)
+		assert.Equal(t, []string{"asha", "bob", "carol"}, ranked[0].Available)
+		assert.False(t, ranked[1].MeetsQuorum)
+		assert.Len(t, ranked[1].Available, 4)
+	})
+
+	t.Run("nobody available", func(t *testing.T) {
+		assert.Empty(t, Rank(slots, []Attendee{{ID: "dave"}}, Overlaps, Quorum{}, 10))
+	})
+}
+
+func TestQuorum_Met(t *testing.T) {
+	assert.True(t, Quorum{}.Met(nil))
+	quorum := Quorum{Min: 2, Required: []string{"alice"}}
+	assert.True(t, quorum.Met([]string{"bob", "alice"}))
+	assert.False(t, quorum.Met([]string{"alice"}), "too few")
+	assert.False(t, quorum.Met([]string{"bob", "carol"}), "alice is required")
+}
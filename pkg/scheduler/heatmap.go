@@ -0,0 +1,54 @@
+package scheduler
+
+import "time"
+
+// Bucket is a span of a heatmap, with the attendees available for all of it.
+type Bucket struct {
+	Slot      Interval
+	Available []string
+	IfNeeded  []string // those of Available who are only if needed
+}
+
+// Buckets returns how many buckets of step Heatmap splits ranges into.
+func Buckets(ranges []Interval, step time.Duration) int {
+	n := 0
+	for _, r := range ranges {
+		n += int((r.Duration() + step - 1) / step)
+	}
+	return n
+}
+
+// Heatmap splits each of ranges, which must be sorted and disjoint, into
+// buckets of step, the last of which may be shorter, and tells for each the
+// attendees whose availability covers it, less their busy time. Buffers are
+// ignored, as they keep time free around a meeting rather than within it.
+func Heatmap(ranges []Interval, step time.Duration, attendees []Attendee) []Bucket {
+	buckets := make([]Bucket, 0, Buckets(ranges, step))
+	for _, r := range ranges {
+		for start := r.Start; start.Before(r.End); start = start.Add(step) {
+			end := start.Add(step)
+			if end.After(r.End) {
+				end = r.End
+			}
+			buckets = append(buckets, Bucket{Slot: Interval{Start: start, End: end}})
+		}
+	}
+	unbuffered := make([]Attendee, len(attendees))
+	for i, attendee := range attendees {
+		attendee.Buffer = Buffer{}
+		unbuffered[i] = attendee
+	}
+	all, preferred := mergeAll(unbuffered, true), mergeAll(unbuffered, false)
+	for i, attendee := range unbuffered {
+		for b := range buckets {
+			if !containing(all.of(i), buckets[b].Slot) {
+				continue
+			}
+			buckets[b].Available = append(buckets[b].Available, attendee.ID)
+			if !containing(preferred.of(i), buckets[b].Slot) {
+				buckets[b].IfNeeded = append(buckets[b].IfNeeded, attendee.ID)
+			}
+		}
+	}
+	return buckets
+}
@@ -0,0 +1,25 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHeatmap(t *testing.T) {
+	half := 30 * time.Minute
+	ranges := []Interval{{Start: at(9), End: at(10).Add(15 * time.Minute)}, span(14, 15)}
+	attendees := []Attendee{
+		{ID: "alice", Availability: []Interval{span(9, 10)}, IfNeeded: []Interval{span(10, 11)}, Buffer: Buffer{Before: time.Hour}},
+		{ID: "bob", Availability: []Interval{{Start: at(9).Add(half), End: at(15)}}, Busy: []Interval{span(14, 15)}},
+	}
+	assert.Equal(t, 5, Buckets(ranges, half))
+	assert.Equal(t, []Bucket{
+		{Slot: Interval{Start: at(9), End: at(9).Add(half)}, Available: []string{"alice"}},
+		{Slot: Interval{Start: at(9).Add(half), End: at(10)}, Available: []string{"alice", "bob"}},
+		{Slot: Interval{Start: at(10), End: at(10).Add(15 * time.Minute)}, Available: []string{"alice", "bob"}, IfNeeded: []string{"alice"}},
+		{Slot: Interval{Start: at(14), End: at(14).Add(half)}},
+		{Slot: Interval{Start: at(14).Add(half), End: at(15)}},
+	}, Heatmap(ranges, half, attendees))
+}
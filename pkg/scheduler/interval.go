@@ -0,0 +1,110 @@
+// Package scheduler holds the scheduling math behind recommendations. It has
+// no storage or model dependencies: callers convert their data into Intervals
+// and Attendees, and nothing here modifies the values it is given.
+package scheduler
+
+import (
+	"slices"
+	"time"
+)
+
+// Interval is the half-open span of time [Start, End).
+type Interval struct {
+	Start, End time.Time
+}
+
+// Duration returns the length of i.
+func (i Interval) Duration() time.Duration {
+	return i.End.Sub(i.Start)
+}
+
+// Empty reports whether i contains no time.
+func (i Interval) Empty() bool {
+	return !i.End.After(i.Start)
+}
+
+// Overlaps reports whether i and o share any time. Empty intervals overlap
+// nothing.
+func (i Interval) Overlaps(o Interval) bool {
+	return !i.Intersection(o).Empty()
+}
+
+// Intersection returns the time i and o share, which is empty if they don't
+// overlap.
+func (i Interval) Intersection(o Interval) Interval {
+	if o.Start.After(i.Start) {
+		i.Start = o.Start
+	}
+	if o.End.Before(i.End) {
+		i.End = o.End
+	}
+	if i.Empty() {
+		return Interval{}
+	}
+	return i
+}
+
+// Contains reports whether all of o lies within i.
+func (i Interval) Contains(o Interval) bool {
+	return !o.Start.Before(i.Start) && !o.End.After(i.End)
+}
+
+// Merge returns the union of intervals as sorted, disjoint intervals, joining
+// those that overlap or touch. Empty intervals are dropped.
+func Merge(intervals []Interval) []Interval {
+	return appendMerged(make([]Interval, 0, len(intervals)), intervals)
+}
+
+// appendMerged appends the union of sets of intervals to dst, as Merge does,
+// reusing dst's capacity as scratch space.
+func appendMerged(dst []Interval, sets ...[]Interval) []Interval {
+	from := len(dst)
+	for _, intervals := range sets {
+		for _, interval := range intervals {
+			if !interval.Empty() {
+				dst = append(dst, interval)
+			}
+		}
+	}
+	own := dst[from:]
+	slices.SortFunc(own, func(a, b Interval) int {
+		return a.Start.Compare(b.Start)
+	})
+	n := 0
+	for _, interval := range own {
+		if n > 0 && !interval.Start.After(own[n-1].End) {
+			if interval.End.After(own[n-1].End) {
+				own[n-1].End = interval.End
+			}
+			continue
+		}
+		own[n] = interval
+		n++
+	}
+	return dst[:from+n]
+}
+
+// Subtract returns the time in intervals that isn't in busy, both sorted and
+// disjoint, as sorted, disjoint intervals.
+func Subtract(intervals, busy []Interval) []Interval {
+	var free []Interval
+	next := 0
+	for _, interval := range intervals {
+		// a busy interval over by this one's start is over for every later one
+		for next < len(busy) && !busy[next].End.After(interval.Start) {
+			next++
+		}
+		for b := next; b < len(busy) && busy[b].Start.Before(interval.End); b++ {
+			if busy[b].Start.After(interval.Start) {
+				free = append(free, Interval{Start: interval.Start, End: busy[b].Start})
+			}
+			if busy[b].End.After(interval.Start) {
+				interval.Start = busy[b].End
+			}
+		}
+		if !interval.Empty() {
+			free = append(free, interval)
+		}
+	}
+	return free
+}
@@ -0,0 +1,111 @@
+package scheduler
+
+import (
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+var epoch = time.Date(2025, 1, 6, 0, 0, 0, 0, time.UTC)
+
+func at(hour int) time.Time { return epoch.Add(time.Duration(hour) * time.Hour) }
+
+func span(from, to int) Interval { return Interval{Start: at(from), End: at(to)} }
+
+// randomIntervals returns n intervals on a quarter hour grid within a day,
+// including empty, overlapping, touching and duplicate ones.
+func randomIntervals(rng *rand.Rand, n int) []Interval {
+	intervals := make([]Interval, n)
+	for i := range intervals {
+		start := epoch.Add(time.Duration(rng.Intn(96)) * 15 * time.Minute)
+		intervals[i] = Interval{Start: start, End: start.Add(time.Duration(rng.Intn(12)) * 15 * time.Minute)}
+	}
+	return intervals
+}
+
+// covered reports whether t lies within any of intervals.
+func covered(intervals []Interval, t time.Time) bool {
+	for _, interval := range intervals {
+		if !t.Before(interval.Start) && t.Before(interval.End) {
+			return true
+		}
+	}
+	return false
+}
+
+func TestInterval(t *testing.T) {
+	assert.Equal(t, 2*time.Hour, span(9, 11).Duration())
+	assert.True(t, span(9, 9).Empty())
+	assert.True(t, span(10, 9).Empty())
+	assert.False(t, span(9, 10).Empty())
+
+	assert.True(t, span(9, 11).Overlaps(span(10, 12)))
+	assert.True(t, span(9, 12).Overlaps(span(10, 11)))
+	assert.False(t, span(9, 10).Overlaps(span(10, 11)), "touching intervals don't overlap")
+	assert.False(t, span(9, 10).Overlaps(span(11, 12)))
+	assert.False(t, span(9, 12).Overlaps(span(10, 10)), "empty intervals overlap nothing")
+
+	assert.True(t, span(9, 12).Contains(span(9, 12)))
+	assert.True(t, span(9, 12).Contains(span(10, 11)))
+	assert.False(t, span(9, 12).Contains(span(11, 13)))
+}
+
+func TestMerge(t *testing.T) {
+	assert.Empty(t, Merge(nil))
+	assert.Equal(t, []Interval{span(8, 12), span(13, 14)},
+		Merge([]Interval{span(13, 14), span(10, 12), span(8, 9), span(9, 11), span(11, 11)}))
+}
+
+func TestMerge_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 500; run++ {
+		input := randomIntervals(rng, rng.Intn(20))
+		original := slices.Clone(input)
+		merged := Merge(input)
+
+		require.Equal(t, original, input, "input is left untouched")
+		for i, interval := range merged {
+			require.False(t, interval.Empty(), "no empty intervals")
+			if i > 0 {
+				require.True(t, merged[i-1].End.Before(interval.Start), "sorted, with gaps between intervals")
+			}
+		}
+		for minute := 0; minute < 27*60; minute += 15 {
+			point := epoch.Add(time.Duration(minute) * time.Minute)
+			require.Equal(t, covered(input, point), covered(merged, point), "same coverage at %s", point)
+		}
+		require.Equal(t, merged, Merge(merged), "idempotent")
+		rng.Shuffle(len(input), func(i, j int) { input[i], input[j] = input[j], input[i] })
+		require.Equal(t, merged, Merge(input), "independent of order")
+	}
+}
+
+func TestSubtract(t *testing.T) {
+	assert.Empty(t, Subtract(nil, []Interval{span(9, 10)}))
+	assert.Equal(t, []Interval{span(9, 10)}, Subtract([]Interval{span(9, 10)}, nil))
+	assert.Equal(t, []Interval{span(8, 9), span(10, 11), span(12, 13), span(15, 16)},
+		Subtract([]Interval{span(8, 11), span(12, 16)}, []Interval{span(7, 8), span(9, 10), span(11, 12), span(13, 15), span(16, 17)}))
+	assert.Empty(t, Subtract([]Interval{span(9, 10), span(11, 12)}, []Interval{span(8, 13)}))
+}
+
+func TestSubtract_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 500; run++ {
+		intervals, busy := Merge(randomIntervals(rng, rng.Intn(20))), Merge(randomIntervals(rng, rng.Intn(20)))
+		free := Subtract(intervals, busy)
+		for i, interval := range free {
+			require.False(t, interval.Empty(), "no empty intervals")
+			if i > 0 {
+				require.False(t, interval.Start.Before(free[i-1].End), "sorted and disjoint")
+			}
+		}
+		for minute := 0; minute < 27*60; minute += 15 {
+			point := epoch.Add(time.Duration(minute) * time.Minute)
+			require.Equal(t, covered(intervals, point) && !covered(busy, point), covered(free, point), "coverage at %s", point)
+		}
+	}
+}
@@ -0,0 +1,385 @@
+package scheduler
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// Attendee is someone whose availability slots are matched against.
+type Attendee struct {
+	ID           string // identifies the attendee in a Recommendation
+	Availability []Interval
+	IfNeeded     []Interval // availability the attendee would rather not be held to
+	Workday      *Workday   // hours the attendee prefers to meet in, if known
+	Buffer       Buffer     // time the attendee keeps free around a meeting
+	// Busy is time the attendee is already taken, such as other meetings.
+	// They can't attend a slot that, with their buffer, overlaps any of it,
+	// whatever their availability, unless they vote for it. It is left out
+	// of their availability too, so their buffer keeps clear of it.
+	Busy []Interval
+	// Votes are the attendee's answers for slots, by position; a vote
+	// overrides whatever Availability and IfNeeded imply for its slot.
+	Votes map[int]Vote
+}
+
+// Buffer is time kept free around a meeting, e.g. for a break or travel.
+type Buffer struct {
+	Before, After time.Duration
+}
+
+// shrink trims the buffer off the ends of sorted, disjoint intervals in
+// place, leaving the time a meeting with its buffer fits in, and drops those
+// too short to keep any.
+func (b Buffer) shrink(intervals []Interval) []Interval {
+	kept := intervals[:0]
+	for _, interval := range intervals {
+		interval = Interval{Start: interval.Start.Add(b.Before), End: interval.End.Add(-b.After)}
+		if !interval.Empty() {
+			kept = append(kept, interval)
+		}
+	}
+	return kept
+}
+
+// widen returns busy intervals grown by the buffer a meeting needs on the
+// other side of them: After before each and Before after it.
+func (b Buffer) widen(busy []Interval) []Interval {
+	if b == (Buffer{}) {
+		return busy
+	}
+	widened := make([]Interval, len(busy))
+	for i, interval := range busy {
+		widened[i] = Interval{Start: interval.Start.Add(-b.After), End: interval.End.Add(b.Before)}
+	}
+	return widened
+}
+
+// Free returns the time the attendee can attend in: their availability,
+// including that given only if needed, less their busy time and buffer, as
+// sorted, disjoint intervals.
+func (a Attendee) Free() []Interval {
+	return mergeAll([]Attendee{a}, true).intervals
+}
+
+// Vote is an attendee's explicit answer for a slot.
+type Vote int
+
+// Answers an attendee can give for a slot.
+const (
+	VoteYes   Vote = iota + 1 // can attend
+	VoteMaybe                 // can attend if needed
+	VoteNo                    // can't attend
+)
+
+// Fit decides whether an attendee with the availability interval can attend
+// slot. Only the first of an attendee's merged intervals ending after the
+// slot's start is tried, so a Fit must not hold for a later interval when it
+// doesn't for that one.
+type Fit func(availability, slot Interval) bool
+
+// Fits of availability for a slot.
+var (
+	Overlaps Fit = Interval.Overlaps // availability for some of the slot
+	Covers   Fit = Interval.Contains // availability for all of the slot
+)
+
+// Recommendation is the outcome of matching slots against attendees.
+type Recommendation struct {
+	Index     int // position of Slot among the candidates, -1 if nobody can attend any
+	Slot      Interval
+	Available []string // IDs of the attendees who can attend Slot
+	IfNeeded  []string // IDs of those of Available who can only if needed
+	Missing   []string // IDs of the others, nil if there are none
+}
+
+// Recommend picks the first of slots that the most attendees have some
+// availability overlapping and no busy time, preferring among those the slots that the most
+// can attend without resorting to availability they gave only if needed.
+// A vote counts instead of the availability for its slot, maybe as if
+// needed. Available, IfNeeded and Missing keep the attendees' order.
+func Recommend(slots []Interval, attendees []Attendee) Recommendation {
+	return match(slots, attendees, Overlaps)
+}
+
+// Discover picks the first of candidates, such as those of a Window, that the
+// most attendees are available for throughout, with the same preference as
+// Recommend.
+func Discover(candidates []Interval, attendees []Attendee) Recommendation {
+	return match(candidates, attendees, Covers)
+}
+
+// match picks the first of slots that fits an availability interval of the
+// most attendees, and then of the most attendees' preferred availability.
+//
+// Each attendee's availability is merged once into sorted, disjoint
+// intervals, and the slots are visited by start time so that every
+// attendee's intervals are swept a single time: O(attendees × (availability
+// + slots)) rather than a full scan per slot. The intervals of all attendees
+// share one buffer, so allocations don't grow with the number of attendees.
+func match(slots []Interval, attendees []Attendee, fits Fit) Recommendation {
+	a := newAnswers(attendees, fits)
+	counts, preferredCounts := a.tally(slots, byStart(slots))
+
+	best := -1
+	for s := range slots {
+		if counts[s] == 0 {
+			continue
+		}
+		if best < 0 || counts[s] > counts[best] || counts[s] == counts[best] && preferredCounts[s] > preferredCounts[best] {
+			best = s
+		}
+	}
+	if best < 0 {
+		return Recommendation{Index: -1}
+	}
+	return a.recommendation(slots, best, counts[best], preferredCounts[best])
+}
+
+// byStart returns the positions of slots ordered by start time.
+func byStart(slots []Interval) []int {
+	order := make([]int, len(slots))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortFunc(order, func(a, b int) int {
+		return slots[a].Start.Compare(slots[b].Start)
+	})
+	return order
+}
+
+// tally returns for each slot how many attendees can attend, and how many
+// can without resorting to if needed. order lists the slots by start time.
+func (a answers) tally(slots []Interval, order []int) (counts, preferredCounts []int) {
+	counts = a.all.count(slots, order, a.fits, a.busy)
+	// attendees without if needed availability or votes prefer every slot
+	// they can attend
+	preferredCounts = counts
+	if hasIfNeeded(a.attendees) {
+		preferredCounts = a.preferred.count(slots, order, a.fits, a.busy)
+		a.recount(slots, counts, preferredCounts)
+	}
+	return counts, preferredCounts
+}
+
+// recommendation describes slots[s], which count attendees can attend and
+// preferred of them without resorting to if needed.
+func (a answers) recommendation(slots []Interval, s, count, preferred int) Recommendation {
+	result := Recommendation{
+		Index:     s,
+		Slot:      slots[s],
+		Available: make([]string, 0, count),
+	}
+	if missing := len(a.attendees) - count; missing > 0 {
+		result.Missing = make([]string, 0, missing)
+	}
+	if ifNeeded := count - preferred; ifNeeded > 0 {
+		result.IfNeeded = make([]string, 0, ifNeeded)
+	}
+	for i, attendee := range a.attendees {
+		can, prefers := a.attends(i, s, result.Slot)
+		if !can {
+			result.Missing = append(result.Missing, attendee.ID)
+			continue
+		}
+		result.Available = append(result.Available, attendee.ID)
+		if !prefers {
+			result.IfNeeded = append(result.IfNeeded, attendee.ID)
+		}
+	}
+	return result
+}
+
+// answers tells whether attendees can attend slots, from their vote for a
+// slot if they cast one and otherwise from their merged availability.
+type answers struct {
+	attendees []Attendee
+	all       merged // availability including that given only if needed
+	preferred merged // availability without it, all if nobody gave any
+	busy      merged // busy time, empty if nobody has any
+	fits      Fit
+}
+
+func newAnswers(attendees []Attendee, fits Fit) answers {
+	a := answers{attendees: attendees, all: mergeAll(attendees, true), fits: fits}
+	a.preferred = a.all
+	if hasIfNeeded(attendees) {
+		a.preferred = mergeAll(attendees, false)
+	}
+	if hasBusy(attendees) {
+		a.busy = mergeBusy(attendees)
+	}
+	return a
+}
+
+// attends reports whether attendee i can attend slot, the one at position s,
+// and whether they can without resorting to if needed.
+func (a answers) attends(i, s int, slot Interval) (can, prefers bool) {
+	if vote, ok := a.attendees[i].Votes[s]; ok {
+		return vote != VoteNo, vote == VoteYes
+	}
+	if a.busy.blocks(i, slot) {
+		return false, false
+	}
+	if !a.all.fits(i, slot, a.fits) {
+		return false, false
+	}
+	return true, a.preferred.fits(i, slot, a.fits)
+}
+
+// recount corrects the counts of the slots that attendees voted on, which
+// count their availability instead.
+func (a answers) recount(slots []Interval, counts, preferredCounts []int) {
+	for i, attendee := range a.attendees {
+		for s, vote := range attendee.Votes {
+			if s < 0 || s >= len(slots) {
+				continue
+			}
+			// the slot was counted unless the attendee is busy for it
+			if !a.busy.blocks(i, slots[s]) {
+				if a.all.fits(i, slots[s], a.fits) {
+					counts[s]--
+				}
+				if a.preferred.fits(i, slots[s], a.fits) {
+					preferredCounts[s]--
+				}
+			}
+			if vote != VoteNo {
+				counts[s]++
+			}
+			if vote == VoteYes {
+				preferredCounts[s]++
+			}
+		}
+	}
+}
+
+// merged holds the merged availability of attendees in one buffer; that of
+// attendee i is intervals[offsets[i]:offsets[i+1]].
+type merged struct {
+	intervals []Interval
+	offsets   []int
+}
+
+// mergeAll merges the availability of every attendee, including what they
+// gave only if needed when ifNeeded is set, less their busy time and buffer.
+func mergeAll(attendees []Attendee, ifNeeded bool) merged {
+	total := 0
+	for _, attendee := range attendees {
+		total += len(attendee.Availability)
+		if ifNeeded {
+			total += len(attendee.IfNeeded)
+		}
+	}
+	m := merged{intervals: make([]Interval, 0, total), offsets: make([]int, len(attendees)+1)}
+	for i, attendee := range attendees {
+		start := len(m.intervals)
+		if ifNeeded {
+			m.intervals = appendMerged(m.intervals, attendee.Availability, attendee.IfNeeded)
+		} else {
+			m.intervals = appendMerged(m.intervals, attendee.Availability)
+		}
+		if len(attendee.Busy) > 0 {
+			free := Subtract(m.intervals[start:], Merge(attendee.Busy))
+			m.intervals = append(m.intervals[:start], free...)
+		}
+		// availability is buffered once merged, so that adjacent intervals
+		// stay one
+		if attendee.Buffer != (Buffer{}) {
+			m.intervals = m.intervals[:start+len(attendee.Buffer.shrink(m.intervals[start:]))]
+		}
+		m.offsets[i+1] = len(m.intervals)
+	}
+	return m
+}
+
+// mergeBusy merges the busy time of every attendee, widened by their buffer
+// so that a slot overlaps it when the slot with the buffer would overlap the
+// busy time itself.
+func mergeBusy(attendees []Attendee) merged {
+	total := 0
+	for _, attendee := range attendees {
+		total += len(attendee.Busy)
+	}
+	m := merged{intervals: make([]Interval, 0, total), offsets: make([]int, len(attendees)+1)}
+	for i, attendee := range attendees {
+		m.intervals = appendMerged(m.intervals, attendee.Buffer.widen(attendee.Busy))
+		m.offsets[i+1] = len(m.intervals)
+	}
+	return m
+}
+
+// of returns the merged intervals of attendee i, none if nothing was merged.
+func (m merged) of(i int) []Interval {
+	if m.offsets == nil {
+		return nil
+	}
+	return m.intervals[m.offsets[i]:m.offsets[i+1]]
+}
+
+// count returns for each slot how many attendees it fits and doesn't
+// overlap the busy time of, visiting the slots in order, which must be by
+// start time.
+func (m merged) count(slots []Interval, order []int, fits Fit, busy merged) []int {
+	counts := make([]int, len(slots))
+	for i := 0; i < len(m.offsets)-1; i++ {
+		own, next := m.of(i), 0
+		taken, nextTaken := busy.of(i), 0
+		for _, s := range order {
+			// an interval over by this slot's start is over for every later one
+			for next < len(own) && !own[next].End.After(slots[s].Start) {
+				next++
+			}
+			if next == len(own) {
+				break
+			}
+			for nextTaken < len(taken) && !taken[nextTaken].End.After(slots[s].Start) {
+				nextTaken++
+			}
+			if nextTaken < len(taken) && taken[nextTaken].Overlaps(slots[s]) {
+				continue
+			}
+			if fits(own[next], slots[s]) {
+				counts[s]++
+			}
+		}
+	}
+	return counts
+}
+
+// blocks reports whether slot overlaps the busy time of attendee i, m being
+// the merged busy time.
+func (m merged) blocks(i int, slot Interval) bool {
+	return m.fits(i, slot, Overlaps)
+}
+
+// fits reports whether slot fits the availability of attendee i, trying the
+// interval count would.
+func (m merged) fits(i int, slot Interval, fits Fit) bool {
+	own := m.of(i)
+	next := sort.Search(len(own), func(j int) bool {
+		return own[j].End.After(slot.Start)
+	})
+	return next < len(own) && fits(own[next], slot)
+}
+
+// hasBusy reports whether any attendee has busy time.
+func hasBusy(attendees []Attendee) bool {
+	for _, attendee := range attendees {
+		if len(attendee.Busy) > 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// hasIfNeeded reports whether any attendee gave availability only if needed,
+// or voted, either of which can make them not prefer a slot they can attend.
+func hasIfNeeded(attendees []Attendee) bool {
+	for _, attendee := range attendees {
+		if len(attendee.IfNeeded) > 0 || len(attendee.Votes) > 0 {
+			return true
+		}
+	}
+	return false
+}
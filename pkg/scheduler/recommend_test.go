@@ -0,0 +1,276 @@
+package scheduler
+
+import (
+	"fmt"
+	"maps"
+	"math/rand"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// recommendAllocs is the allocation budget of Recommend. It must not depend
+// on the number of attendees or slots.
+const recommendAllocs = 7
+
+// dataset generates hourly slots and attendees with dense availability: a few
+// overlapping and adjacent windows on every day of a four week range.
+func dataset(seed int64, attendees, slots int) ([]Interval, []Attendee) {
+	rng := rand.New(rand.NewSource(seed))
+	candidates := make([]Interval, slots)
+	for i := range candidates {
+		start := epoch.Add(time.Duration(rng.Intn(28*24)) * time.Hour)
+		candidates[i] = Interval{Start: start, End: start.Add(time.Hour)}
+	}
+	result := make([]Attendee, attendees)
+	for i := range result {
+		result[i].ID = fmt.Sprintf("user-%d", i)
+		for day := 0; day < 28; day++ {
+			for n := rng.Intn(4); n > 0; n-- {
+				start := epoch.AddDate(0, 0, day).Add(time.Duration(rng.Intn(48)) * 30 * time.Minute)
+				end := start.Add(time.Duration(1+rng.Intn(8)) * 30 * time.Minute)
+				result[i].Availability = append(result[i].Availability, Interval{Start: start, End: end})
+			}
+		}
+	}
+	return candidates, result
+}
+
+// recommendNaive checks every slot against every availability interval. It
+// is the reference Recommend is tested against.
+func recommendNaive(slots []Interval, attendees []Attendee) Recommendation {
+	result, preferred := Recommendation{Index: -1}, 0
+	for s, slot := range slots {
+		var available, ifNeeded, missing []string
+		for _, attendee := range attendees {
+			vote, voted := attendee.Votes[s]
+			switch {
+			case voted && vote == VoteYes:
+				available = append(available, attendee.ID)
+			case voted && vote == VoteMaybe:
+				available = append(available, attendee.ID)
+				ifNeeded = append(ifNeeded, attendee.ID)
+			case voted:
+				missing = append(missing, attendee.ID)
+			case slices.ContainsFunc(attendee.Availability, slot.Overlaps):
+				available = append(available, attendee.ID)
+			case slices.ContainsFunc(attendee.IfNeeded, slot.Overlaps):
+				available = append(available, attendee.ID)
+				ifNeeded = append(ifNeeded, attendee.ID)
+			default:
+				missing = append(missing, attendee.ID)
+			}
+		}
+		if len(available) > len(result.Available) ||
+			len(available) > 0 && len(available) == len(result.Available) && len(available)-len(ifNeeded) > preferred {
+			result = Recommendation{Index: s, Slot: slot, Available: available, IfNeeded: ifNeeded, Missing: missing}
+			preferred = len(available) - len(ifNeeded)
+		}
+	}
+	return result
+}
+
+func cloneAttendees(attendees []Attendee) []Attendee {
+	clone := slices.Clone(attendees)
+	for i := range clone {
+		clone[i].Availability = slices.Clone(clone[i].Availability)
+		clone[i].IfNeeded = slices.Clone(clone[i].IfNeeded)
+		clone[i].Votes = maps.Clone(clone[i].Votes)
+	}
+	return clone
+}
+
+func TestRecommend(t *testing.T) {
+	slots := []Interval{span(9, 10), span(12, 13), span(14, 15)}
+
+	t.Run("first slot with the most attendees wins", func(t *testing.T) {
+		attendees := []Attendee{
+			{ID: "alice", Availability: []Interval{span(11, 12), span(12, 16)}},
+			{ID: "bob", Availability: []Interval{span(14, 15)}},
+			{ID: "carol", Availability: []Interval{span(8, 9), span(10, 11), span(12, 13)}},
+		}
+		assert.Equal(t, Recommendation{
+			Index:     1,
+			Slot:      span(12, 13),
+			Available: []string{"alice", "carol"},
+			Missing:   []string{"bob"},
+		}, Recommend(slots, attendees))
+	})
+
+	t.Run("everybody available", func(t *testing.T) {
+		attendees := []Attendee{{ID: "alice", Availability: []Interval{span(8, 18)}}}
+		assert.Equal(t, Recommendation{Index: 0, Slot: span(9, 10), Available: []string{"alice"}}, Recommend(slots, attendees))
+	})
+
+	t.Run("preferred availability breaks ties", func(t *testing.T) {
+		attendees := []Attendee{
+			{ID: "alice", Availability: []Interval{span(12, 13)}, IfNeeded: []Interval{span(9, 10)}},
+			{ID: "bob", Availability: []Interval{span(9, 10)}, IfNeeded: []Interval{span(12, 13)}},
+			{ID: "carol", Availability: []Interval{span(12, 13)}, IfNeeded: []Interval{span(14, 15)}},
+		}
+		assert.Equal(t, Recommendation{
+			Index:     1,
+			Slot:      span(12, 13),
+			Available: []string{"alice", "bob", "carol"},
+			IfNeeded:  []string{"bob"},
+		}, Recommend(slots, attendees))
+	})
+
+	t.Run("votes override availability", func(t *testing.T) {
+		attendees := []Attendee{
+			{ID: "alice", Availability: []Interval{span(9, 15)}, Votes: map[int]Vote{0: VoteNo, 1: VoteMaybe}},
+			{ID: "bob", Votes: map[int]Vote{1: VoteYes, 2: VoteYes}},
+			{ID: "carol", Availability: []Interval{span(12, 13)}, IfNeeded: []Interval{span(14, 15)}, Votes: map[int]Vote{2: VoteYes}},
+		}
+		assert.Equal(t, Recommendation{
+			Index:     2,
+			Slot:      span(14, 15),
+			Available: []string{"alice", "bob", "carol"},
+		}, Recommend(slots, attendees))
+
+		attendees[2].Votes = map[int]Vote{2: VoteNo}
+		assert.Equal(t, Recommendation{
+			Index:     1,
+			Slot:      span(12, 13),
+			Available: []string{"alice", "bob", "carol"},
+			IfNeeded:  []string{"alice"},
+		}, Recommend(slots, attendees))
+	})
+
+	t.Run("busy time overlapping part of a slot rules it out", func(t *testing.T) {
+		meeting := Interval{Start: at(10), End: at(10).Add(45 * time.Minute)}
+		attendees := []Attendee{
+			{ID: "alice", Availability: []Interval{span(8, 12)}, Busy: []Interval{meeting}},
+			{ID: "bob", Availability: []Interval{span(8, 12)}},
+		}
+		assert.Equal(t, Recommendation{
+			Index:     0,
+			Slot:      span(10, 11),
+			Available: []string{"bob"},
+			Missing:   []string{"alice"},
+		}, Recommend([]Interval{span(10, 11)}, attendees))
+		assert.Equal(t, Recommendation{
+			Index:     1,
+			Slot:      span(11, 12),
+			Available: []string{"alice", "bob"},
+		}, Recommend([]Interval{span(10, 11), span(11, 12)}, attendees))
+
+		attendees[0].Votes = map[int]Vote{0: VoteYes}
+		assert.Equal(t, []string{"alice", "bob"}, Recommend([]Interval{span(10, 11)}, attendees).Available)
+	})
+
+	t.Run("buffers keep back-to-back meetings apart", func(t *testing.T) {
+		attendees := []Attendee{
+			{
+				ID:           "alice",
+				Availability: []Interval{span(8, 18)},
+				Busy:         []Interval{span(9, 10), span(14, 15)},
+				Buffer:       Buffer{Before: 15 * time.Minute, After: 15 * time.Minute},
+			},
+			{ID: "bob", Availability: []Interval{span(8, 18)}},
+		}
+		assert.Equal(t, []string{"alice"}, Recommend([]Interval{span(10, 11)}, attendees).Missing)
+		assert.Equal(t, []string{"alice"}, Recommend([]Interval{span(13, 14)}, attendees).Missing)
+		assert.Equal(t, []string{"alice", "bob"}, Recommend([]Interval{span(11, 12)}, attendees).Available)
+
+		attendees[0].Buffer = Buffer{}
+		assert.Equal(t, []string{"alice", "bob"}, Recommend([]Interval{span(10, 11)}, attendees).Available)
+		assert.Equal(t, []string{"alice", "bob"}, Recommend([]Interval{span(13, 14)}, attendees).Available)
+	})
+
+	t.Run("nobody available", func(t *testing.T) {
+		assert.Equal(t, Recommendation{Index: -1}, Recommend(slots, []Attendee{{ID: "alice"}}))
+		assert.Equal(t, Recommendation{Index: -1}, Recommend(nil, []Attendee{{ID: "alice"}}))
+		assert.Equal(t, Recommendation{Index: -1}, Recommend(slots, nil))
+	})
+}
+
+func TestRecommend_Properties(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 300; run++ {
+		slots := randomIntervals(rng, rng.Intn(10))
+		slots = slices.DeleteFunc(slots, Interval.Empty)
+		attendees := make([]Attendee, rng.Intn(10))
+		for i := range attendees {
+			attendees[i] = Attendee{ID: fmt.Sprint(i), Availability: randomIntervals(rng, rng.Intn(6))}
+			if rng.Intn(2) == 0 {
+				attendees[i].IfNeeded = randomIntervals(rng, rng.Intn(4))
+			}
+			if len(slots) > 0 && rng.Intn(3) == 0 {
+				attendees[i].Votes = map[int]Vote{}
+				for n := rng.Intn(3); n >= 0; n-- {
+					attendees[i].Votes[rng.Intn(len(slots))] = Vote(1 + rng.Intn(3))
+				}
+			}
+		}
+		originalSlots, originalAttendees := slices.Clone(slots), cloneAttendees(attendees)
+
+		got := Recommend(slots, attendees)
+
+		require.Equal(t, originalSlots, slots, "slots are left untouched")
+		require.Equal(t, originalAttendees, attendees, "attendees are left untouched")
+		require.Equal(t, recommendNaive(slots, attendees), got)
+		if got.Index >= 0 {
+			require.Equal(t, len(attendees), len(got.Available)+len(got.Missing), "every attendee is accounted for")
+		}
+	}
+}
+
+func TestRecommend_MatchesNaiveOnDenseData(t *testing.T) {
+	slots, attendees := dataset(1, 200, 50)
+	assert.Equal(t, recommendNaive(slots, attendees), Recommend(slots, attendees))
+}
+
+func TestRecommend_Allocations(t *testing.T) {
+	for _, size := range []int{10, 1000} {
+		slots, attendees := dataset(1, size, 50)
+		allocs := testing.AllocsPerRun(10, func() {
+			Recommend(slots, attendees)
+		})
+		require.LessOrEqual(t, allocs, float64(recommendAllocs), "%d attendees", size)
+	}
+}
+
+func BenchmarkRecommend(b *testing.B) {
+	slots, attendees := dataset(1, 1000, 50)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		Recommend(slots, attendees)
+	}
+}
+
+func BenchmarkRecommendNaive(b *testing.B) {
+	slots, attendees := dataset(1, 1000, 50)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		recommendNaive(slots, attendees)
+	}
+}
+
+func BenchmarkMerge(b *testing.B) {
+	_, attendees := dataset(1, 1000, 0)
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		for _, attendee := range attendees {
+			Merge(attendee.Availability)
+		}
+	}
+}
+
+func TestAttendee_Free(t *testing.T) {
+	alice := Attendee{
+		ID:           "alice",
+		Availability: []Interval{span(9, 11), span(14, 16)},
+		IfNeeded:     []Interval{span(11, 12)},
+		Busy:         []Interval{span(15, 16)},
+		Buffer:       Buffer{After: 30 * time.Minute},
+	}
+	assert.Equal(t, []Interval{{Start: at(9), End: at(11).Add(30 * time.Minute)}, {Start: at(14), End: at(14).Add(30 * time.Minute)}}, alice.Free())
+	assert.Empty(t, Attendee{ID: "bob"}.Free())
+}
@@ -0,0 +1,120 @@
+package scheduler
+
+import "time"
+
+// Recurrence repeats a slot every week, such as for a weekly sync.
+type Recurrence struct {
+	Weeks    int            // occurrences, the first being the slot itself
+	Location *time.Location // wall clock the series keeps its time on, UTC if nil
+}
+
+// Occurrences returns the occurrences of slot, a week apart and at the same
+// time on the wall clock even across daylight saving changes.
+func (r Recurrence) Occurrences(slot Interval) []Interval {
+	location := r.Location
+	if location == nil {
+		location = time.UTC
+	}
+	start, length := slot.Start.In(location), slot.Duration()
+	occurrences := make([]Interval, 0, r.Weeks)
+	for week := 0; week < r.Weeks; week++ {
+		at := start.AddDate(0, 0, 7*week)
+		occurrences = append(occurrences, Interval{Start: at, End: at.Add(length)})
+	}
+	return occurrences
+}
+
+// Series is a slot recommended for a recurring event.
+type Series struct {
+	// Recommendation is for the series as a whole: Available are the
+	// attendees who can attend every occurrence, and IfNeeded those of them
+	// who can some only if needed.
+	Recommendation
+	Occurrences []Recommendation // by week, with that week's conflicts in Missing
+}
+
+// Recur picks the first of slots whose weekly occurrences have the most
+// attendance in total, and then the most without resorting to if needed.
+// Attendance is counted as by fit, and a vote for a slot counts for every
+// one of its occurrences. Index is -1 if nobody can attend any occurrence.
+func Recur(slots []Interval, attendees []Attendee, fit Fit, recurrence Recurrence) Series {
+	weeks := recurrence.Weeks
+	if weeks <= 0 {
+		return Series{Recommendation: Recommendation{Index: -1}}
+	}
+	// occurrences[s*weeks+week] is that week's occurrence of slots[s]
+	occurrences := make([]Interval, 0, len(slots)*weeks)
+	for _, slot := range slots {
+		occurrences = append(occurrences, recurrence.Occurrences(slot)...)
+	}
+	a := newAnswers(seriesVotes(attendees, weeks), fit)
+	counts, preferredCounts := a.tally(occurrences, byStart(occurrences))
+
+	best, bestCount, bestPreferred := -1, 0, 0
+	for s := range slots {
+		count, preferred := 0, 0
+		for o := s * weeks; o < (s+1)*weeks; o++ {
+			count, preferred = count+counts[o], preferred+preferredCounts[o]
+		}
+		if count > bestCount || count > 0 && count == bestCount && preferred > bestPreferred {
+			best, bestCount, bestPreferred = s, count, preferred
+		}
+	}
+	if best < 0 {
+		return Series{Recommendation: Recommendation{Index: -1}}
+	}
+
+	series := Series{Recommendation: Recommendation{Index: best, Slot: slots[best], Available: []string{}}}
+	for o := best * weeks; o < (best+1)*weeks; o++ {
+		occurrence := a.recommendation(occurrences, o, counts[o], preferredCounts[o])
+		occurrence.Index = best
+		series.Occurrences = append(series.Occurrences, occurrence)
+	}
+	for i, attendee := range attendees {
+		every, always := true, true
+		for o := best * weeks; o < (best+1)*weeks && every; o++ {
+			can, prefers := a.attends(i, o, occurrences[o])
+			every, always = can, always && prefers
+		}
+		if !every {
+			series.Missing = append(series.Missing, attendee.ID)
+			continue
+		}
+		series.Available = append(series.Available, attendee.ID)
+		if !always {
+			series.IfNeeded = append(series.IfNeeded, attendee.ID)
+		}
+	}
+	return series
+}
+
+// seriesVotes returns attendees with their votes for slots moved onto every
+// occurrence of those slots, leaving attendees untouched.
+func seriesVotes(attendees []Attendee, weeks int) []Attendee {
+	if !hasVotes(attendees) {
+		return attendees
+	}
+	moved := make([]Attendee, len(attendees))
+	for i, attendee := range attendees {
+		moved[i] = attendee
+		if len(attendee.Votes) == 0 {
+			continue
+		}
+		moved[i].Votes = make(map[int]Vote, len(attendee.Votes)*weeks)
+		for s, vote := range attendee.Votes {
+			for week := 0; week < weeks; week++ {
+				moved[i].Votes[s*weeks+week] = vote
+			}
+		}
+	}
+	return moved
+}
+
+func hasVotes(attendees []Attendee) bool {
+	for _, attendee := range attendees {
+		if len(attendee.Votes) > 0 {
+			return true
+		}
+	}
+	return false
+}
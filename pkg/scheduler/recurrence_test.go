@@ -0,0 +1,119 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRecurrence_Occurrences(t *testing.T) {
+	berlin, err := time.LoadLocation("Europe/Berlin")
+	require.NoError(t, err)
+	// the clocks go forward on March 30th
+	slot := Interval{Start: time.Date(2025, 3, 24, 8, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 24, 9, 0, 0, 0, time.UTC)}
+
+	got := Recurrence{Weeks: 2, Location: berlin}.Occurrences(slot)
+
+	require.Len(t, got, 2)
+	assert.True(t, slot.Start.Equal(got[0].Start))
+	assert.True(t, time.Date(2025, 3, 31, 7, 0, 0, 0, time.UTC).Equal(got[1].Start))
+	assert.Equal(t, time.Hour, got[1].Duration())
+	assert.Len(t, Recurrence{Weeks: 2}.Occurrences(slot), 2)
+	assert.True(t, time.Date(2025, 3, 31, 8, 0, 0, 0, time.UTC).Equal(Recurrence{Weeks: 2}.Occurrences(slot)[1].Start))
+}
+
+func TestRecur(t *testing.T) {
+	const week = 7 * 24
+	monday, tuesday := span(9, 10), span(24+9, 24+10)
+	slots := []Interval{monday, tuesday}
+	weekly := func(from, to int, weeks ...int) []Interval {
+		var intervals []Interval
+		for _, w := range weeks {
+			intervals = append(intervals, span(w*week+from, w*week+to))
+		}
+		return intervals
+	}
+	attendees := func() []Attendee {
+		return []Attendee{
+			{ID: "alice", Availability: weekly(9, 10, 0, 1, 2)},
+			{ID: "bob", Availability: append(weekly(9, 10, 0, 2), weekly(24+9, 24+10, 0, 1, 2)...)},
+			{ID: "carol", Availability: weekly(9, 10, 0, 1, 2)},
+		}
+	}
+
+	t.Run("most attendance across the series", func(t *testing.T) {
+		got := Recur(slots, attendees(), Overlaps, Recurrence{Weeks: 3})
+		assert.Equal(t, 0, got.Index)
+		assert.Equal(t, []string{"alice", "carol"}, got.Available)
+		assert.Equal(t, []string{"bob"}, got.Missing)
+		require.Len(t, got.Occurrences, 3)
+		assert.Equal(t, span(week+9, week+10), got.Occurrences[1].Slot)
+		assert.Equal(t, []string{"bob"}, got.Occurrences[1].Missing)
+		assert.Nil(t, got.Occurrences[2].Missing)
+	})
+
+	t.Run("votes count for every occurrence", func(t *testing.T) {
+		voted := attendees()
+		voted[0].Votes = map[int]Vote{1: VoteYes}
+		voted[2].Votes = map[int]Vote{0: VoteNo, 1: VoteMaybe}
+		got := Recur(slots, voted, Overlaps, Recurrence{Weeks: 3})
+		assert.Equal(t, 1, got.Index)
+		assert.Equal(t, []string{"alice", "bob", "carol"}, got.Available)
+		assert.Equal(t, []string{"carol"}, got.IfNeeded)
+		assert.Nil(t, got.Missing)
+		assert.Equal(t, []string{"carol"}, got.Occurrences[2].IfNeeded)
+		assert.Len(t, voted[0].Votes, 1, "attendees are left untouched")
+	})
+
+	t.Run("nobody available", func(t *testing.T) {
+		assert.Equal(t, -1, Recur(slots, []Attendee{{ID: "dave"}}, Overlaps, Recurrence{Weeks: 3}).Index)
+		assert.Equal(t, -1, Recur(slots, attendees(), Overlaps, Recurrence{}).Index)
+	})
+}
+
+func TestRecur_MatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 200; run++ {
+		slots := make([]Interval, 1+rng.Intn(5))
+		for i := range slots {
+			start := epoch.Add(time.Duration(rng.Intn(7*24*2)) * 30 * time.Minute)
+			slots[i] = Interval{Start: start, End: start.Add(time.Duration(1+rng.Intn(4)) * 30 * time.Minute)}
+		}
+		recurrence := Recurrence{Weeks: 1 + rng.Intn(4)}
+		attendees := make([]Attendee, 1+rng.Intn(4))
+		for i := range attendees {
+			attendees[i] = Attendee{ID: fmt.Sprint(i)}
+			for week := 0; week < recurrence.Weeks; week++ {
+				for _, interval := range randomIntervals(rng, rng.Intn(3)) {
+					shift := time.Duration(week) * 7 * 24 * time.Hour
+					attendees[i].Availability = append(attendees[i].Availability, Interval{Start: interval.Start.Add(shift), End: interval.End.Add(shift)})
+				}
+			}
+		}
+
+		got := Recur(slots, attendees, Overlaps, recurrence)
+
+		best, bestTotal := -1, 0
+		for s, slot := range slots {
+			total := 0
+			for _, occurrence := range recurrence.Occurrences(slot) {
+				total += len(recommendNaive([]Interval{occurrence}, attendees).Available)
+			}
+			if total > bestTotal {
+				best, bestTotal = s, total
+			}
+		}
+		require.Equal(t, best, got.Index, "run %d", run)
+		if best < 0 {
+			continue
+		}
+		for week, occurrence := range got.Occurrences {
+			want := recommendNaive([]Interval{recurrence.Occurrences(slots[best])[week]}, attendees)
+			require.ElementsMatch(t, want.Available, occurrence.Available, "run %d week %d", run, week)
+		}
+	}
+}
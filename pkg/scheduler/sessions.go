@@ -0,0 +1,158 @@
+package scheduler
+
+import (
+	"slices"
+	"sort"
+	"time"
+)
+
+// Sessions describes a combination of slots to schedule together, such as
+// a series of workshops.
+type Sessions struct {
+	Count        int            // slots to combine
+	MinGap       time.Duration  // from the end of a session to the start of the next
+	DistinctDays bool           // at most one session a day
+	Location     *time.Location // wall clock days are told apart on, UTC if nil
+}
+
+// total is the attendance of a combination of sessions.
+type total struct {
+	count, preferred int
+}
+
+func (t total) add(count, preferred int) total {
+	return total{count: t.count + count, preferred: t.preferred + preferred}
+}
+
+func (t total) beats(o total) bool {
+	return t.count > o.count || t.count == o.count && t.preferred > o.preferred
+}
+
+// Combine picks sessions.Count slots that don't overlap and meet the gap and
+// day constraints, maximizing the total attendance over them and then the
+// total attendance without resorting to if needed. Attendance is counted as
+// by fit and votes as for Recommend. Among equal combinations the earliest
+// wins. The sessions are returned by start time, or nil if no combination
+// meets the constraints or nobody can attend any.
+//
+// Sessions are chosen by dynamic programming over the slots by start time:
+// the best combination of k sessions ending with a slot extends the best one
+// of k-1 sessions ending early enough before it. Those are found with a
+// prefix maximum over the slots by end time, so the cost is O(Count × slots
+// × log slots) rather than trying every combination.
+func Combine(slots []Interval, attendees []Attendee, fit Fit, sessions Sessions) []Recommendation {
+	k := sessions.Count
+	if k <= 0 || k > len(slots) {
+		return nil
+	}
+	a := newAnswers(attendees, fit)
+	order := byStart(slots)
+	counts, preferredCounts := a.tally(slots, order)
+	byEnd := slices.Clone(order)
+	slices.SortStableFunc(byEnd, func(a, b int) int {
+		return slots[a].End.Compare(slots[b].End)
+	})
+	endRank, position := make([]int, len(slots)), make([]int, len(slots))
+	for r, s := range byEnd {
+		endRank[s] = r
+	}
+	for p, s := range order {
+		position[s] = p
+	}
+	location := sessions.Location
+	if location == nil {
+		location = time.UTC
+	}
+	day := func(s int) time.Time {
+		y, m, d := slots[s].Start.In(location).Date()
+		return time.Date(y, m, d, 0, 0, 0, 0, time.UTC)
+	}
+
+	// best[l][s] is the best attendance of l+1 sessions the last of which is
+	// slots[s], and before[l][s] the session before it; -1 if there is none,
+	// and for l > 0 also if no combination ends with slots[s]
+	best, before := make([][]total, k), make([][]int, k)
+	for l := range best {
+		best[l], before[l] = make([]total, len(slots)), make([]int, len(slots))
+	}
+	for s := range slots {
+		best[0][s], before[0][s] = total{count: counts[s], preferred: preferredCounts[s]}, -1
+	}
+	for l := 1; l < k; l++ {
+		tree := newPrefixBest(len(slots), func(s, o int) bool {
+			return best[l-1][s].beats(best[l-1][o]) || best[l-1][s] == best[l-1][o] && position[s] < position[o]
+		})
+		inserted := 0
+		for p, s := range order {
+			// a slot can precede s once it starts earlier, on an earlier day
+			// if days must be distinct
+			for ; inserted < p && (!sessions.DistinctDays || day(order[inserted]).Before(day(s))); inserted++ {
+				if prev := order[inserted]; l == 1 || before[l-1][prev] >= 0 {
+					tree.add(endRank[prev], prev)
+				}
+			}
+			// of those, the ones over by the gap before s starts
+			latest := sort.Search(len(byEnd), func(r int) bool {
+				return slots[byEnd[r]].End.Add(sessions.MinGap).After(slots[s].Start)
+			})
+			prev := tree.best(latest)
+			before[l][s] = prev
+			if prev >= 0 {
+				best[l][s] = best[l-1][prev].add(counts[s], preferredCounts[s])
+			}
+		}
+	}
+
+	last := -1
+	for _, s := range order {
+		if k > 1 && before[k-1][s] < 0 {
+			continue
+		}
+		if last < 0 || best[k-1][s].beats(best[k-1][last]) {
+			last = s
+		}
+	}
+	if last < 0 || best[k-1][last].count == 0 {
+		return nil
+	}
+	combination := make([]Recommendation, k)
+	for l, s := k-1, last; l >= 0; l, s = l-1, before[l][s] {
+		combination[l] = a.recommendation(slots, s, counts[s], preferredCounts[s])
+	}
+	return combination
+}
+
+// prefixBest is a Fenwick tree over ranks that answers which of the slots
+// added at ranks below a bound is best.
+type prefixBest struct {
+	tree  []int // slot positions, -1 for none
+	beats func(s, o int) bool
+}
+
+func newPrefixBest(n int, beats func(s, o int) bool) *prefixBest {
+	tree := make([]int, n+1)
+	for i := range tree {
+		tree[i] = -1
+	}
+	return &prefixBest{tree: tree, beats: beats}
+}
+
+// add adds slot s at rank.
+func (p *prefixBest) add(rank, s int) {
+	for i := rank + 1; i < len(p.tree); i += i & -i {
+		if p.tree[i] < 0 || p.beats(s, p.tree[i]) {
+			p.tree[i] = s
+		}
+	}
+}
+
+// best returns the best slot added at a rank below bound, or -1.
+func (p *prefixBest) best(bound int) int {
+	result := -1
+	for i := bound; i > 0; i -= i & -i {
+		if s := p.tree[i]; s >= 0 && (result < 0 || p.beats(s, result)) {
+			result = s
+		}
+	}
+	return result
+}
@@ -0,0 +1,155 @@
+package scheduler
+
+import (
+	"fmt"
+	"math/rand"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCombine(t *testing.T) {
+	// two hour-long slots a day, at 9 and 14, for three days
+	var slots []Interval
+	for day := 0; day < 3; day++ {
+		slots = append(slots, span(24*day+9, 24*day+10), span(24*day+14, 24*day+15))
+	}
+	attendees := []Attendee{
+		{ID: "alice", Availability: []Interval{span(9, 15), span(24+14, 24+15)}},
+		{ID: "bob", Availability: []Interval{span(9, 15), span(48+9, 48+10)}},
+		{ID: "carol", Availability: []Interval{span(14, 15), span(48+9, 48+10)}},
+	}
+	starts := func(combination []Recommendation) []time.Time {
+		var result []time.Time
+		for _, session := range combination {
+			result = append(result, session.Slot.Start)
+		}
+		return result
+	}
+
+	t.Run("best total attendance", func(t *testing.T) {
+		// ties with 14:00 on the first day and 9:00 on the third, the earliest wins
+		got := Combine(slots, attendees, Overlaps, Sessions{Count: 2})
+		assert.Equal(t, []time.Time{at(9), at(14)}, starts(got))
+		assert.Equal(t, []string{"alice", "bob"}, got[0].Available)
+		assert.Equal(t, []string{"carol"}, got[0].Missing)
+		assert.Equal(t, []string{"alice", "bob", "carol"}, got[1].Available)
+	})
+
+	t.Run("not independently best slots", func(t *testing.T) {
+		// the two best slots are on the same day
+		got := Combine(slots, attendees, Overlaps, Sessions{Count: 2, DistinctDays: true})
+		assert.Equal(t, []time.Time{at(14), at(48 + 9)}, starts(got))
+		got = Combine(slots, attendees, Overlaps, Sessions{Count: 3, DistinctDays: true})
+		assert.Equal(t, []time.Time{at(14), at(24 + 14), at(48 + 9)}, starts(got))
+	})
+
+	t.Run("days follow the location", func(t *testing.T) {
+		// 14:00 UTC is already the next day in Auckland
+		auckland, err := time.LoadLocation("Pacific/Auckland")
+		require.NoError(t, err)
+		got := Combine(slots[:3], attendees, Overlaps, Sessions{Count: 2, DistinctDays: true, Location: auckland})
+		assert.Equal(t, []time.Time{at(9), at(14)}, starts(got))
+	})
+
+	t.Run("gap", func(t *testing.T) {
+		got := Combine(slots[:2], attendees, Overlaps, Sessions{Count: 2, MinGap: 4 * time.Hour})
+		assert.Equal(t, []time.Time{at(9), at(14)}, starts(got))
+		assert.Nil(t, Combine(slots[:2], attendees, Overlaps, Sessions{Count: 2, MinGap: 5 * time.Hour}))
+	})
+
+	t.Run("overlapping slots can't be combined", func(t *testing.T) {
+		assert.Nil(t, Combine([]Interval{span(9, 11), span(10, 12)}, attendees, Overlaps, Sessions{Count: 2}))
+	})
+
+	t.Run("nobody available", func(t *testing.T) {
+		assert.Nil(t, Combine(slots, []Attendee{{ID: "dave"}}, Overlaps, Sessions{Count: 2}))
+		assert.Nil(t, Combine(slots, attendees, Overlaps, Sessions{Count: 7}))
+	})
+}
+
+// combineNaive tries every combination of slots. It is the reference Combine
+// is tested against, and returns the best total attendance.
+func combineNaive(slots []Interval, attendees []Attendee, sessions Sessions) (total, bool) {
+	var best total
+	found := false
+	var try func(from int, chosen []int)
+	try = func(from int, chosen []int) {
+		if len(chosen) == sessions.Count {
+			var sum total
+			for _, s := range chosen {
+				rec := recommendNaive(slots[s:s+1], attendees)
+				sum = sum.add(len(rec.Available), len(rec.Available)-len(rec.IfNeeded))
+			}
+			if sum.count > 0 && (!found || sum.beats(best)) {
+				best, found = sum, true
+			}
+			return
+		}
+		for s := from; s < len(slots); s++ {
+			if compatible(slots, chosen, s, sessions) {
+				try(s+1, append(chosen, s))
+			}
+		}
+	}
+	try(0, nil)
+	return best, found
+}
+
+func compatible(slots []Interval, chosen []int, s int, sessions Sessions) bool {
+	for _, c := range chosen {
+		first, second := slots[c], slots[s]
+		if second.Start.Before(first.Start) {
+			first, second = second, first
+		}
+		if first.End.Add(sessions.MinGap).After(second.Start) {
+			return false
+		}
+		if sessions.DistinctDays && first.Start.YearDay() == second.Start.YearDay() {
+			return false
+		}
+	}
+	return true
+}
+
+func TestCombine_MatchesNaive(t *testing.T) {
+	rng := rand.New(rand.NewSource(1))
+	for run := 0; run < 300; run++ {
+		slots := make([]Interval, 1+rng.Intn(8))
+		for i := range slots {
+			start := epoch.Add(time.Duration(rng.Intn(3*24*2)) * 30 * time.Minute)
+			slots[i] = Interval{Start: start, End: start.Add(time.Duration(1+rng.Intn(4)) * 30 * time.Minute)}
+		}
+		attendees := make([]Attendee, rng.Intn(5))
+		for i := range attendees {
+			attendees[i] = Attendee{ID: fmt.Sprint(i), Availability: randomIntervals(rng, rng.Intn(6))}
+			if rng.Intn(2) == 0 {
+				attendees[i].IfNeeded = randomIntervals(rng, rng.Intn(4))
+			}
+		}
+		sessions := Sessions{
+			Count:        1 + rng.Intn(3),
+			MinGap:       time.Duration(rng.Intn(3)) * time.Hour,
+			DistinctDays: rng.Intn(2) == 0,
+		}
+
+		got := Combine(slots, attendees, Overlaps, sessions)
+
+		want, found := combineNaive(slots, attendees, sessions)
+		if !found {
+			require.Nil(t, got, "run %d", run)
+			continue
+		}
+		require.Len(t, got, sessions.Count, "run %d", run)
+		var sum total
+		var chosen []int
+		for _, session := range got {
+			require.True(t, compatible(slots, chosen, session.Index, sessions), "run %d", run)
+			chosen = append(chosen, session.Index)
+			sum = sum.add(len(session.Available), len(session.Available)-len(session.IfNeeded))
+		}
+		require.Equal(t, want, sum, "run %d", run)
+	}
+}
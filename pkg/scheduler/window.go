@@ -0,0 +1,83 @@
+package scheduler
+
+import "time"
+
+// DefaultStep is how far apart the candidates of a Window start when it
+// doesn't say.
+const DefaultStep = 15 * time.Minute
+
+// Window describes where to look for slots when none are proposed: every
+// Duration long slot within Range that falls inside the daily hours.
+type Window struct {
+	Range        Interval
+	Location     *time.Location // zone of the daily hours, UTC if nil
+	DayStart     time.Duration  // start of the daily hours, as an offset from midnight
+	DayEnd       time.Duration  // end of the daily hours; 24h for the whole day
+	WeekdaysOnly bool           // skip Saturdays and Sundays
+	Duration     time.Duration  // length of the slots
+	Step         time.Duration  // distance between slot starts, DefaultStep if zero
+}
+
+// Candidates lists the slots of w in chronological order. Within a day they
+// start every Step from the start of the daily hours, following the wall
+// clock of Location across daylight saving changes.
+func (w Window) Candidates() []Interval {
+	if w.Duration <= 0 || w.Range.Empty() || w.DayEnd <= w.DayStart {
+		return nil
+	}
+	step := w.Step
+	if step <= 0 {
+		step = DefaultStep
+	}
+	var candidates []Interval
+	for day := w.day(w.Range.Start); day.Before(w.Range.End); day = day.AddDate(0, 0, 1) {
+		hours, ok := w.hours(day)
+		if !ok {
+			continue
+		}
+		for offset := w.DayStart; ; offset += step {
+			start := wallClock(day, offset)
+			slot := Interval{Start: start, End: start.Add(w.Duration)}
+			if !hours.Contains(slot) || slot.End.After(w.Range.End) {
+				break
+			}
+			if !slot.Start.Before(w.Range.Start) {
+				candidates = append(candidates, slot)
+			}
+		}
+	}
+	return candidates
+}
+
+// Allows reports whether slot lies within the range and the daily hours of
+// w, whatever its duration and start.
+func (w Window) Allows(slot Interval) bool {
+	if !w.Range.Contains(slot) {
+		return false
+	}
+	hours, ok := w.hours(w.day(slot.Start))
+	return ok && hours.Contains(slot)
+}
+
+// day returns midnight of the day of t in w's location.
+func (w Window) day(t time.Time) time.Time {
+	location := w.Location
+	if location == nil {
+		location = time.UTC
+	}
+	t = t.In(location)
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, location)
+}
+
+// hours returns the daily hours of day, if it has any.
+func (w Window) hours(day time.Time) (Interval, bool) {
+	if w.WeekdaysOnly && (day.Weekday() == time.Saturday || day.Weekday() == time.Sunday) {
+		return Interval{}, false
+	}
+	return Interval{Start: wallClock(day, w.DayStart), End: wallClock(day, w.DayEnd)}, true
+}
+
+// wallClock returns the time offset past midnight of day on its wall clock.
+func wallClock(day time.Time, offset time.Duration) time.Time {
+	return time.Date(day.Year(), day.Month(), day.Day(), 0, 0, 0, int(offset), day.Location())
+}
@@ -0,0 +1,121 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWindow_Candidates(t *testing.T) {
+	t.Run("daily hours", func(t *testing.T) {
+		// Friday 10:30 to Monday midnight, 9 to 12, weekdays only
+		window := Window{
+			Range:        Interval{Start: at(4*24 + 10).Add(30 * time.Minute), End: at(7 * 24)},
+			DayStart:     9 * time.Hour,
+			DayEnd:       12 * time.Hour,
+			WeekdaysOnly: true,
+			Duration:     time.Hour,
+			Step:         30 * time.Minute,
+		}
+		assert.Equal(t, []Interval{
+			span(4*24+10, 4*24+11).shift(30 * time.Minute),
+			span(4*24+11, 4*24+12),
+		}, window.Candidates())
+	})
+
+	t.Run("whole days with the default step", func(t *testing.T) {
+		window := Window{Range: span(0, 48), DayEnd: 24 * time.Hour, Duration: time.Hour}
+		candidates := window.Candidates()
+		require.Equal(t, 2*(24*4-3), len(candidates), "an hour doesn't fit after 23:00")
+		assert.Equal(t, span(0, 1), candidates[0])
+		assert.Equal(t, span(47, 48), candidates[len(candidates)-1])
+	})
+
+	t.Run("wall clock across daylight saving time", func(t *testing.T) {
+		berlin, err := time.LoadLocation("Europe/Berlin")
+		require.NoError(t, err)
+		// clocks moved forward on Sunday, 30 March 2025
+		window := Window{
+			Range:    Interval{Start: time.Date(2025, 3, 29, 0, 0, 0, 0, berlin), End: time.Date(2025, 3, 31, 0, 0, 0, 0, berlin)},
+			Location: berlin,
+			DayStart: 9 * time.Hour,
+			DayEnd:   10 * time.Hour,
+			Duration: time.Hour,
+		}
+		assert.Equal(t, []Interval{
+			{Start: time.Date(2025, 3, 29, 8, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 29, 9, 0, 0, 0, time.UTC)},
+			{Start: time.Date(2025, 3, 30, 7, 0, 0, 0, time.UTC), End: time.Date(2025, 3, 30, 8, 0, 0, 0, time.UTC)},
+		}, utc(window.Candidates()))
+	})
+
+	t.Run("nothing fits", func(t *testing.T) {
+		assert.Empty(t, Window{Range: span(0, 24), DayStart: 9 * time.Hour, DayEnd: 10 * time.Hour, Duration: 2 * time.Hour}.Candidates())
+		assert.Empty(t, Window{Range: span(0, 24), DayStart: 10 * time.Hour, DayEnd: 9 * time.Hour, Duration: time.Hour}.Candidates())
+		assert.Empty(t, Window{Range: span(0, 24), DayEnd: 24 * time.Hour}.Candidates())
+	})
+}
+
+func TestWindow_Allows(t *testing.T) {
+	window := Window{Range: span(0, 7*24), DayStart: 9 * time.Hour, DayEnd: 17 * time.Hour, WeekdaysOnly: true}
+	assert.True(t, window.Allows(span(9, 17)))
+	assert.True(t, window.Allows(span(24+10, 24+11).shift(5*time.Minute)))
+	assert.False(t, window.Allows(span(8, 10)), "before the daily hours")
+	assert.False(t, window.Allows(span(16, 18)), "after the daily hours")
+	assert.False(t, window.Allows(span(5*24+10, 5*24+11)), "on a Saturday")
+	assert.False(t, window.Allows(span(7*24+10, 7*24+11)), "outside the range")
+}
+
+func TestDiscover(t *testing.T) {
+	window := Window{Range: span(0, 24), DayStart: 9 * time.Hour, DayEnd: 17 * time.Hour, Duration: 2 * time.Hour, Step: time.Hour}
+	attendees := []Attendee{
+		{ID: "alice", Availability: []Interval{span(8, 12), span(14, 17)}},
+		{ID: "bob", Availability: []Interval{span(10, 11), span(11, 13)}},
+		{ID: "carol", Availability: []Interval{span(10, 16)}},
+	}
+	assert.Equal(t, Recommendation{
+		Index:     1,
+		Slot:      span(10, 12),
+		Available: []string{"alice", "bob", "carol"},
+	}, Discover(window.Candidates(), attendees))
+
+	// overlapping availability isn't enough to be found
+	assert.Equal(t, Recommendation{Index: -1}, Discover(window.Candidates(), []Attendee{{ID: "dave", Availability: []Interval{span(9, 10)}}}))
+
+	t.Run("buffers", func(t *testing.T) {
+		window := Window{Range: span(0, 24), DayStart: 9 * time.Hour, DayEnd: 17 * time.Hour, Duration: time.Hour}
+		buffer := Buffer{Before: 15 * time.Minute, After: 15 * time.Minute}
+		// adjacent availability is buffered as one
+		alice := Attendee{ID: "alice", Availability: []Interval{span(9, 10), span(10, 11)}, Buffer: buffer}
+		got := Discover(window.Candidates(), []Attendee{alice})
+		assert.Equal(t, Interval{Start: at(9).Add(15 * time.Minute), End: at(10).Add(15 * time.Minute)}, got.Slot)
+
+		// availability no longer than the buffer leaves nothing
+		bob := Attendee{ID: "bob", Availability: []Interval{{Start: at(12), End: at(12).Add(30 * time.Minute)}}, Buffer: buffer}
+		assert.Equal(t, Recommendation{Index: -1}, Recommend([]Interval{span(12, 13)}, []Attendee{bob}))
+	})
+
+	t.Run("busy time", func(t *testing.T) {
+		window := Window{Range: span(0, 24), DayStart: 9 * time.Hour, DayEnd: 17 * time.Hour, Duration: time.Hour}
+		// a meeting until 10:30 leaves the rest of the morning, less the buffer after it
+		alice := Attendee{ID: "alice", Availability: []Interval{span(9, 13)}, Busy: []Interval{{Start: at(9), End: at(10).Add(30 * time.Minute)}},
+			Buffer: Buffer{Before: 15 * time.Minute}}
+		got := Discover(window.Candidates(), []Attendee{alice})
+		assert.Equal(t, Interval{Start: at(10).Add(45 * time.Minute), End: at(11).Add(45 * time.Minute)}, got.Slot)
+
+		alice.Busy = []Interval{span(8, 14)}
+		assert.Equal(t, Recommendation{Index: -1}, Discover(window.Candidates(), []Attendee{alice}))
+	})
+}
+
+func (i Interval) shift(d time.Duration) Interval {
+	return Interval{Start: i.Start.Add(d), End: i.End.Add(d)}
+}
+
+func utc(intervals []Interval) []Interval {
+	for i := range intervals {
+		intervals[i] = Interval{Start: intervals[i].Start.UTC(), End: intervals[i].End.UTC()}
+	}
+	return intervals
+}
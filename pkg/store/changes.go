@@ -0,0 +1,86 @@
+package store
+
+import (
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+)
+
+// changeTables are the tables whose writes can alter recommendations.
+var changeTables = []string{
+	"users", "user_availabilities", "events", "event_slots", "slot_votes",
+	"resources", "resource_availabilities", "resource_bookings",
+}
+
+// notifyChangeFunction announces every row written to a change table as a
+// bus.Change on the bus.Channel notification channel. Notifications are sent
+// when the writing transaction commits, and identical ones within a
+// transaction are delivered once.
+var notifyChangeFunction = fmt.Sprintf(`
+CREATE OR REPLACE FUNCTION stackgen_notify_change() RETURNS trigger AS $$
+DECLARE
+	r jsonb;
+	change jsonb;
+BEGIN
+	IF TG_OP = 'DELETE' THEN
+		r := to_jsonb(OLD);
+	ELSE
+		r := to_jsonb(NEW);
+	END IF;
+	IF TG_TABLE_NAME = 'users' THEN
+		change := jsonb_build_object('kind', '%[1]s', 'user_id', r->>'id');
+	ELSIF TG_TABLE_NAME = 'user_availabilities' THEN
+		change := jsonb_build_object('kind', '%[2]s', 'user_id', r->>'user_id');
+	ELSIF TG_TABLE_NAME = 'events' AND TG_OP = 'DELETE' THEN
+		change := jsonb_build_object('kind', '%[3]s', 'event_id', r->>'id');
+	ELSIF TG_TABLE_NAME = 'events' THEN
+		change := jsonb_build_object('kind', '%[4]s', 'event_id', r->>'id');
+	ELSIF TG_TABLE_NAME = 'resources' THEN
+		change := jsonb_build_object('kind', '%[6]s', 'resource_id', r->>'id');
+	ELSIF TG_TABLE_NAME IN ('resource_availabilities', 'resource_bookings') THEN
+		change := jsonb_build_object('kind', '%[6]s', 'resource_id', r->>'resource_id');
+	ELSE
+		change := jsonb_build_object('kind', '%[4]s', 'event_id', r->>'event_id');
+	END IF;
+	PERFORM pg_notify('%[5]s', change::text);
+	RETURN NULL;
+END;
+$$ LANGUAGE plpgsql`, bus.UserChanged, bus.AvailabilityChanged, bus.EventDeleted, bus.EventChanged, bus.Channel, bus.ResourceChanged)
+
+// createChangeTrigger creates the trigger calling stackgen_notify_change on
+// a table, named as %[1]s and %[2]s, unless it exists.
+const createChangeTrigger = `
+DO $$
+BEGIN
+	IF NOT EXISTS (SELECT 1 FROM pg_trigger WHERE tgname = '%[1]s' AND tgrelid = '%[2]s'::regclass) THEN
+		CREATE TRIGGER %[1]s AFTER INSERT OR UPDATE OR DELETE ON %[2]s
+			FOR EACH ROW EXECUTE PROCEDURE stackgen_notify_change();
+	END IF;
+END
+$$`
+
+// changeTriggersLock is the advisory lock key, "stackgen" in ASCII, taken
+// while installing the change triggers.
+const changeTriggersLock = 0x737461636b67656e
+
+// installChangeTriggers makes writes to the change tables notify listeners.
+// It is idempotent so it can run on every start, and leaves the triggers of
+// a running deployment in place: replicas starting together take turns, and
+// writes keep notifying throughout.
+func installChangeTriggers(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", changeTriggersLock).Error; err != nil {
+			return err
+		}
+		if err := tx.Exec(notifyChangeFunction).Error; err != nil {
+			return err
+		}
+		for _, table := range changeTables {
+			if err := tx.Exec(fmt.Sprintf(createChangeTrigger, table+"_notify_change", table)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
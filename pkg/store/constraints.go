@@ -0,0 +1,88 @@
+package store
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/jinzhu/gorm"
+)
+
+// constraint is added to a table the models already created. The rows
+// written before it existed that fail it are fixed by repair, a DELETE or
+// an UPDATE.
+type constraint struct {
+	table, name, definition, repair string
+}
+
+// check requires condition of every row of table. Rows without it are
+// deleted.
+func check(table, name, condition string) constraint {
+	return constraint{table, name,
+		fmt.Sprintf("CHECK (%s)", condition),
+		fmt.Sprintf("DELETE FROM %s WHERE NOT (%s)", table, condition)}
+}
+
+// reference makes column of table refer to a row of parent by ID. Rows that
+// refer to a missing one are deleted or, with ON DELETE SET NULL, cleared.
+func reference(table, name, column, parent, onDelete string) constraint {
+	missing := fmt.Sprintf("%s IS NOT NULL AND NOT EXISTS (SELECT 1 FROM %s p WHERE p.id = %s.%s)", column, parent, table, column)
+	repair := fmt.Sprintf("DELETE FROM %s WHERE %s", table, missing)
+	if onDelete == "SET NULL" {
+		repair = fmt.Sprintf("UPDATE %s SET %s = NULL WHERE %s", table, column, missing)
+	}
+	return constraint{table, name,
+		fmt.Sprintf("FOREIGN KEY (%s) REFERENCES %s (id) ON DELETE %s", column, parent, onDelete),
+		repair}
+}
+
+// constraints are added before the indexes, some of which rely on them.
+var constraints = []constraint{
+	// tstzrange, which the availability period index is built on, rejects
+	// periods that end before they start. Zero-length periods are empty
+	// ranges, which overlap nothing, so rows with one are allowed, even if
+	// the API doesn't accept them.
+	check("user_availabilities", "chk_user_availabilities_period", "end_time >= start_time"),
+	// resources with bookings that haven't ended can't be deleted, so an
+	// event only loses the room it was held in
+	reference("events", "fk_events_room", "room_id", "resources", "SET NULL"),
+	reference("resource_availabilities", "fk_resource_availabilities_resource", "resource_id", "resources", "CASCADE"),
+	reference("resource_bookings", "fk_resource_bookings_resource", "resource_id", "resources", "CASCADE"),
+}
+
+// constraintsLock is the advisory lock key, "stackchk" in ASCII, taken while
+// adding constraints.
+const constraintsLock = 0x737461636b63686b
+
+// addConstraints adds the missing constraints, each once per database: the
+// rows written before it existed that fail it are repaired first, and their
+// number logged. Replicas starting together take turns, and those after the
+// first find the constraints in place.
+func addConstraints(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", constraintsLock).Error; err != nil {
+			return err
+		}
+		for _, c := range constraints {
+			var existing int
+			if err := tx.Table("pg_constraint").
+				Where("conname = ? AND conrelid = ?::regclass", c.name, c.table).
+				Count(&existing).Error; err != nil {
+				return err
+			}
+			if existing > 0 {
+				continue
+			}
+			repaired := tx.Exec(c.repair)
+			if repaired.Error != nil {
+				return repaired.Error
+			}
+			if repaired.RowsAffected > 0 {
+				log.Printf("adding constraint %s: repaired %d rows of %s with %s", c.name, repaired.RowsAffected, c.table, c.repair)
+			}
+			if err := tx.Exec(fmt.Sprintf("ALTER TABLE %s ADD CONSTRAINT %s %s", c.table, c.name, c.definition)).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
@@ -12,6 +12,16 @@ import (
 var db *gorm.DB
 
 func InitDB() {
+	var err error
+	db, err = gorm.Open("postgres", DSN())
+	if err != nil {
+		panic(fmt.Sprintf("failed to connect to database: %v", err))
+	}
+}
+
+// DSN returns the Postgres connection string configured by the DB_*
+// environment variables.
+func DSN() string {
 	dbHost := os.Getenv("DB_HOST")
 	if dbHost == "" {
 		panic("DB_HOST environment variable is not set")
@@ -32,12 +42,7 @@ func InitDB() {
 	if dbName == "" {
 		panic("DB_NAME environment variable is not set")
 	}
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbPort, dbUser, dbPass, dbName)
-	var err error
-	db, err = gorm.Open("postgres", dsn)
-	if err != nil {
-		panic(fmt.Sprintf("failed to connect to database: %v", err))
-	}
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable", dbHost, dbPort, dbUser, dbPass, dbName)
 }
 
 func GetDB() *gorm.DB {
@@ -68,10 +73,26 @@ func AutoMigrate() error {
 		&models.Event{},
 		&models.User{},
 		&models.EventSlot{},
+		&models.SlotVote{},
 		&models.UserAvailability{},
+		&models.Resource{},
+		&models.ResourceAvailability{},
+		&models.ResourceBooking{},
+		&models.WebhookSubscription{},
+		&models.WebhookDelivery{},
+		&models.RecommendationSnapshot{},
 	).Error; err != nil {
 		return fmt.Errorf("failed to run migrations: %w", err)
 	}
+	if err := addConstraints(db); err != nil {
+		return fmt.Errorf("failed to add constraints: %w", err)
+	}
+	if err := createIndexes(db); err != nil {
+		return fmt.Errorf("failed to create indexes: %w", err)
+	}
+	if err := installChangeTriggers(db); err != nil {
+		return fmt.Errorf("failed to install change triggers: %w", err)
+	}
 
 	return nil
 }
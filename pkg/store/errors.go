@@ -0,0 +1,50 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+)
+
+// postgres error codes, see https://www.postgresql.org/docs/current/errcodes-appendix.html
+const (
+	pgForeignKeyViolation = "23503"
+	pgUniqueViolation     = "23505"
+	pgInvalidTextRep      = "22P02"
+)
+
+// TranslateError maps driver and ORM errors onto the error taxonomy in models
+// so that handlers never have to know about gorm or postgres.
+func TranslateError(err error) error {
+	if err == nil {
+		return nil
+	}
+	if gorm.IsRecordNotFoundError(err) {
+		return models.ErrNotFound
+	}
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case pgUniqueViolation:
+			return fmt.Errorf("%w: %s", models.ErrConflict, uniqueViolationDetail(pqErr))
+		case pgForeignKeyViolation:
+			return fmt.Errorf("%w: referenced resource does not exist", models.ErrNotFound)
+		case pgInvalidTextRep:
+			// malformed identifiers (e.g. a non-uuid id) can never match a row
+			return models.ErrNotFound
+		}
+	}
+	return err
+}
+
+func uniqueViolationDetail(err *pq.Error) string {
+	switch err.Constraint {
+	case "users_email_key", "uix_users_email":
+		return "email is already registered"
+	default:
+		return "resource already exists"
+	}
+}
@@ -0,0 +1,44 @@
+package store
+
+import (
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/jinzhu/gorm"
+	"github.com/lib/pq"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTranslateError(t *testing.T) {
+	other := errors.New("connection reset")
+	tests := []struct {
+		name     string
+		err      error
+		expected error
+	}{
+		{"nil", nil, nil},
+		{"record not found", gorm.ErrRecordNotFound, models.ErrNotFound},
+		{"unique email", &pq.Error{Code: pgUniqueViolation, Constraint: "uix_users_email"}, models.ErrConflict},
+		{"wrapped unique", fmt.Errorf("insert: %w", &pq.Error{Code: pgUniqueViolation}), models.ErrConflict},
+		{"foreign key", &pq.Error{Code: pgForeignKeyViolation}, models.ErrNotFound},
+		{"invalid uuid", &pq.Error{Code: pgInvalidTextRep}, models.ErrNotFound},
+		{"other", other, other},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := TranslateError(tt.err)
+			if tt.expected == nil {
+				assert.NoError(t, got)
+				return
+			}
+			assert.ErrorIs(t, got, tt.expected)
+		})
+	}
+}
+
+func TestTranslateError_UniqueEmailDetail(t *testing.T) {
+	err := TranslateError(&pq.Error{Code: pgUniqueViolation, Constraint: "uix_users_email"})
+	assert.EqualError(t, err, "conflict: email is already registered")
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+)
+
+// Affected returns the IDs of the events whose recommendations a change may
+// alter, or all if it may alter those of every event.
+type Affected func(change bus.Change) (eventIDs []string, all bool, err error)
+
+// NewAffected returns the Affected of the events in db.
+func NewAffected(db *gorm.DB) Affected {
+	s := &store{db: db}
+	return lookups{involving: s.eventsInvolving, needing: s.eventsNeeding, holding: s.holdsTime}.affected
+}
+
+// lookups find the events a change can affect.
+type lookups struct {
+	// involving returns the IDs of the events whose roster includes the user
+	involving func(userID string) ([]string, error)
+	// needing returns the IDs of the events that need the resource
+	needing func(resourceID string) ([]string, error)
+	// holding reports whether the event may hold time of its participants
+	holding func(eventID string) (bool, error)
+}
+
+// affected is the event itself for an event change, the events on the
+// user's roster, for events without participants any user, for a user
+// change, and the events that need the resource for a resource change. A
+// change to a finalized event can free or take up the time of any user, so
+// it affects every event, as does a resync.
+func (l lookups) affected(change bus.Change) ([]string, bool, error) {
+	switch {
+	case change.EventID != "":
+		held, err := l.holding(change.EventID)
+		if err != nil || held {
+			return nil, true, err
+		}
+		return []string{change.EventID}, false, nil
+	case change.UserID != "":
+		ids, err := l.involving(change.UserID)
+		return ids, false, err
+	case change.ResourceID != "":
+		ids, err := l.needing(change.ResourceID)
+		return ids, false, err
+	default:
+		return nil, true, nil
+	}
+}
@@ -0,0 +1,217 @@
+package events
+
+import (
+	"encoding/json"
+	"expvar"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/cache"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+// cacheTTL bounds how long a result can outlive a missed invalidation, e.g.
+// one made by another replica while this one was disconnected.
+const cacheTTL = 10 * time.Minute
+
+// CacheStats counts recommendation cache hits, misses and invalidated events.
+// It is published with expvar as "recommendation_cache".
+var CacheStats = expvar.NewMap("recommendation_cache")
+
+// maxQueued bounds the changes waiting for invalidation; past it, the cache
+// is cleared instead.
+const maxQueued = 1000
+
+// cachedStore caches recommendation results per event. Entries are dropped
+// when a change affects the event: when it changes, when a user on its
+// roster or their availability changes, for events without participants any
+// user, or when a resource it needs changes or is booked. A change to a
+// finalized event can free or take up the time of any user, so it clears
+// every entry.
+//
+// The events a change affects are looked up by a single worker, off the
+// bus. Until it is done with every change received, the cache is bypassed,
+// so that a result read right after a write reflects it.
+type cachedStore struct {
+	Store
+	cache    cache.Cache
+	affected Affected
+
+	mu sync.Mutex
+	// versions increase with every invalidation of an event, and epoch with
+	// every full clear; a result computed across a change is not cached
+	versions map[string]uint64
+	epoch    uint64
+	// queued are the changes received and not yet taken by the worker, or
+	// nil with overflowed set once they outgrew maxQueued; working is set
+	// while the worker invalidates a batch
+	queued     []bus.Change
+	overflowed bool
+	working    bool
+	wake       chan struct{}
+}
+
+// NewCachedStore returns an event store that caches recommendations in c and
+// invalidates them as changes are announced on changes.
+func NewCachedStore(db *gorm.DB, c cache.Cache, changes bus.Bus) Store {
+	return newCachedStore(&store{db: db}, c, changes, NewAffected(db))
+}
+
+func newCachedStore(s Store, c cache.Cache, changes bus.Bus, affected Affected) *cachedStore {
+	cs := &cachedStore{Store: s, cache: c, affected: affected, versions: map[string]uint64{}, wake: make(chan struct{}, 1)}
+	go cs.work()
+	changes.Listen(cs.queue)
+	return cs
+}
+
+// GetRecommendations returns the cached recommendations of the event, or
+// computes and caches them.
+func (s *cachedStore) GetRecommendations(eventID string) (*models.RecommendedSlot, error) {
+	version, settled := s.version(eventID)
+	if settled {
+		if data, ok := s.cache.Get(eventID); ok {
+			var rec models.RecommendedSlot
+			if err := json.Unmarshal(data, &rec); err == nil {
+				CacheStats.Add("hits", 1)
+				return &rec, nil
+			}
+		}
+	}
+	CacheStats.Add("misses", 1)
+	rec, err := s.Store.GetRecommendations(eventID)
+	if err != nil {
+		return nil, err
+	}
+	if data, err := json.Marshal(rec); err == nil && settled {
+		if after, stillSettled := s.version(eventID); stillSettled && after == version {
+			s.cache.Set(eventID, data, cacheTTL)
+		}
+	}
+	return rec, nil
+}
+
+// version returns the version of the event's entry, and whether every
+// change received has been invalidated.
+func (s *cachedStore) version(eventID string) (uint64, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.epoch + s.versions[eventID], s.settled()
+}
+
+func (s *cachedStore) settled() bool {
+	return len(s.queued) == 0 && !s.overflowed && !s.working
+}
+
+// queue hands change to the worker without blocking the bus.
+func (s *cachedStore) queue(change bus.Change) {
+	s.mu.Lock()
+	if len(s.queued) < maxQueued && !s.overflowed {
+		s.queued = append(s.queued, change)
+	} else {
+		s.queued, s.overflowed = nil, true
+	}
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// work invalidates the queued changes, a batch at a time.
+func (s *cachedStore) work() {
+	for range s.wake {
+		s.mu.Lock()
+		queued, overflowed := s.queued, s.overflowed
+		s.queued, s.overflowed, s.working = nil, false, true
+		s.mu.Unlock()
+		if overflowed {
+			s.clear()
+		} else {
+			s.invalidate(queued)
+		}
+		s.mu.Lock()
+		s.working = false
+		s.mu.Unlock()
+	}
+}
+
+// invalidate drops the entries of the events changes affect. Invalidating is
+// idempotent, and changes repeated in the batch, such as a write seen both
+// from this replica and from the database, are looked up once.
+func (s *cachedStore) invalidate(changes []bus.Change) {
+	seen := map[bus.Change]bool{}
+	for _, change := range changes {
+		if seen[change] {
+			continue
+		}
+		seen[change] = true
+		ids, all, err := s.affected(change)
+		if err != nil {
+			log.Printf("recommendation cache: events affected by %s change: %v", change.Kind, err)
+		}
+		if err != nil || all {
+			s.clear()
+			return
+		}
+		s.drop(ids...)
+	}
+}
+
+func (s *cachedStore) drop(eventIDs ...string) {
+	s.mu.Lock()
+	for _, id := range eventIDs {
+		s.versions[id]++
+	}
+	s.mu.Unlock()
+	s.cache.Delete(eventIDs...)
+	CacheStats.Add("invalidations", int64(len(eventIDs)))
+}
+
+func (s *cachedStore) clear() {
+	s.mu.Lock()
+	s.epoch++
+	s.mu.Unlock()
+	s.cache.Clear()
+	CacheStats.Add("clears", 1)
+}
+
+// eventsInvolving returns the IDs of the events the user takes part in,
+// including every event without an explicit participant list.
+func (s *store) eventsInvolving(userID string) ([]string, error) {
+	var ids []string
+	if err := s.db.Model(&models.Event{}).
+		Where("participant_ids IS NULL OR cardinality(participant_ids) = 0 OR ? = ANY(participant_ids)", userID).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return ids, nil
+}
+
+// eventsNeeding returns the IDs of the events that need the resource, either
+// outright or as one of their rooms.
+func (s *store) eventsNeeding(resourceID string) ([]string, error) {
+	var ids []string
+	if err := s.db.Model(&models.Event{}).
+		Where("? = ANY(resource_ids) OR ? = ANY(room_ids)", resourceID, resourceID).
+		Pluck("id", &ids).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return ids, nil
+}
+
+// holdsTime reports whether the event is finalized, and so takes up the time
+// of its participants, or is gone, and may have.
+func (s *store) holdsTime(eventID string) (bool, error) {
+	var event models.Event
+	if err := s.db.Select("final_start_time").Where("id = ?", eventID).First(&event).Error; err != nil {
+		if gorm.IsRecordNotFoundError(err) {
+			return true, nil
+		}
+		return false, stores.TranslateError(err)
+	}
+	return event.FinalStartTime != nil, nil
+}
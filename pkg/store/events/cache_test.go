@@ -0,0 +1,188 @@
+package events
+
+import (
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/cache"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// countingStore computes a distinct recommendation on every call.
+type countingStore struct {
+	Store
+	calls  map[string]int
+	during func() // runs while a recommendation is being computed
+}
+
+func (s *countingStore) GetRecommendations(eventID string) (*models.RecommendedSlot, error) {
+	if eventID == "missing" {
+		return nil, models.ErrNotFound
+	}
+	s.calls[eventID]++
+	if s.during != nil {
+		s.during()
+	}
+	start := time.Date(2025, 1, 12, s.calls[eventID], 0, 0, 0, time.UTC)
+	return &models.RecommendedSlot{StartTime: start, EndTime: start.Add(time.Hour)}, nil
+}
+
+func newTestCachedStore() (*cachedStore, *countingStore, *bus.Memory) {
+	inner := &countingStore{calls: map[string]int{}}
+	changes := bus.NewMemory()
+	rosters := map[string][]string{"alice": {"e1"}, "bob": {"e1", "e2"}}
+	involving := func(userID string) ([]string, error) {
+		if userID == "broken" {
+			return nil, errors.New("boom")
+		}
+		return rosters[userID], nil
+	}
+	needing := func(resourceID string) ([]string, error) {
+		return map[string][]string{"room": {"e2"}}[resourceID], nil
+	}
+	holding := func(eventID string) (bool, error) {
+		return eventID == "final", nil
+	}
+	affected := lookups{involving: involving, needing: needing, holding: holding}.affected
+	return newCachedStore(inner, cache.NewLRU(10), changes, affected), inner, changes
+}
+
+// settle waits for the cache to invalidate every change published.
+func settle(t *testing.T, s *cachedStore) {
+	t.Helper()
+	require.Eventually(t, func() bool {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		return s.settled()
+	}, time.Second, time.Millisecond)
+}
+
+func counter(name string) int64 {
+	if v := CacheStats.Get(name); v != nil {
+		return v.(interface{ Value() int64 }).Value()
+	}
+	return 0
+}
+
+func TestCachedStore_Hits(t *testing.T) {
+	s, inner, _ := newTestCachedStore()
+	hits, misses := counter("hits"), counter("misses")
+
+	first, err := s.GetRecommendations("e1")
+	require.NoError(t, err)
+	second, err := s.GetRecommendations("e1")
+	require.NoError(t, err)
+
+	assert.Equal(t, first, second)
+	assert.Equal(t, 1, inner.calls["e1"])
+	assert.Equal(t, hits+1, counter("hits"))
+	assert.Equal(t, misses+1, counter("misses"))
+
+	_, err = s.GetRecommendations("missing")
+	assert.ErrorIs(t, err, models.ErrNotFound)
+}
+
+func TestCachedStore_InvalidatesPrecisely(t *testing.T) {
+	s, inner, changes := newTestCachedStore()
+	for _, id := range []string{"e1", "e2", "e3"} {
+		s.GetRecommendations(id)
+	}
+
+	// alice is only on e1's roster
+	changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: "alice"})
+	settle(t, s)
+	for _, id := range []string{"e1", "e2", "e3"} {
+		s.GetRecommendations(id)
+	}
+	assert.Equal(t, map[string]int{"e1": 2, "e2": 1, "e3": 1}, inner.calls)
+
+	changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: "e3"})
+	changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: "bob"})
+	settle(t, s)
+	for _, id := range []string{"e1", "e2", "e3"} {
+		s.GetRecommendations(id)
+	}
+	assert.Equal(t, map[string]int{"e1": 3, "e2": 2, "e3": 2}, inner.calls)
+
+	// only e2 needs the room
+	changes.Publish(bus.Change{Kind: bus.ResourceChanged, ResourceID: "room"})
+	settle(t, s)
+	for _, id := range []string{"e1", "e2", "e3"} {
+		s.GetRecommendations(id)
+	}
+	assert.Equal(t, map[string]int{"e1": 3, "e2": 3, "e3": 2}, inner.calls)
+
+	// unknown rosters and lost notifications clear everything
+	changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: "broken"})
+	settle(t, s)
+	s.GetRecommendations("e3")
+	changes.Publish(bus.Change{Kind: bus.Resync})
+	settle(t, s)
+	s.GetRecommendations("e2")
+	assert.Equal(t, map[string]int{"e1": 3, "e2": 4, "e3": 3}, inner.calls)
+
+	// a finalized event can conflict with any other
+	s.GetRecommendations("e1")
+	changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: "final"})
+	settle(t, s)
+	s.GetRecommendations("e1")
+	assert.Equal(t, 5, inner.calls["e1"])
+}
+
+func TestCachedStore_DoesNotCacheAcrossChange(t *testing.T) {
+	s, inner, changes := newTestCachedStore()
+	inner.during = func() {
+		inner.during = nil
+		changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: "alice"})
+	}
+
+	s.GetRecommendations("e1")
+	settle(t, s)
+	s.GetRecommendations("e1")
+	assert.Equal(t, 2, inner.calls["e1"], "the result computed during the change must not be cached")
+}
+
+func TestCachedStore_InvalidatesOffTheBus(t *testing.T) {
+	inner := &countingStore{calls: map[string]int{}}
+	changes := bus.NewMemory()
+	release := make(chan struct{})
+	var mu sync.Mutex
+	lookups := map[string]int{}
+	affected := func(change bus.Change) ([]string, bool, error) {
+		mu.Lock()
+		lookups[change.UserID]++
+		mu.Unlock()
+		if change.UserID == "alice" {
+			<-release
+		}
+		return []string{"e1"}, false, nil
+	}
+	s := newCachedStore(inner, cache.NewLRU(10), changes, affected)
+	s.GetRecommendations("e1")
+
+	// the lookup blocks the worker, not the bus
+	changes.Publish(bus.Change{Kind: bus.AvailabilityChanged, UserID: "alice"})
+	// delivered twice, as the Postgres bus does with local writes
+	changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: "bob"})
+	changes.Publish(bus.Change{Kind: bus.UserChanged, UserID: "bob"})
+
+	// until the changes are invalidated, results are neither read from nor
+	// written to the cache
+	s.GetRecommendations("e1")
+	s.GetRecommendations("e1")
+	assert.Equal(t, 3, inner.calls["e1"])
+
+	close(release)
+	settle(t, s)
+	s.GetRecommendations("e1")
+	s.GetRecommendations("e1")
+	assert.Equal(t, 4, inner.calls["e1"])
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[string]int{"alice": 1, "bob": 1}, lookups)
+}
@@ -0,0 +1,93 @@
+package events
+
+import (
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+// conflict is a time a user is taken by another finalized event.
+type conflict struct {
+	eventID, title string
+	interval       scheduler.Interval
+}
+
+// conflicts returns, by user, the times the users are taken by finalized
+// events other than event that fall within slots widened by margin: those
+// they were invited to or organize, every week of a recurring one.
+func conflicts(db *gorm.DB, event *models.Event, users []models.User, slots []scheduler.Interval, margin scheduler.Buffer) (map[uuid.UUID][]conflict, error) {
+	if len(slots) == 0 || len(users) == 0 {
+		return nil, nil
+	}
+	var span scheduler.Interval
+	for _, slot := range slots {
+		if span.Start.IsZero() || slot.Start.Before(span.Start) {
+			span.Start = slot.Start
+		}
+		if slot.End.After(span.End) {
+			span.End = slot.End
+		}
+	}
+	span = scheduler.Interval{Start: span.Start.Add(-margin.Before), End: span.End.Add(margin.After)}
+	var finalized []models.Event
+	// a recurring event can take time weeks after its first occurrence
+	if err := db.Where("final_start_time IS NOT NULL AND id <> ?", event.ID).
+		Where("final_start_time < ? AND (final_end_time > ? OR recurrence IS NOT NULL)", span.End, span.Start).
+		Find(&finalized).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return takenBy(finalized, users, span), nil
+}
+
+// takenBy returns, by user, the occurrences of finalized within span. An
+// event takes the time of its organizer and participants, or of every user
+// when it has no participant list.
+func takenBy(finalized []models.Event, users []models.User, span scheduler.Interval) map[uuid.UUID][]conflict {
+	loaded := map[string]uuid.UUID{}
+	for _, user := range users {
+		loaded[user.ID.String()] = user.ID
+	}
+	taken := map[uuid.UUID][]conflict{}
+	for i := range finalized {
+		f := &finalized[i]
+		holders := map[uuid.UUID]bool{}
+		if len(f.ParticipantIDs) == 0 {
+			for _, id := range loaded {
+				holders[id] = true
+			}
+		}
+		for _, participant := range f.ParticipantIDs {
+			if id, ok := loaded[participant]; ok {
+				holders[id] = true
+			}
+		}
+		if f.OrganizerID != nil {
+			if id, ok := loaded[f.OrganizerID.String()]; ok {
+				holders[id] = true
+			}
+		}
+		var times []scheduler.Interval
+		for _, t := range occurrences(f)(scheduler.Interval{Start: *f.FinalStartTime, End: *f.FinalEndTime}) {
+			if t.Overlaps(span) {
+				times = append(times, t)
+			}
+		}
+		for id := range holders {
+			for _, t := range times {
+				taken[id] = append(taken[id], conflict{eventID: f.ID.String(), title: f.Title, interval: t})
+			}
+		}
+	}
+	return taken
+}
+
+// busy returns the times of conflicts.
+func busy(conflicts []conflict) []scheduler.Interval {
+	var intervals []scheduler.Interval
+	for _, c := range conflicts {
+		intervals = append(intervals, c.interval)
+	}
+	return intervals
+}
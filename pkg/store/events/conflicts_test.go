@@ -0,0 +1,51 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/lib/pq"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTakenBy(t *testing.T) {
+	alice := models.User{ID: uuid.New(), Name: "Alice"}
+	bob := models.User{ID: uuid.New(), Name: "Bob"}
+	carol := models.User{ID: uuid.New(), Name: "Carol"}
+	users := []models.User{alice, bob, carol}
+	finalized := func(participants ...models.User) models.Event {
+		start, end := at(9), at(10)
+		event := models.Event{ID: uuid.New(), Title: "Standup", FinalStartTime: &start, FinalEndTime: &end}
+		for _, participant := range participants {
+			event.ParticipantIDs = append(event.ParticipantIDs, participant.ID.String())
+		}
+		return event
+	}
+
+	t.Run("participants and organizer", func(t *testing.T) {
+		event := finalized(alice)
+		event.OrganizerID = &bob.ID
+		taken := takenBy([]models.Event{event}, users, span(8, 12))
+		assert.Len(t, taken, 2)
+		assert.Equal(t, []conflict{{eventID: event.ID.String(), title: "Standup", interval: span(9, 10)}}, taken[alice.ID])
+		assert.Len(t, taken[bob.ID], 1)
+		assert.Empty(t, taken[carol.ID])
+	})
+
+	t.Run("no participant list", func(t *testing.T) {
+		event := finalized()
+		taken := takenBy([]models.Event{event}, users, span(8, 12))
+		assert.Len(t, taken, 3, "an event open to every user takes everyone's time")
+		for _, user := range users {
+			assert.Equal(t, []scheduler.Interval{span(9, 10)}, busy(taken[user.ID]))
+		}
+	})
+
+	t.Run("outside the span", func(t *testing.T) {
+		event := finalized()
+		event.ParticipantIDs = pq.StringArray{alice.ID.String()}
+		assert.Empty(t, takenBy([]models.Event{event}, users, span(11, 12)))
+	})
+}
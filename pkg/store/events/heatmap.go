@@ -0,0 +1,62 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+// GetHeatmap computes how many, and which, participants are available in
+// every bucket of granularity across the event's slots: the proposed ones,
+// or the daily hours of a search. Participants are identified by user ID.
+// Finalized events they are busy with are left out of their availability.
+func (s *store) GetHeatmap(eventID string, granularity time.Duration) (*models.Heatmap, error) {
+	var event models.Event
+	if err := s.db.Preload("EventSlots").First(&event, "id = ?", eventID).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	slots, err := candidateSlots(&event)
+	if err != nil {
+		return nil, err
+	}
+	ranges := scheduler.Merge(slots)
+	if scheduler.Buckets(ranges, granularity) > models.MaxHeatmapBuckets {
+		return nil, &models.ValidationError{Fields: []models.FieldError{
+			{Field: "granularity", Message: fmt.Sprintf("is too fine for the event, which would need more than %d buckets", models.MaxHeatmapBuckets)},
+		}}
+	}
+	var users []models.User
+	participants := s.db
+	if len(event.ParticipantIDs) > 0 {
+		participants = participants.Where("id IN (?)", []string(event.ParticipantIDs))
+	}
+	if err := participants.Preload("Availabilities", availabilityFilter(ranges, scheduler.Buffer{})...).Find(&users).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	taken, err := conflicts(s.db, &event, users, ranges, scheduler.Buffer{})
+	if err != nil {
+		return nil, err
+	}
+	attendees := make([]scheduler.Attendee, len(users))
+	for i := range users {
+		attendees[i] = newAttendee(&event, &users[i], taken)
+	}
+	heatmap := &models.Heatmap{
+		Granularity:  int(granularity / time.Minute),
+		Participants: len(users),
+		Buckets:      []models.HeatmapBucket{},
+	}
+	for _, bucket := range scheduler.Heatmap(ranges, granularity, attendees) {
+		heatmap.Buckets = append(heatmap.Buckets, models.HeatmapBucket{
+			StartTime:       bucket.Slot.Start,
+			EndTime:         bucket.Slot.End,
+			Count:           len(bucket.Available),
+			UserIDs:         bucket.Available,
+			IfNeededUserIDs: bucket.IfNeeded,
+		})
+	}
+	return heatmap, nil
+}
@@ -0,0 +1,131 @@
+package events
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+// submitted returns the users who have submitted any general availability,
+// not just that loaded for the recommendation.
+func (s *store) submitted(users []models.User) (map[uuid.UUID]bool, error) {
+	ids := make([]string, len(users))
+	for i, user := range users {
+		ids[i] = user.ID.String()
+	}
+	var found []string
+	if err := s.db.Model(&models.UserAvailability{}).
+		Where("user_id IN (?) AND event_id IS NULL", ids).
+		Pluck("DISTINCT user_id", &found).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	submitted := map[uuid.UUID]bool{}
+	for _, id := range found {
+		if parsed, err := uuid.Parse(id); err == nil {
+			submitted[parsed] = true
+		}
+	}
+	return submitted, nil
+}
+
+// explain tells why each missing user of rec, who are identified by ID,
+// can't attend, at any occurrence of the slot for a recurring event.
+// submitted holds the users who have submitted any availability.
+func explain(event *models.Event, rec *models.RecommendedSlot, users []models.User, taken map[uuid.UUID][]conflict, submitted map[uuid.UUID]bool) {
+	if rec.StartTime.IsZero() {
+		return
+	}
+	missing := map[string]bool{}
+	for _, id := range rec.MissingUserIDs {
+		missing[id] = true
+	}
+	slot := scheduler.Interval{Start: rec.StartTime, End: rec.EndTime}
+	held := occurrences(event)(slot)
+	declined := declinedUsers(event, slot)
+	locations := map[string]*time.Location{}
+	for i := range users {
+		user := &users[i]
+		if !missing[user.ID.String()] {
+			continue
+		}
+		why := models.MissingUser{UserID: user.ID.String()}
+		margin := buffer(event, user.BufferBefore, user.BufferAfter)
+		switch c, conflicting := firstConflict(taken[user.ID], held, margin); {
+		case declined[user.ID]:
+			why.Reason = models.ReasonDeclined
+		case conflicting:
+			why.Reason, why.EventID, why.EventTitle = models.ReasonConflict, c.eventID, c.title
+		case !submitted[user.ID]:
+			why.Reason = models.ReasonNoAvailability
+		default:
+			why.Reason = models.ReasonUnavailable
+			covered := overlap(newAttendee(event, user, taken).Free(), held)
+			if covered > 0 {
+				why.Reason, why.OverlapMinutes = models.ReasonPartial, int(covered/time.Minute)
+			} else if outsideHours(workday(user, locations), held) {
+				why.Reason = models.ReasonOutsideHours
+			}
+		}
+		rec.Missing = append(rec.Missing, why)
+	}
+}
+
+// declinedUsers returns the users who voted no for the proposed slot.
+func declinedUsers(event *models.Event, slot scheduler.Interval) map[uuid.UUID]bool {
+	declined := map[uuid.UUID]bool{}
+	if event.Search != nil {
+		return declined
+	}
+	for _, proposed := range event.EventSlots {
+		if !proposed.StartTime.Equal(slot.Start) || !proposed.EndTime.Equal(slot.End) {
+			continue
+		}
+		for _, vote := range proposed.Votes {
+			if vote.Vote == models.VoteNo {
+				declined[vote.UserID] = true
+			}
+		}
+	}
+	return declined
+}
+
+// firstConflict returns the first of conflicts that overlaps any of held,
+// widened by margin.
+func firstConflict(conflicts []conflict, held []scheduler.Interval, margin scheduler.Buffer) (conflict, bool) {
+	for _, c := range conflicts {
+		for _, slot := range held {
+			if c.interval.Overlaps(scheduler.Interval{Start: slot.Start.Add(-margin.Before), End: slot.End.Add(margin.After)}) {
+				return c, true
+			}
+		}
+	}
+	return conflict{}, false
+}
+
+// overlap returns how much of held the sorted, disjoint free intervals cover.
+func overlap(free, held []scheduler.Interval) time.Duration {
+	var total time.Duration
+	for _, slot := range held {
+		for _, interval := range free {
+			total += slot.Intersection(interval).Duration()
+		}
+	}
+	return total
+}
+
+// outsideHours reports whether any of held falls outside the working hours,
+// if they are known.
+func outsideHours(workday *scheduler.Workday, held []scheduler.Interval) bool {
+	if workday == nil {
+		return false
+	}
+	for _, slot := range held {
+		if workday.Outside(slot) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,86 @@
+package events
+
+import (
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExplain(t *testing.T) {
+	availability := func(start, end time.Time) []*models.UserAvailability {
+		return []*models.UserAvailability{{Slot: models.Slot{StartTime: start, EndTime: end}}}
+	}
+	user := func(name string, slots []*models.UserAvailability) models.User {
+		return models.User{ID: uuid.New(), Name: name, Availabilities: slots}
+	}
+	users := []models.User{
+		user("alice", availability(at(12), at(13))),
+		user("dave", availability(at(12), at(13))),
+		user("erin", availability(at(9), at(17))),
+		user("frank", nil),
+		user("gina", availability(at(11), at(12).Add(30*time.Minute))),
+		user("hank", availability(at(8), at(9))),
+		user("ivy", availability(at(8), at(9))),
+	}
+	users[5].TimeZone, users[5].WorkdayStart, users[5].WorkdayEnd = "UTC", "08:00", "11:00"
+	submitted := map[uuid.UUID]bool{}
+	for _, u := range users[:3] {
+		submitted[u.ID] = true
+	}
+	for _, u := range users[4:] {
+		submitted[u.ID] = true
+	}
+	taken := map[uuid.UUID][]conflict{
+		users[2].ID: {{eventID: "e1", title: "Review", interval: scheduler.Interval{Start: at(13), End: at(14)}}},
+	}
+	event := &models.Event{
+		BufferAfter: 15,
+		EventSlots: []models.EventSlot{{
+			StartTime: at(12), EndTime: at(13),
+			Votes: []models.SlotVote{{UserID: users[1].ID, Vote: models.VoteNo}},
+		}},
+	}
+	ids := make([]string, len(users))
+	for i, u := range users {
+		ids[i] = u.ID.String()
+	}
+	rec := &models.RecommendedSlot{
+		StartTime:      at(12),
+		EndTime:        at(13),
+		UserIDs:        ids[:1],
+		MissingUserIDs: ids[1:],
+	}
+	explain(event, rec, users, taken, submitted)
+	assert.Equal(t, []models.MissingUser{
+		{UserID: ids[1], Reason: models.ReasonDeclined},
+		// the event's buffer reaches into the review
+		{UserID: ids[2], Reason: models.ReasonConflict, EventID: "e1", EventTitle: "Review"},
+		{UserID: ids[3], Reason: models.ReasonNoAvailability},
+		// less the buffer after the meeting
+		{UserID: ids[4], Reason: models.ReasonPartial, OverlapMinutes: 15},
+		{UserID: ids[5], Reason: models.ReasonOutsideHours},
+		{UserID: ids[6], Reason: models.ReasonUnavailable},
+	}, rec.Missing)
+
+	t.Run("namesakes", func(t *testing.T) {
+		namesakes := []models.User{user("alice", availability(at(12), at(13))), user("alice", nil)}
+		rec := &models.RecommendedSlot{
+			StartTime:      at(12),
+			EndTime:        at(13),
+			UserIDs:        []string{namesakes[0].ID.String()},
+			MissingUserIDs: []string{namesakes[1].ID.String()},
+		}
+		explain(&models.Event{}, rec, namesakes, nil, map[uuid.UUID]bool{namesakes[0].ID: true})
+		assert.Equal(t, []models.MissingUser{{UserID: namesakes[1].ID.String(), Reason: models.ReasonNoAvailability}}, rec.Missing)
+	})
+
+	t.Run("nothing recommended", func(t *testing.T) {
+		rec := &models.RecommendedSlot{}
+		explain(event, rec, users, taken, submitted)
+		assert.Empty(t, rec.Missing)
+	})
+}
@@ -3,91 +3,377 @@ package events
 import (
 	"fmt"
 	"sort"
+	"strings"
+	"time"
 
+	"github.com/google/uuid"
 	"github.com/jinzhu/gorm"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
 )
 
 // GetRecommendations retrieves recommended slots for an event based on user availability.
 func (s *store) GetRecommendations(eventID string) (*models.RecommendedSlot, error) {
 	var event models.Event
 	var users []models.User
-	if err := s.db.Preload("EventSlots").First(&event, "id = ?", eventID).Error; err != nil {
+	if err := s.db.Preload("EventSlots.Votes").First(&event, "id = ?", eventID).Error; err != nil {
 		fmt.Println("Error retrieving event:", err)
-		if gorm.IsRecordNotFoundError(err) {
-			return nil, gorm.ErrRecordNotFound
+		return nil, stores.TranslateError(err)
+	}
+	slots, err := candidateSlots(&event)
+	if err != nil {
+		return nil, err
+	}
+	var rooms []string
+	if needsResources(&event) {
+		if slots, rooms, err = s.bookable(&event, slots); err != nil {
+			return nil, err
 		}
+	}
+	participants := s.db
+	if len(event.ParticipantIDs) > 0 {
+		participants = participants.Where("id IN (?)", []string(event.ParticipantIDs))
+	}
+	margin, err := bufferMargin(&event, participants)
+	if err != nil {
 		return nil, err
 	}
-	if err := s.db.Preload("Availabilities", "event_id IS NULL").Find(&users).Error; err != nil {
+	// only load the availability that intersects the candidate slots, every
+	// occurrence of them for a recurring event, and the buffers around them
+	loaded := slots
+	if event.Recurrence != nil {
+		loaded = nil
+		series := recurrence(&event)
+		for _, slot := range slots {
+			loaded = append(loaded, series.Occurrences(slot)...)
+		}
+	}
+	if err := participants.Preload("Availabilities", availabilityFilter(loaded, margin)...).Find(&users).Error; err != nil {
 		fmt.Println("Error retrieving users:", err)
+		return nil, stores.TranslateError(err)
+	}
+	taken, err := conflicts(s.db, &event, users, loaded, margin)
+	if err != nil {
 		return nil, err
 	}
-	// Logic to calculate recommended slots based on event and user availability
-	// This is a placeholder; actual implementation would involve more complex logic
-	var finalSlot models.EventSlot
-	var finalAvailableUsers, finalMissingUsers []string
-	for i := 0; i < len(event.EventSlots); i++ {
-		var availableUsers, missingUsers []string
-		for _, user := range users {
-			mergedAvailabilities := MergeConsecutiveAvailabilities(user.Availabilities)
-			if checkAvailability(event.EventSlots[i], mergedAvailabilities) {
-				availableUsers = append(availableUsers, user.Name)
-			} else {
-				missingUsers = append(missingUsers, user.Name)
-			}
+	rec := schedule(&event, slots, users, taken)
+	if len(rec.MissingUserIDs) > 0 {
+		submitted, err := s.submitted(users)
+		if err != nil {
+			return nil, err
 		}
-		if len(availableUsers) > len(finalAvailableUsers) {
-			finalSlot = event.EventSlots[i]
-			finalAvailableUsers = availableUsers
-			finalMissingUsers = missingUsers
+		explain(&event, rec, users, taken, submitted)
+	}
+	for i, room := range rooms {
+		if slots[i].Start.Equal(rec.StartTime) && slots[i].End.Equal(rec.EndTime) {
+			rec.RoomID = room
+			break
 		}
 	}
-	return &models.RecommendedSlot{
-		StartTime:      finalSlot.StartTime,
-		EndTime:        finalSlot.EndTime,
-		UserIDs:        finalAvailableUsers,
-		MissingUserIDs: finalMissingUsers,
-	}, nil
+	return rec, nil
 }
 
-func MergeConsecutiveAvailabilities(slots []*models.UserAvailability) []*models.UserAvailability {
-	if len(slots) == 0 {
-		return nil
+// bufferMargin returns the longest buffers before and after the event that
+// any of its participants keeps.
+func bufferMargin(event *models.Event, participants *gorm.DB) (scheduler.Buffer, error) {
+	var before, after int
+	if err := participants.Model(&models.User{}).
+		Select("COALESCE(MAX(buffer_before), 0), COALESCE(MAX(buffer_after), 0)").
+		Row().Scan(&before, &after); err != nil {
+		return scheduler.Buffer{}, stores.TranslateError(err)
+	}
+	return buffer(event, before, after), nil
+}
+
+// buffer returns the buffer an attendee with the given buffers, in minutes,
+// keeps around the event: theirs or the event's, whichever is longer.
+func buffer(event *models.Event, before, after int) scheduler.Buffer {
+	return scheduler.Buffer{
+		Before: time.Duration(max(before, event.BufferBefore)) * time.Minute,
+		After:  time.Duration(max(after, event.BufferAfter)) * time.Minute,
 	}
+}
 
-	// Sort by start time
-	sort.Slice(slots, func(i, j int) bool {
-		return slots[i].StartTime.Before(slots[j].StartTime)
+// availabilityFilter returns the preload condition, followed by its arguments,
+// selecting the general availability rows that overlap any of slots widened
+// by margin. Each merged slot range is a separate predicate so that the GiST
+// index on the row's period serves it.
+func availabilityFilter(slots []scheduler.Interval, margin scheduler.Buffer) []interface{} {
+	sorted := make([]scheduler.Interval, len(slots))
+	for i, slot := range slots {
+		sorted[i] = scheduler.Interval{Start: slot.Start.Add(-margin.Before), End: slot.End.Add(margin.After)}
+	}
+	sort.Slice(sorted, func(i, j int) bool {
+		return sorted[i].Start.Before(sorted[j].Start)
 	})
+	var ranges []string
+	args := []interface{}{""}
+	for i := 0; i < len(sorted); {
+		start, end := sorted[i].Start, sorted[i].End
+		for i++; i < len(sorted) && !sorted[i].Start.After(end); i++ {
+			if sorted[i].End.After(end) {
+				end = sorted[i].End
+			}
+		}
+		ranges = append(ranges, "tstzrange(start_time, end_time) && tstzrange(?, ?)")
+		args = append(args, start, end)
+	}
+	if len(ranges) == 0 {
+		return []interface{}{"FALSE"}
+	}
+	args[0] = "event_id IS NULL AND (" + strings.Join(ranges, " OR ") + ")"
+	return args
+}
 
-	var merged []*models.UserAvailability
-	current := slots[0]
+// maxCandidates bounds the candidates reported for events with fairness or a
+// quorum.
+const maxCandidates = 10
 
-	for i := 1; i < len(slots); i++ {
-		next := slots[i]
-		// If next starts at or before current ends, or exactly when it ends
-		if !next.StartTime.After(current.EndTime) {
-			// Merge the two
-			if next.EndTime.After(current.EndTime) {
-				current.EndTime = next.EndTime
-			}
+// votes maps the answers of slot votes onto those of the scheduler.
+var votes = map[string]scheduler.Vote{
+	models.VoteYes:   scheduler.VoteYes,
+	models.VoteMaybe: scheduler.VoteMaybe,
+	models.VoteNo:    scheduler.VoteNo,
+}
+
+// schedule matches the loaded users against the event's candidate slots,
+// less the times taken by their conflicts, with the scheduler the event
+// calls for, and converts its result back. Users are identified by ID.
+func schedule(event *models.Event, slots []scheduler.Interval, users []models.User, taken map[uuid.UUID][]conflict) *models.RecommendedSlot {
+	attendees := make([]scheduler.Attendee, len(users))
+	locations := map[string]*time.Location{}
+	voted := slotVotes(event)
+	for i, user := range users {
+		attendees[i] = newAttendee(event, &user, taken)
+		attendees[i].Votes = voted[user.ID]
+		if event.Fairness {
+			attendees[i].Workday = workday(&user, locations)
+		}
+	}
+	if event.Recurrence != nil {
+		return recur(slots, attendees, fit(event), recurrence(event))
+	}
+	if event.Sessions != nil {
+		return combine(slots, attendees, fit(event), sessions(event))
+	}
+	if event.Fairness || event.Quorum != nil {
+		return rank(slots, attendees, fit(event), quorum(event))
+	}
+	var recommendation scheduler.Recommendation
+	if event.Search != nil {
+		recommendation = scheduler.Discover(slots, attendees)
+	} else {
+		recommendation = scheduler.Recommend(slots, attendees)
+	}
+	if recommendation.Index < 0 {
+		return &models.RecommendedSlot{}
+	}
+	return &models.RecommendedSlot{
+		StartTime:       recommendation.Slot.Start,
+		EndTime:         recommendation.Slot.End,
+		UserIDs:         recommendation.Available,
+		IfNeededUserIDs: recommendation.IfNeeded,
+		MissingUserIDs:  recommendation.Missing,
+	}
+}
+
+// newAttendee returns the loaded user as an attendee of the event, identified
+// by their ID, without their votes or working hours.
+func newAttendee(event *models.Event, user *models.User, taken map[uuid.UUID][]conflict) scheduler.Attendee {
+	attendee := scheduler.Attendee{
+		ID:     user.ID.String(),
+		Buffer: buffer(event, user.BufferBefore, user.BufferAfter),
+		Busy:   busy(taken[user.ID]),
+	}
+	for _, slot := range user.Availabilities {
+		interval := scheduler.Interval{Start: slot.StartTime, End: slot.EndTime}
+		if slot.Preference == models.PreferenceIfNeeded {
+			attendee.IfNeeded = append(attendee.IfNeeded, interval)
 		} else {
-			merged = append(merged, current)
-			current = next
+			attendee.Availability = append(attendee.Availability, interval)
 		}
 	}
+	return attendee
+}
 
-	// Add the last one
-	merged = append(merged, current)
-	return merged
+// slotVotes returns the votes cast on the event's proposed slots by user,
+// keyed by the position of the slot.
+func slotVotes(event *models.Event) map[uuid.UUID]map[int]scheduler.Vote {
+	if event.Search != nil {
+		return nil
+	}
+	voted := map[uuid.UUID]map[int]scheduler.Vote{}
+	for s, slot := range event.EventSlots {
+		for _, vote := range slot.Votes {
+			if voted[vote.UserID] == nil {
+				voted[vote.UserID] = map[int]scheduler.Vote{}
+			}
+			voted[vote.UserID][s] = votes[vote.Vote]
+		}
+	}
+	return voted
+}
+
+// quorum returns the quorum of the event, if any, on the attendees, who are
+// identified by ID. A required user who wasn't loaded is no attendee, so the
+// quorum can't be met.
+func quorum(event *models.Event) *scheduler.Quorum {
+	if event.Quorum == nil {
+		return nil
+	}
+	return &scheduler.Quorum{Min: event.Quorum.MinAttendees, Required: event.Quorum.RequiredIDs}
+}
+
+// sessions returns the sessions of the event for the scheduler. Days are
+// told apart in the sessions' time zone, or else the search's.
+func sessions(event *models.Event) scheduler.Sessions {
+	location := eventLocation(event, event.Sessions.TimeZone)
+	return scheduler.Sessions{
+		Count:        event.Sessions.Count,
+		MinGap:       time.Duration(event.Sessions.MinGap) * time.Minute,
+		DistinctDays: event.Sessions.DistinctDays,
+		Location:     location,
+	}
 }
 
-func checkAvailability(slot models.EventSlot, availabilities []*models.UserAvailability) bool {
-	for _, availability := range availabilities {
-		if slot.StartTime.Before(availability.EndTime) && slot.EndTime.After(availability.StartTime) {
-			return true
+// recurrence returns the recurrence of the event for the scheduler. The
+// series keeps its time in the recurrence's time zone, or else the search's.
+func recurrence(event *models.Event) scheduler.Recurrence {
+	return scheduler.Recurrence{
+		Weeks:    event.Recurrence.Weeks,
+		Location: eventLocation(event, event.Recurrence.TimeZone),
+	}
+}
+
+// eventLocation loads zone, falling back to the time zone of the event's
+// search and then to UTC.
+func eventLocation(event *models.Event, zone string) *time.Location {
+	if zone == "" && event.Search != nil {
+		zone = event.Search.TimeZone
+	}
+	location, err := time.LoadLocation(zone)
+	if err != nil {
+		return time.UTC
+	}
+	return location
+}
+
+// recur recommends the slot whose weekly occurrences have the most
+// attendance, reporting each week's.
+func recur(slots []scheduler.Interval, attendees []scheduler.Attendee, fit scheduler.Fit, recurrence scheduler.Recurrence) *models.RecommendedSlot {
+	series := scheduler.Recur(slots, attendees, fit, recurrence)
+	if series.Index < 0 {
+		return &models.RecommendedSlot{}
+	}
+	result := &models.RecommendedSlot{
+		StartTime:       series.Slot.Start,
+		EndTime:         series.Slot.End,
+		UserIDs:         series.Available,
+		IfNeededUserIDs: series.IfNeeded,
+		MissingUserIDs:  series.Missing,
+	}
+	for _, occurrence := range series.Occurrences {
+		result.Occurrences = append(result.Occurrences, models.Occurrence{
+			StartTime:       occurrence.Slot.Start,
+			EndTime:         occurrence.Slot.End,
+			UserIDs:         occurrence.Available,
+			IfNeededUserIDs: occurrence.IfNeeded,
+			MissingUserIDs:  occurrence.Missing,
+		})
+	}
+	return result
+}
+
+// combine recommends the best combination of sessions, the first of which
+// is also reported as the recommended slot.
+func combine(slots []scheduler.Interval, attendees []scheduler.Attendee, fit scheduler.Fit, sessions scheduler.Sessions) *models.RecommendedSlot {
+	combination := scheduler.Combine(slots, attendees, fit, sessions)
+	if len(combination) == 0 {
+		return &models.RecommendedSlot{}
+	}
+	result := &models.RecommendedSlot{
+		StartTime:       combination[0].Slot.Start,
+		EndTime:         combination[0].Slot.End,
+		UserIDs:         combination[0].Available,
+		IfNeededUserIDs: combination[0].IfNeeded,
+		MissingUserIDs:  combination[0].Missing,
+	}
+	for _, session := range combination {
+		result.Sessions = append(result.Sessions, models.Session{
+			StartTime:       session.Slot.Start,
+			EndTime:         session.Slot.End,
+			UserIDs:         session.Available,
+			IfNeededUserIDs: session.IfNeeded,
+			MissingUserIDs:  session.Missing,
+		})
+	}
+	return result
+}
+
+// rank recommends the best slot, along with the best candidates, ranking
+// those that meet quorum first if there is one.
+func rank(slots []scheduler.Interval, attendees []scheduler.Attendee, fit scheduler.Fit, quorum *scheduler.Quorum) *models.RecommendedSlot {
+	var q scheduler.Quorum
+	if quorum != nil {
+		q = *quorum
+	}
+	ranked := scheduler.Rank(slots, attendees, fit, q, maxCandidates)
+	if len(ranked) == 0 {
+		return &models.RecommendedSlot{}
+	}
+	meets := func(candidate scheduler.Candidate) *bool {
+		if quorum == nil {
+			return nil
+		}
+		return &candidate.MeetsQuorum
+	}
+	result := &models.RecommendedSlot{
+		StartTime:       ranked[0].Slot.Start,
+		EndTime:         ranked[0].Slot.End,
+		UserIDs:         ranked[0].Available,
+		IfNeededUserIDs: ranked[0].IfNeeded,
+		MissingUserIDs:  ranked[0].Missing,
+		MeetsQuorum:     meets(ranked[0]),
+	}
+	for _, candidate := range ranked {
+		converted := models.Candidate{
+			StartTime:       candidate.Slot.Start,
+			EndTime:         candidate.Slot.End,
+			UserIDs:         candidate.Available,
+			IfNeededUserIDs: candidate.IfNeeded,
+			MissingUserIDs:  candidate.Missing,
+			MeetsQuorum:     meets(candidate),
+			PainScore:       int(candidate.Pain / time.Minute),
+		}
+		for _, attendee := range candidate.Attendees {
+			converted.Attendees = append(converted.Attendees, models.AttendeeSlot{
+				UserID:    attendee.ID,
+				StartTime: attendee.Local.Start,
+				EndTime:   attendee.Local.End,
+				PainScore: int(attendee.Pain / time.Minute),
+			})
+		}
+		result.Candidates = append(result.Candidates, converted)
+	}
+	return result
+}
+
+// workday returns the working hours of user, if they have set them. Time
+// zones are loaded once per recommendation.
+func workday(user *models.User, locations map[string]*time.Location) *scheduler.Workday {
+	start, errStart := models.ParseClock(user.WorkdayStart)
+	end, errEnd := models.ParseClock(user.WorkdayEnd)
+	if errStart != nil || errEnd != nil || end <= start {
+		return nil
+	}
+	location, ok := locations[user.TimeZone]
+	if !ok {
+		var err error
+		if location, err = time.LoadLocation(user.TimeZone); err != nil {
+			location = time.UTC
 		}
+		locations[user.TimeZone] = location
 	}
-	return false
+	return &scheduler.Workday{Location: location, Start: start, End: end}
 }
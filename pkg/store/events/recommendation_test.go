@@ -0,0 +1,374 @@
+package events
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func at(hour int) time.Time { return time.Date(2025, 1, 13, hour, 0, 0, 0, time.UTC) }
+
+func span(from, to int) scheduler.Interval { return scheduler.Interval{Start: at(from), End: at(to)} }
+
+// recommend schedules the event as GetRecommendations does, explaining the
+// missing users if submitted is given, and names the users.
+func recommend(event *models.Event, slots []scheduler.Interval, users []models.User, taken map[uuid.UUID][]conflict, submitted ...map[uuid.UUID]bool) *models.RecommendedSlot {
+	rec := schedule(event, slots, users, taken)
+	if len(submitted) > 0 {
+		explain(event, rec, users, taken, submitted[0])
+	}
+	return named(rec, users)
+}
+
+// named replaces the user IDs in rec with the names of the users, for
+// expectations that read better.
+func named(rec *models.RecommendedSlot, users []models.User) *models.RecommendedSlot {
+	names := make(map[string]string, len(users))
+	for _, user := range users {
+		names[user.ID.String()] = user.Name
+	}
+	rename := func(ids []string) []string {
+		if ids == nil {
+			return nil
+		}
+		renamed := make([]string, len(ids))
+		for i, id := range ids {
+			renamed[i] = names[id]
+		}
+		return renamed
+	}
+	rec.UserIDs, rec.IfNeededUserIDs, rec.MissingUserIDs = rename(rec.UserIDs), rename(rec.IfNeededUserIDs), rename(rec.MissingUserIDs)
+	for i := range rec.Missing {
+		rec.Missing[i].UserID = names[rec.Missing[i].UserID]
+	}
+	for i := range rec.Occurrences {
+		o := &rec.Occurrences[i]
+		o.UserIDs, o.IfNeededUserIDs, o.MissingUserIDs = rename(o.UserIDs), rename(o.IfNeededUserIDs), rename(o.MissingUserIDs)
+	}
+	for i := range rec.Sessions {
+		s := &rec.Sessions[i]
+		s.UserIDs, s.IfNeededUserIDs, s.MissingUserIDs = rename(s.UserIDs), rename(s.IfNeededUserIDs), rename(s.MissingUserIDs)
+	}
+	for i := range rec.Candidates {
+		c := &rec.Candidates[i]
+		c.UserIDs, c.IfNeededUserIDs, c.MissingUserIDs = rename(c.UserIDs), rename(c.IfNeededUserIDs), rename(c.MissingUserIDs)
+		for j := range c.Attendees {
+			c.Attendees[j].UserID = names[c.Attendees[j].UserID]
+		}
+	}
+	return rec
+}
+
+func TestAvailabilityFilter(t *testing.T) {
+	t.Run("no slots", func(t *testing.T) {
+		assert.Equal(t, []interface{}{"FALSE"}, availabilityFilter(nil, scheduler.Buffer{}))
+	})
+
+	t.Run("overlapping and adjacent slots share a range", func(t *testing.T) {
+		filter := availabilityFilter([]scheduler.Interval{span(15, 16), span(9, 11), span(10, 12), span(12, 13)}, scheduler.Buffer{})
+		assert.Equal(t, []interface{}{
+			"event_id IS NULL AND (tstzrange(start_time, end_time) && tstzrange(?, ?) OR tstzrange(start_time, end_time) && tstzrange(?, ?))",
+			at(9), at(13),
+			at(15), at(16),
+		}, filter)
+	})
+
+	t.Run("slots are widened by the margin", func(t *testing.T) {
+		filter := availabilityFilter([]scheduler.Interval{span(9, 10), span(11, 12)}, scheduler.Buffer{Before: 30 * time.Minute, After: 30 * time.Minute})
+		assert.Equal(t, []interface{}{
+			"event_id IS NULL AND (tstzrange(start_time, end_time) && tstzrange(?, ?))",
+			at(9).Add(-30 * time.Minute), at(12).Add(30 * time.Minute),
+		}, filter)
+	})
+}
+
+func TestRecommend(t *testing.T) {
+	availability := func(from, to int) *models.UserAvailability {
+		return &models.UserAvailability{Slot: models.Slot{StartTime: at(from), EndTime: at(to)}}
+	}
+	slots := []scheduler.Interval{span(9, 10), span(12, 13), span(14, 15)}
+
+	t.Run("first slot with the most users wins", func(t *testing.T) {
+		users := []models.User{
+			{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(11, 12), availability(12, 16)}},
+			{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(14, 15)}},
+			{ID: uuid.New(), Name: "carol", Availabilities: []*models.UserAvailability{availability(8, 9), availability(10, 11)}},
+		}
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime:      at(14),
+			EndTime:        at(15),
+			UserIDs:        []string{"alice", "bob"},
+			MissingUserIDs: []string{"carol"},
+		}, recommend(&models.Event{}, slots, users, nil))
+
+		// as the heatmap does, users are identified by ID
+		rec := schedule(&models.Event{}, slots, users, nil)
+		assert.Equal(t, []string{users[0].ID.String(), users[1].ID.String()}, rec.UserIDs)
+		assert.Equal(t, []string{users[2].ID.String()}, rec.MissingUserIDs)
+	})
+
+	t.Run("discovered slots need availability throughout", func(t *testing.T) {
+		users := []models.User{
+			{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(9, 13)}},
+			{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(8, 9), availability(12, 13), availability(14, 15)}},
+		}
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime: at(12),
+			EndTime:   at(13),
+			UserIDs:   []string{"alice", "bob"},
+		}, recommend(&models.Event{Search: &models.SlotSearch{}}, slots, users, nil))
+	})
+
+	t.Run("fairness", func(t *testing.T) {
+		users := []models.User{
+			{ID: uuid.New(), Name: "asha", TimeZone: "Asia/Kolkata", WorkdayStart: "09:00", WorkdayEnd: "18:00",
+				Availabilities: []*models.UserAvailability{availability(0, 24)}},
+			{ID: uuid.New(), Name: "bob", TimeZone: "America/New_York", WorkdayStart: "09:00", WorkdayEnd: "17:00",
+				Availabilities: []*models.UserAvailability{availability(0, 24)}},
+			{ID: uuid.New(), Name: "carol", Availabilities: []*models.UserAvailability{availability(9, 10)}},
+		}
+		// 14:00 UTC is 19:30 for asha and 09:00 for bob
+		slots := []scheduler.Interval{span(3, 4), span(14, 15)}
+		got := recommend(&models.Event{Fairness: true}, slots, users, nil)
+		assert.Equal(t, at(14), got.StartTime)
+		assert.Equal(t, []string{"asha", "bob"}, got.UserIDs)
+		require.Len(t, got.Candidates, 2)
+		assert.Equal(t, 60, got.Candidates[0].PainScore)
+		assert.Equal(t, 90, got.Candidates[1].PainScore)
+		require.Len(t, got.Candidates[0].Attendees, 2)
+		assert.Equal(t, "19:30 +0530", got.Candidates[0].Attendees[0].StartTime.Format("15:04 -0700"))
+		assert.Equal(t, 60, got.Candidates[0].Attendees[0].PainScore)
+		assert.Equal(t, "09:00 EST", got.Candidates[0].Attendees[1].StartTime.Format("15:04 MST"))
+		assert.Equal(t, 0, got.Candidates[0].Attendees[1].PainScore)
+	})
+
+	t.Run("availability given only if needed", func(t *testing.T) {
+		reluctantly := availability(9, 10)
+		reluctantly.Preference = models.PreferenceIfNeeded
+		alice := models.User{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{reluctantly, availability(14, 15)}}
+		bob := models.User{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(9, 15)}}
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime: at(14),
+			EndTime:   at(15),
+			UserIDs:   []string{"alice", "bob"},
+		}, recommend(&models.Event{}, slots, []models.User{alice, bob}, nil))
+
+		alice.Availabilities = alice.Availabilities[:1]
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime:       at(9),
+			EndTime:         at(10),
+			UserIDs:         []string{"alice", "bob"},
+			IfNeededUserIDs: []string{"alice"},
+		}, recommend(&models.Event{}, slots, []models.User{alice, bob}, nil))
+	})
+
+	t.Run("votes override availability", func(t *testing.T) {
+		alice := models.User{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(9, 10)}}
+		bob := models.User{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(9, 10)}}
+		event := &models.Event{EventSlots: []models.EventSlot{
+			{StartTime: at(9), EndTime: at(10), Votes: []models.SlotVote{{UserID: alice.ID, Vote: models.VoteNo}}},
+			{StartTime: at(12), EndTime: at(13), Votes: []models.SlotVote{{UserID: alice.ID, Vote: models.VoteYes}, {UserID: bob.ID, Vote: models.VoteMaybe}}},
+		}}
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime:       at(12),
+			EndTime:         at(13),
+			UserIDs:         []string{"alice", "bob"},
+			IfNeededUserIDs: []string{"bob"},
+		}, recommend(event, slots[:2], []models.User{alice, bob}, nil))
+	})
+
+	t.Run("quorum", func(t *testing.T) {
+		lead := models.User{ID: uuid.New(), Name: "lead", Availabilities: []*models.UserAvailability{availability(12, 13)}}
+		users := []models.User{
+			lead,
+			{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(9, 15)}},
+			{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(9, 10)}},
+			{ID: uuid.New(), Name: "carol", Availabilities: []*models.UserAvailability{availability(9, 10)}},
+		}
+		event := &models.Event{Quorum: &models.Quorum{MinAttendees: 2, RequiredIDs: []string{lead.ID.String()}}}
+		got := recommend(event, slots, users, nil)
+		assert.Equal(t, at(12), got.StartTime)
+		assert.Equal(t, []string{"lead", "alice"}, got.UserIDs)
+		require.NotNil(t, got.MeetsQuorum)
+		assert.True(t, *got.MeetsQuorum)
+		require.Len(t, got.Candidates, 3)
+		assert.False(t, *got.Candidates[1].MeetsQuorum)
+
+		// a required user who isn't a participant
+		got = recommend(event, slots, users[1:], nil)
+		assert.Equal(t, at(9), got.StartTime)
+		assert.False(t, *got.MeetsQuorum)
+
+		// another user named like the required one doesn't stand in for them
+		namesake := models.User{ID: uuid.New(), Name: "lead", Availabilities: []*models.UserAvailability{availability(9, 10)}}
+		got = recommend(event, slots, append([]models.User{namesake}, users[1:]...), nil)
+		assert.Equal(t, at(9), got.StartTime)
+		assert.False(t, *got.MeetsQuorum)
+	})
+
+	t.Run("buffers", func(t *testing.T) {
+		squeezed := &models.UserAvailability{Slot: models.Slot{StartTime: at(9), EndTime: at(9).Add(45 * time.Minute)}}
+		users := []models.User{
+			{ID: uuid.New(), Name: "alice", BufferAfter: 30, Availabilities: []*models.UserAvailability{squeezed, availability(12, 13)}},
+			{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{squeezed, availability(12, 13)}},
+		}
+		// alice's own buffer and the event's leave no time at 9
+		assert.Equal(t, []string{"bob"}, recommend(&models.Event{BufferBefore: 20}, slots[:1], users, nil).UserIDs)
+		assert.Equal(t, []string{"alice", "bob"}, recommend(&models.Event{}, slots[:1], users, nil).UserIDs)
+		assert.Equal(t, []string{"alice", "bob"}, recommend(&models.Event{BufferBefore: 20}, slots[1:2], users, nil).UserIDs)
+	})
+
+	t.Run("sessions", func(t *testing.T) {
+		users := []models.User{
+			{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(12, 16)}},
+			{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(9, 10), availability(14, 15)}},
+		}
+		got := recommend(&models.Event{Sessions: &models.Sessions{Count: 2, MinGap: 90}}, slots, users, nil)
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime:      at(9),
+			EndTime:        at(10),
+			UserIDs:        []string{"bob"},
+			MissingUserIDs: []string{"alice"},
+			Sessions: []models.Session{
+				{StartTime: at(9), EndTime: at(10), UserIDs: []string{"bob"}, MissingUserIDs: []string{"alice"}},
+				{StartTime: at(14), EndTime: at(15), UserIDs: []string{"alice", "bob"}},
+			},
+		}, got)
+
+		// the slots all fall on the same day in UTC, but not in Auckland
+		distinct := &models.Sessions{Count: 2, DistinctDays: true}
+		assert.Equal(t, &models.RecommendedSlot{}, recommend(&models.Event{Sessions: distinct}, slots, users, nil))
+		distinct.TimeZone = "Pacific/Auckland"
+		got = recommend(&models.Event{Sessions: distinct}, slots, users, nil)
+		require.Len(t, got.Sessions, 2)
+		assert.Equal(t, []time.Time{at(9), at(14)}, []time.Time{got.Sessions[0].StartTime, got.Sessions[1].StartTime})
+	})
+
+	t.Run("recurring", func(t *testing.T) {
+		nextWeek := func(from, to int) *models.UserAvailability { return availability(7*24+from, 7*24+to) }
+		users := []models.User{
+			{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(12, 13), nextWeek(12, 13)}},
+			{ID: uuid.New(), Name: "bob", Availabilities: []*models.UserAvailability{availability(9, 10), availability(12, 13), nextWeek(9, 10)}},
+		}
+		got := recommend(&models.Event{Recurrence: &models.Recurrence{Weeks: 2}}, slots, users, nil)
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime:      at(12),
+			EndTime:        at(13),
+			UserIDs:        []string{"alice"},
+			MissingUserIDs: []string{"bob"},
+			Occurrences: []models.Occurrence{
+				{StartTime: at(12), EndTime: at(13), UserIDs: []string{"alice", "bob"}},
+				{StartTime: at(7*24 + 12), EndTime: at(7*24 + 13), UserIDs: []string{"alice"}, MissingUserIDs: []string{"bob"}},
+			},
+		}, got)
+	})
+
+	t.Run("conflicting events", func(t *testing.T) {
+		users := []models.User{
+			{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(9, 16)}},
+			{ID: uuid.New(), Name: "bob", BufferAfter: 30, Availabilities: []*models.UserAvailability{availability(9, 16)}},
+		}
+		standup := scheduler.Interval{Start: at(9), End: at(9).Add(15 * time.Minute)}
+		review := scheduler.Interval{Start: at(13).Add(15 * time.Minute), End: at(14)}
+		taken := map[uuid.UUID][]conflict{
+			users[0].ID: {{eventID: "e1", title: "Standup", interval: standup}},
+			users[1].ID: {{eventID: "e2", title: "Review", interval: review}},
+		}
+		submitted := map[uuid.UUID]bool{users[0].ID: true, users[1].ID: true}
+		// found slots must be free throughout; bob's buffer keeps him from
+		// the 12:00 slot before the review
+		event := &models.Event{Search: &models.SlotSearch{}}
+		got := recommend(event, slots[:1], users, taken, submitted)
+		assert.Equal(t, &models.RecommendedSlot{
+			StartTime:      at(9),
+			EndTime:        at(10),
+			UserIDs:        []string{"bob"},
+			MissingUserIDs: []string{"alice"},
+			Missing:        []models.MissingUser{{UserID: "alice", Reason: models.ReasonConflict, EventID: "e1", EventTitle: "Standup"}},
+		}, got)
+
+		got = recommend(event, slots[1:2], users, taken, submitted)
+		assert.Equal(t, []models.MissingUser{{UserID: "bob", Reason: models.ReasonConflict, EventID: "e2", EventTitle: "Review"}}, got.Missing)
+
+		got = recommend(event, slots[1:], users, taken, submitted)
+		assert.Equal(t, at(14), got.StartTime)
+		assert.Equal(t, []string{"alice", "bob"}, got.UserIDs)
+		assert.Empty(t, got.Missing)
+	})
+
+	t.Run("nobody available", func(t *testing.T) {
+		assert.Equal(t, &models.RecommendedSlot{}, recommend(&models.Event{}, slots, []models.User{{ID: uuid.New(), Name: "alice"}}, nil))
+	})
+
+	t.Run("loaded users are left untouched", func(t *testing.T) {
+		users := []models.User{{ID: uuid.New(), Name: "alice", Availabilities: []*models.UserAvailability{availability(12, 14), availability(9, 13)}}}
+		recommend(&models.Event{}, slots, users, nil)
+		assert.Equal(t, []*models.UserAvailability{availability(12, 14), availability(9, 13)}, users[0].Availabilities)
+	})
+}
+
+func TestCandidateSlots(t *testing.T) {
+	t.Run("proposed slots", func(t *testing.T) {
+		event := &models.Event{EventSlots: []models.EventSlot{{StartTime: at(9), EndTime: at(10)}}}
+		slots, err := candidateSlots(event)
+		require.NoError(t, err)
+		assert.Equal(t, []scheduler.Interval{span(9, 10)}, slots)
+	})
+
+	t.Run("search", func(t *testing.T) {
+		event := &models.Event{
+			EstimatedDuration: 90,
+			EventSlots:        []models.EventSlot{{StartTime: at(9), EndTime: at(10)}},
+			Search: &models.SlotSearch{
+				From: at(0), To: at(24),
+				TimeZone: "Asia/Kolkata", DayStart: "14:00", DayEnd: "16:00",
+			},
+		}
+		slots, err := candidateSlots(event)
+		require.NoError(t, err)
+		// 14:00 to 16:00 in Kolkata is 08:30 to 10:30 UTC
+		assert.Equal(t, []scheduler.Interval{
+			{Start: at(8).Add(30 * time.Minute), End: at(10)},
+			{Start: at(8).Add(45 * time.Minute), End: at(10).Add(15 * time.Minute)},
+			{Start: at(9), End: at(10).Add(30 * time.Minute)},
+		}, utc(slots))
+	})
+
+	t.Run("search range too long", func(t *testing.T) {
+		event := &models.Event{
+			EstimatedDuration: 30,
+			Search:            &models.SlotSearch{From: at(0), To: at(0).AddDate(2, 0, 0), DayStart: "09:00", DayEnd: "17:00"},
+		}
+		_, err := candidateSlots(event)
+		assert.True(t, errors.Is(err, models.ErrValidation), "got %v", err)
+	})
+
+	t.Run("recurring", func(t *testing.T) {
+		event := &models.Event{
+			EstimatedDuration: 30,
+			Recurrence:        &models.Recurrence{Weeks: 4},
+			Search:            &models.SlotSearch{From: at(0), To: at(0).AddDate(0, 0, 8), DayStart: "09:00", DayEnd: "17:00"},
+		}
+		_, err := candidateSlots(event)
+		assert.True(t, errors.Is(err, models.ErrValidation), "got %v", err)
+		event.Search.To = at(0).AddDate(0, 0, 7)
+		_, err = candidateSlots(event)
+		assert.NoError(t, err)
+		event.Sessions = &models.Sessions{Count: 2}
+		_, err = candidateSlots(event)
+		assert.True(t, errors.Is(err, models.ErrValidation), "got %v", err)
+	})
+}
+
+func utc(intervals []scheduler.Interval) []scheduler.Interval {
+	for i := range intervals {
+		intervals[i] = scheduler.Interval{Start: intervals[i].Start.UTC(), End: intervals[i].End.UTC()}
+	}
+	return intervals
+}
@@ -0,0 +1,235 @@
+package events
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+// needsResources reports whether the event needs any resource.
+func needsResources(event *models.Event) bool {
+	return len(event.ResourceIDs) > 0 || len(event.RoomIDs) > 0
+}
+
+// resourceCalendar is the calendar of a resource an event needs.
+type resourceCalendar struct {
+	resource models.Resource
+	calendar scheduler.Calendar
+}
+
+// resourceCalendars are the calendars of the resources an event needs: all
+// of required, and one of rooms, which are in the event's order.
+type resourceCalendars struct {
+	required []resourceCalendar
+	rooms    []resourceCalendar
+}
+
+// loadCalendars loads the calendars of the resources the event needs over
+// the span of intervals. The event's own bookings are left out, so that it
+// can be booked again.
+func loadCalendars(db *gorm.DB, event *models.Event, intervals []scheduler.Interval) (*resourceCalendars, error) {
+	ids := append(slices.Clone([]string(event.ResourceIDs)), event.RoomIDs...)
+	var span scheduler.Interval
+	for _, interval := range intervals {
+		if span.Start.IsZero() || interval.Start.Before(span.Start) {
+			span.Start = interval.Start
+		}
+		if interval.End.After(span.End) {
+			span.End = interval.End
+		}
+	}
+	var resources []models.Resource
+	if err := db.Where("id IN (?)", ids).
+		Preload("Availabilities", "start_time < ? AND end_time > ?", span.End, span.Start).
+		Find(&resources).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	// only the availability within the span is loaded, so whether a
+	// resource has any at all is asked separately
+	var limited []string
+	if err := db.Model(&models.ResourceAvailability{}).Where("resource_id IN (?)", ids).
+		Pluck("DISTINCT resource_id", &limited).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	var bookings []models.ResourceBooking
+	if err := db.Where("resource_id IN (?) AND event_id <> ?", ids, event.ID).
+		Where("start_time < ? AND end_time > ?", span.End, span.Start).
+		Find(&bookings).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	restricted := map[string]bool{}
+	for _, id := range limited {
+		restricted[id] = true
+	}
+	return newResourceCalendars(event, resources, restricted, bookings)
+}
+
+// newResourceCalendars builds the calendars of the resources the event
+// needs from those loaded. restricted holds the IDs of the resources that
+// have availability, whether or not any of it was loaded, which can only be
+// booked within it.
+func newResourceCalendars(event *models.Event, resources []models.Resource, restricted map[string]bool, bookings []models.ResourceBooking) (*resourceCalendars, error) {
+	calendars := &resourceCalendars{}
+	find := func(field, id string) (resourceCalendar, error) {
+		for _, resource := range resources {
+			if resource.ID.String() != id {
+				continue
+			}
+			var available, booked []scheduler.Interval
+			for _, slot := range resource.Availabilities {
+				available = append(available, scheduler.Interval{Start: slot.StartTime, End: slot.EndTime})
+			}
+			for _, booking := range bookings {
+				if booking.ResourceID == resource.ID {
+					booked = append(booked, scheduler.Interval{Start: booking.StartTime, End: booking.EndTime})
+				}
+			}
+			calendar := scheduler.NewCalendar(available, booked, restricted[resource.ID.String()] || len(available) > 0)
+			return resourceCalendar{resource: resource, calendar: calendar}, nil
+		}
+		return resourceCalendar{}, &models.ValidationError{Fields: []models.FieldError{
+			{Field: field, Message: "must be existing resources"},
+		}}
+	}
+	for _, id := range event.ResourceIDs {
+		c, err := find("resource_ids", id)
+		if err != nil {
+			return nil, err
+		}
+		calendars.required = append(calendars.required, c)
+	}
+	for _, id := range event.RoomIDs {
+		c, err := find("room_ids", id)
+		if err != nil {
+			return nil, err
+		}
+		if c.resource.Kind != models.ResourceRoom {
+			return nil, &models.ValidationError{Fields: []models.FieldError{
+				{Field: "room_ids", Message: "must be rooms"},
+			}}
+		}
+		calendars.rooms = append(calendars.rooms, c)
+	}
+	return calendars, nil
+}
+
+// free reports whether every required resource, and one of the rooms if
+// there are any, is free for all of intervals. It returns the first such
+// room.
+func (c *resourceCalendars) free(intervals ...scheduler.Interval) (*models.Resource, bool) {
+	for _, required := range c.required {
+		if !required.calendar.Free(intervals...) {
+			return nil, false
+		}
+	}
+	if len(c.rooms) == 0 {
+		return nil, true
+	}
+	for i := range c.rooms {
+		if c.rooms[i].calendar.Free(intervals...) {
+			return &c.rooms[i].resource, true
+		}
+	}
+	return nil, false
+}
+
+// occurrences returns the function giving the times an event is held at for
+// one of its slots: every week of a recurring event, or just the slot.
+func occurrences(event *models.Event) func(scheduler.Interval) []scheduler.Interval {
+	if event.Recurrence == nil {
+		return func(slot scheduler.Interval) []scheduler.Interval { return []scheduler.Interval{slot} }
+	}
+	return recurrence(event).Occurrences
+}
+
+// bookable keeps the slots whose resources are free, and for proposed slots
+// the event's slots along with them, so that votes keep matching. It returns
+// the room found for each slot kept, if the event has rooms.
+func (s *store) bookable(event *models.Event, slots []scheduler.Interval) ([]scheduler.Interval, []string, error) {
+	held := occurrences(event)
+	var all []scheduler.Interval
+	for _, slot := range slots {
+		all = append(all, held(slot)...)
+	}
+	calendars, err := loadCalendars(s.db, event, all)
+	if err != nil {
+		return nil, nil, err
+	}
+	var kept []scheduler.Interval
+	var rooms []string
+	var eventSlots []models.EventSlot
+	for i, slot := range slots {
+		room, ok := calendars.free(held(slot)...)
+		if !ok {
+			continue
+		}
+		kept = append(kept, slot)
+		if room != nil {
+			rooms = append(rooms, room.ID.String())
+		}
+		if event.Search == nil {
+			eventSlots = append(eventSlots, event.EventSlots[i])
+		}
+	}
+	if event.Search == nil {
+		event.EventSlots = eventSlots
+	}
+	return kept, rooms, nil
+}
+
+// Book books the resources the event needs for slot, and for every
+// occurrence of a recurring event, within the transaction tx, replacing the
+// event's earlier bookings. It returns the room booked, if the event has
+// rooms, or an error matching models.ErrConflict if something it needs
+// isn't free. The resources are locked first, so that of concurrent
+// bookings only one can succeed.
+func Book(tx *gorm.DB, event *models.Event, slot models.Slot) (*uuid.UUID, error) {
+	if err := tx.Where("event_id = ?", event.ID).Delete(&models.ResourceBooking{}).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	if !needsResources(event) {
+		return nil, nil
+	}
+	ids := append(slices.Clone([]string(event.ResourceIDs)), event.RoomIDs...)
+	slices.Sort(ids)
+	var locked []models.Resource
+	if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id IN (?)", ids).Order("id").Find(&locked).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	times := occurrences(event)(scheduler.Interval{Start: slot.StartTime, End: slot.EndTime})
+	calendars, err := loadCalendars(tx, event, times)
+	if err != nil {
+		return nil, err
+	}
+	booked := make([]models.Resource, 0, len(calendars.required)+1)
+	for _, required := range calendars.required {
+		if !required.calendar.Free(times...) {
+			return nil, fmt.Errorf("%w: %s isn't free at that time", models.ErrConflict, required.resource.Name)
+		}
+		booked = append(booked, required.resource)
+	}
+	room, ok := calendars.free(times...)
+	if !ok {
+		return nil, fmt.Errorf("%w: none of the event's rooms is free at that time", models.ErrConflict)
+	}
+	if room != nil {
+		booked = append(booked, *room)
+	}
+	for _, resource := range booked {
+		for _, t := range times {
+			booking := &models.ResourceBooking{ResourceID: resource.ID, EventID: event.ID, Period: models.Period{StartTime: t.Start, EndTime: t.End}}
+			if err := tx.Create(booking).Error; err != nil {
+				return nil, stores.TranslateError(err)
+			}
+		}
+	}
+	if room == nil {
+		return nil, nil
+	}
+	return &room.ID, nil
+}
@@ -0,0 +1,89 @@
+package events
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestResourceCalendars_Free(t *testing.T) {
+	calendar := func(name string, available, booked []scheduler.Interval) resourceCalendar {
+		return resourceCalendar{
+			resource: models.Resource{ID: uuid.New(), Name: name},
+			calendar: scheduler.NewCalendar(available, booked, available != nil),
+		}
+	}
+	projector := calendar("projector", nil, []scheduler.Interval{span(14, 15)})
+	small := calendar("small", []scheduler.Interval{span(9, 17)}, []scheduler.Interval{span(9, 10)})
+	large := calendar("large", []scheduler.Interval{span(9, 12)}, nil)
+
+	t.Run("required resources", func(t *testing.T) {
+		calendars := &resourceCalendars{required: []resourceCalendar{projector}}
+		room, ok := calendars.free(span(9, 10))
+		assert.True(t, ok)
+		assert.Nil(t, room)
+		_, ok = calendars.free(span(9, 10), span(14, 15))
+		assert.False(t, ok)
+	})
+
+	t.Run("first free room", func(t *testing.T) {
+		calendars := &resourceCalendars{required: []resourceCalendar{projector}, rooms: []resourceCalendar{small, large}}
+		room, ok := calendars.free(span(9, 10))
+		assert.True(t, ok)
+		assert.Equal(t, "large", room.Name)
+		room, ok = calendars.free(span(10, 11))
+		assert.True(t, ok)
+		assert.Equal(t, "small", room.Name)
+		// the projector is booked
+		_, ok = calendars.free(span(14, 15))
+		assert.False(t, ok)
+		// no room is available
+		_, ok = calendars.free(span(17, 18))
+		assert.False(t, ok)
+	})
+}
+
+func TestNewResourceCalendars(t *testing.T) {
+	room := models.Resource{ID: uuid.New(), Name: "room", Kind: models.ResourceRoom}
+	projector := models.Resource{ID: uuid.New(), Name: "projector", Kind: models.ResourceEquipment}
+	event := &models.Event{RoomIDs: []string{room.ID.String()}, ResourceIDs: []string{projector.ID.String()}}
+
+	t.Run("availability outside the slot", func(t *testing.T) {
+		// the room has availability, just none of it loaded for the slot
+		calendars, err := newResourceCalendars(event, []models.Resource{room, projector}, map[string]bool{room.ID.String(): true}, nil)
+		require.NoError(t, err)
+		_, ok := calendars.free(span(9, 10))
+		assert.False(t, ok)
+	})
+
+	t.Run("without availability", func(t *testing.T) {
+		booking := models.ResourceBooking{ResourceID: projector.ID, Period: models.Period{StartTime: at(14), EndTime: at(15)}}
+		calendars, err := newResourceCalendars(event, []models.Resource{room, projector}, nil, []models.ResourceBooking{booking})
+		require.NoError(t, err)
+		got, ok := calendars.free(span(9, 10))
+		assert.True(t, ok)
+		assert.Equal(t, "room", got.Name)
+		_, ok = calendars.free(span(14, 15))
+		assert.False(t, ok)
+	})
+
+	t.Run("unknown and misused resources", func(t *testing.T) {
+		_, err := newResourceCalendars(event, []models.Resource{room}, nil, nil)
+		var verr *models.ValidationError
+		require.ErrorAs(t, err, &verr)
+		assert.Equal(t, "resource_ids", verr.Fields[0].Field)
+		_, err = newResourceCalendars(&models.Event{RoomIDs: []string{projector.ID.String()}}, []models.Resource{projector}, nil, nil)
+		require.ErrorAs(t, err, &verr)
+		assert.Equal(t, "room_ids", verr.Fields[0].Field)
+	})
+}
+
+func TestOccurrences(t *testing.T) {
+	assert.Equal(t, []scheduler.Interval{span(9, 10)}, occurrences(&models.Event{})(span(9, 10)))
+	weekly := occurrences(&models.Event{Recurrence: &models.Recurrence{Weeks: 2}})(span(9, 10))
+	assert.Equal(t, []scheduler.Interval{span(9, 10), span(7*24+9, 7*24+10)}, utc(weekly))
+}
@@ -0,0 +1,88 @@
+package events
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+)
+
+// searchWindow returns the window the slot of an event with a search is
+// looked for in.
+func searchWindow(event *models.Event) (scheduler.Window, error) {
+	search := event.Search
+	if search.To.Sub(search.From) > models.MaxSearchRange {
+		return scheduler.Window{}, &models.ValidationError{Fields: []models.FieldError{
+			{Field: "search.to", Message: fmt.Sprintf("must be within %d days of from", models.MaxSearchRange/(24*time.Hour))},
+		}}
+	}
+	location, err := time.LoadLocation(search.TimeZone)
+	if err != nil {
+		return scheduler.Window{}, fmt.Errorf("%w: %v", models.ErrInvalidArgument, err)
+	}
+	dayStart, err := models.ParseClock(search.DayStart)
+	if err != nil {
+		return scheduler.Window{}, fmt.Errorf("%w: %v", models.ErrInvalidArgument, err)
+	}
+	dayEnd, err := models.ParseClock(search.DayEnd)
+	if err != nil {
+		return scheduler.Window{}, fmt.Errorf("%w: %v", models.ErrInvalidArgument, err)
+	}
+	return scheduler.Window{
+		Range:        scheduler.Interval{Start: search.From, End: search.To},
+		Location:     location,
+		DayStart:     dayStart,
+		DayEnd:       dayEnd,
+		WeekdaysOnly: search.WeekdaysOnly,
+		Duration:     time.Duration(event.EstimatedDuration) * time.Minute,
+	}, nil
+}
+
+// candidateSlots returns the slots to recommend among: the proposed ones, or
+// those of the event's search. For a recurring event these are the first
+// week's.
+func candidateSlots(event *models.Event) ([]scheduler.Interval, error) {
+	if event.Recurrence != nil {
+		if err := checkRecurrence(event); err != nil {
+			return nil, err
+		}
+	}
+	if event.Search == nil {
+		slots := make([]scheduler.Interval, len(event.EventSlots))
+		for i, slot := range event.EventSlots {
+			slots[i] = scheduler.Interval{Start: slot.StartTime, End: slot.EndTime}
+		}
+		return slots, nil
+	}
+	window, err := searchWindow(event)
+	if err != nil {
+		return nil, err
+	}
+	return window.Candidates(), nil
+}
+
+// checkRecurrence rejects what a recurring event can't be recommended with:
+// sessions, and searches longer than the week the series repeats.
+func checkRecurrence(event *models.Event) error {
+	if event.Sessions != nil {
+		return &models.ValidationError{Fields: []models.FieldError{
+			{Field: "recurrence", Message: "can't be combined with sessions"},
+		}}
+	}
+	if event.Search != nil && event.Search.To.Sub(event.Search.From) > 7*24*time.Hour {
+		return &models.ValidationError{Fields: []models.FieldError{
+			{Field: "search.to", Message: "must be within 7 days of from for a recurring event"},
+		}}
+	}
+	return nil
+}
+
+// fit returns how attendees must be available for the event's slots: for
+// some of a proposed slot, or throughout a slot found by a search.
+func fit(event *models.Event) scheduler.Fit {
+	if event.Search != nil {
+		return scheduler.Covers
+	}
+	return scheduler.Overlaps
+}
@@ -1,8 +1,13 @@
 package events
 
 import (
+	"slices"
+	"time"
+
 	"github.com/jinzhu/gorm"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/scheduler"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
 )
 
 type Store interface {
@@ -11,6 +16,10 @@ type Store interface {
 	Update(event *models.Event) error
 	Delete(id string) error
 	GetRecommendations(eventID string) (*models.RecommendedSlot, error)
+	GetHeatmap(eventID string, granularity time.Duration) (*models.Heatmap, error)
+	Finalize(id string, slot models.Slot) (*models.Event, error)
+	Vote(eventID, slotID string, vote *models.SlotVote) error
+	ListOpen(now time.Time) ([]models.Event, error)
 }
 
 type store struct {
@@ -24,7 +33,7 @@ func NewStore(db *gorm.DB) Store {
 // Create inserts a new event into the database.
 func (s *store) Create(event *models.Event) error {
 	if err := s.db.Create(event).Error; err != nil {
-		return err
+		return stores.TranslateError(err)
 	}
 	return nil
 }
@@ -33,29 +42,137 @@ func (s *store) Create(event *models.Event) error {
 func (s *store) Get(id string) (*models.Event, error) {
 	var event models.Event
 	if err := s.db.Preload("EventSlots,Organizer").Where("id = ?", id).First(&event).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return nil, gorm.ErrRecordNotFound
-		}
-		return nil, err
+		return nil, stores.TranslateError(err)
 	}
 	return &event, nil
 }
 
-// Update modifies an existing event in the database.
+// serverColumns are the columns of an event that only the server sets:
+// when it is finalized, in which room, and when its reminder went out.
+var serverColumns = []string{"final_start_time", "final_end_time", "room_id", "reminder_sent_at"}
+
+// Update modifies an existing event in the database, keeping the columns
+// only the server sets, which are read back into event.
 func (s *store) Update(event *models.Event) error {
-	if err := s.db.Save(event).Error; err != nil {
-		return err
+	if err := s.db.Omit(serverColumns...).Save(event).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	if err := s.db.Select(serverColumns).Where("id = ?", event.ID).Take(event).Error; err != nil {
+		return stores.TranslateError(err)
 	}
 	return nil
 }
 
-// Delete removes an event by its ID from the database.
+// Delete removes an event by its ID from the database, releasing the
+// resources it booked.
 func (s *store) Delete(id string) error {
-	if err := s.db.Where("id = ?", id).Delete(&models.Event{}).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return gorm.ErrRecordNotFound
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("id = ?", id).Delete(&models.Event{})
+		if result.Error != nil {
+			return stores.TranslateError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrNotFound
+		}
+		if err := tx.Where("event_id = ?", id).Delete(&models.ResourceBooking{}).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		return nil
+	})
+}
+
+// Finalize fixes the time of an event to the given slot, which must fall
+// within one of the event's proposed slots, or within its search, and books
+// the resources it needs.
+func (s *store) Finalize(id string, slot models.Slot) (*models.Event, error) {
+	event, err := s.Get(id)
+	if err != nil {
+		return nil, err
+	}
+	if event.Search != nil {
+		window, err := searchWindow(event)
+		if err != nil {
+			return nil, err
+		}
+		if !window.Allows(scheduler.Interval{Start: slot.StartTime, End: slot.EndTime}) {
+			return nil, &models.ValidationError{Fields: []models.FieldError{
+				{Field: "start_time", Message: "must fall within the event's search"},
+			}}
+		}
+	} else if !withinEventSlots(event.EventSlots, slot) {
+		return nil, &models.ValidationError{Fields: []models.FieldError{
+			{Field: "start_time", Message: "must fall within one of the event slots"},
+		}}
+	}
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		room, err := Book(tx, event, slot)
+		if err != nil {
+			return err
+		}
+		event.RoomID = room
+		if err := tx.Model(&models.Event{}).Where("id = ?", id).Updates(map[string]interface{}{
+			"final_start_time": slot.StartTime,
+			"final_end_time":   slot.EndTime,
+			"room_id":          room,
+		}).Error; err != nil {
+			return stores.TranslateError(err)
 		}
-		return err
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	event.FinalStartTime = &slot.StartTime
+	event.FinalEndTime = &slot.EndTime
+	return event, nil
+}
+
+func withinEventSlots(eventSlots []models.EventSlot, slot models.Slot) bool {
+	for _, eventSlot := range eventSlots {
+		if !slot.StartTime.Before(eventSlot.StartTime) && !slot.EndTime.After(eventSlot.EndTime) {
+			return true
+		}
+	}
+	return false
+}
+
+// Vote records a participant's answer for one of the event's slots,
+// replacing any answer they gave before.
+func (s *store) Vote(eventID, slotID string, vote *models.SlotVote) error {
+	var slot models.EventSlot
+	if err := s.db.Where("id = ? AND event_id = ?", slotID, eventID).First(&slot).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	var event models.Event
+	if err := s.db.Select("participant_ids").Where("id = ?", eventID).First(&event).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	var users int
+	if err := s.db.Model(&models.User{}).Where("id = ?", vote.UserID).Count(&users).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	if users == 0 || len(event.ParticipantIDs) > 0 && !slices.Contains(event.ParticipantIDs, vote.UserID.String()) {
+		return &models.ValidationError{Fields: []models.FieldError{
+			{Field: "user_id", Message: "must be a participant of the event"},
+		}}
+	}
+	vote.SlotID, vote.EventID = slot.ID, *slot.EventID
+	if err := s.db.Set("gorm:insert_option", "ON CONFLICT (slot_id, user_id) DO UPDATE SET vote = EXCLUDED.vote").
+		Create(vote).Error; err != nil {
+		return stores.TranslateError(err)
 	}
 	return nil
 }
+
+// ListOpen retrieves the events that are not finalized and still have a
+// proposed slot ending after now.
+func (s *store) ListOpen(now time.Time) ([]models.Event, error) {
+	var events []models.Event
+	if err := s.db.Preload("EventSlots").
+		Where("final_start_time IS NULL").
+		Where("EXISTS (SELECT 1 FROM event_slots WHERE event_slots.event_id = events.id AND event_slots.end_time > ?)", now).
+		Find(&events).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return events, nil
+}
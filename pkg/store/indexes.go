@@ -0,0 +1,21 @@
+package store
+
+import "github.com/jinzhu/gorm"
+
+// indexes are created in addition to those declared on the models, for
+// expressions gorm tags can't describe.
+var indexes = []string{
+	// serves the recommender's overlap queries on general availability
+	`CREATE INDEX IF NOT EXISTS idx_user_availabilities_period
+		ON user_availabilities USING gist (tstzrange(start_time, end_time))
+		WHERE event_id IS NULL`,
+}
+
+func createIndexes(db *gorm.DB) error {
+	for _, index := range indexes {
+		if err := db.Exec(index).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
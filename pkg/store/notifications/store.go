@@ -0,0 +1,153 @@
+package notifications
+
+import (
+	"errors"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+)
+
+type Store interface {
+	// Users retrieves the users with the given IDs.
+	Users(ids []string) ([]models.User, error)
+	// DueReminders retrieves the open events whose response deadline falls
+	// within lead of now and whose participants have not been reminded yet.
+	DueReminders(now time.Time, lead time.Duration) ([]models.Event, error)
+	// ClaimReminder marks the reminder of an event as sent and reports whether
+	// this call was the one to do so.
+	ClaimReminder(eventID string, at time.Time) (bool, error)
+	// Unresponsive retrieves the participants of an event that have neither
+	// voted on its slots nor given availability overlapping any of them, or
+	// the range it searches.
+	Unresponsive(event *models.Event) ([]models.User, error)
+	// DueFinalizations retrieves the open events set to be finalized once
+	// their response deadline passes, and whose deadline has passed by now.
+	DueFinalizations(now time.Time) ([]models.Event, error)
+	// ClaimFinalization finalizes an event on slot unless it already is, or
+	// the resources it needs aren't free, and reports whether this call was
+	// the one to do so, along with the room booked for events with rooms.
+	ClaimFinalization(eventID string, slot models.Slot) (*uuid.UUID, bool, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+// Users retrieves the users with the given IDs.
+func (s *store) Users(ids []string) ([]models.User, error) {
+	var users []models.User
+	if len(ids) == 0 {
+		return users, nil
+	}
+	if err := s.db.Where("id IN (?)", ids).Find(&users).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return users, nil
+}
+
+// DueReminders retrieves the events that need a deadline reminder.
+func (s *store) DueReminders(now time.Time, lead time.Duration) ([]models.Event, error) {
+	var events []models.Event
+	if err := s.db.Preload("EventSlots").
+		Where("final_start_time IS NULL AND reminder_sent_at IS NULL").
+		Where("response_deadline > ? AND response_deadline <= ?", now, now.Add(lead)).
+		Find(&events).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return events, nil
+}
+
+// ClaimReminder sets reminder_sent_at unless another replica already did.
+func (s *store) ClaimReminder(eventID string, at time.Time) (bool, error) {
+	result := s.db.Model(&models.Event{}).
+		Where("id = ? AND reminder_sent_at IS NULL", eventID).
+		Update("reminder_sent_at", at)
+	if result.Error != nil {
+		return false, stores.TranslateError(result.Error)
+	}
+	return result.RowsAffected == 1, nil
+}
+
+// Unresponsive retrieves the participants who haven't responded to the
+// event: voted, or given general or event availability for its time.
+func (s *store) Unresponsive(event *models.Event) ([]models.User, error) {
+	var users []models.User
+	if len(event.ParticipantIDs) == 0 {
+		return users, nil
+	}
+	query := s.db.Where("id IN (?)", []string(event.ParticipantIDs)).
+		Where("NOT EXISTS (SELECT 1 FROM slot_votes v WHERE v.event_id = ? AND v.user_id = users.id)", event.ID)
+	if event.Search != nil {
+		query = query.Where(`NOT EXISTS (
+			SELECT 1 FROM user_availabilities a
+			WHERE a.user_id = users.id AND (a.event_id IS NULL OR a.event_id = ?)
+			AND a.start_time < ? AND a.end_time > ?
+		)`, event.ID, event.Search.To, event.Search.From)
+	} else {
+		query = query.Where(`NOT EXISTS (
+			SELECT 1 FROM user_availabilities a JOIN event_slots s ON s.event_id = ?
+			WHERE a.user_id = users.id AND (a.event_id IS NULL OR a.event_id = ?)
+			AND a.start_time < s.end_time AND a.end_time > s.start_time
+		)`, event.ID, event.ID)
+	}
+	if err := query.Find(&users).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return users, nil
+}
+
+// DueFinalizations retrieves the events due to be finalized automatically.
+func (s *store) DueFinalizations(now time.Time) ([]models.Event, error) {
+	var events []models.Event
+	if err := s.db.Preload("EventSlots").
+		Where("final_start_time IS NULL AND (quorum->>'auto_finalize')::boolean").
+		Where("response_deadline <= ?", now).
+		Find(&events).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return events, nil
+}
+
+// ClaimFinalization sets the final time unless another replica, or the
+// organizer, already did, and books the resources the event needs. Events
+// whose resources aren't free are left to the organizer.
+func (s *store) ClaimFinalization(eventID string, slot models.Slot) (*uuid.UUID, bool, error) {
+	var room *uuid.UUID
+	claimed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var event models.Event
+		err := tx.Set("gorm:query_option", "FOR UPDATE").
+			Where("id = ? AND final_start_time IS NULL", eventID).First(&event).Error
+		if gorm.IsRecordNotFoundError(err) {
+			return nil
+		}
+		if err != nil {
+			return stores.TranslateError(err)
+		}
+		booked, err := events.Book(tx, &event, slot)
+		if errors.Is(err, models.ErrConflict) {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Event{}).Where("id = ?", eventID).Updates(map[string]interface{}{
+			"final_start_time": slot.StartTime,
+			"final_end_time":   slot.EndTime,
+			"room_id":          booked,
+		}).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		room, claimed = booked, true
+		return nil
+	})
+	return room, claimed, err
+}
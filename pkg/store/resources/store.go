@@ -0,0 +1,132 @@
+package resources
+
+import (
+	"fmt"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+type Store interface {
+	Create(resource *models.Resource) error
+	Get(id string) (*models.Resource, error)
+	Update(id string, resource *models.Resource) error
+	Delete(id string) error
+	GetSchedule(resourceID string) (*models.ResourceSchedule, error)
+	AddAvailability(slots []models.ResourceAvailability) error
+	DeleteAvailability(resourceID, slotID string) error
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+func (s *store) Create(resource *models.Resource) error {
+	if err := s.db.Create(resource).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	return nil
+}
+
+func (s *store) Get(id string) (*models.Resource, error) {
+	var resource models.Resource
+	if err := s.db.Where("id = ?", id).First(&resource).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return &resource, nil
+}
+
+func (s *store) Update(id string, resource *models.Resource) error {
+	result := s.db.Model(&models.Resource{}).Where("id = ?", id).Updates(resource)
+	if result.Error != nil {
+		return stores.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
+
+// Delete removes a resource along with its availability and past bookings,
+// and clears it from the events held in it. A resource booked for a time that
+// hasn't ended can't be deleted. It is locked as finalizing does, so that it
+// isn't booked meanwhile.
+func (s *store) Delete(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		var resource models.Resource
+		if err := tx.Set("gorm:query_option", "FOR UPDATE").Where("id = ?", id).First(&resource).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		var booked int
+		if err := tx.Model(&models.ResourceBooking{}).Where("resource_id = ? AND end_time > now()", id).Count(&booked).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		if booked > 0 {
+			return fmt.Errorf("%w: %s has %d bookings that haven't ended", models.ErrConflict, resource.Name, booked)
+		}
+		// the constraints take its availability, bookings and events along
+		if err := tx.Delete(&resource).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		return nil
+	})
+}
+
+// GetSchedule retrieves the availability and bookings of a resource, by
+// start time.
+func (s *store) GetSchedule(resourceID string) (*models.ResourceSchedule, error) {
+	if _, err := s.Get(resourceID); err != nil {
+		return nil, err
+	}
+	schedule := &models.ResourceSchedule{Availability: []models.ResourceAvailability{}, Bookings: []models.ResourceBooking{}}
+	if err := s.db.Where("resource_id = ?", resourceID).Order("start_time").Find(&schedule.Availability).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	if err := s.db.Where("resource_id = ?", resourceID).Order("start_time").Find(&schedule.Bookings).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return schedule, nil
+}
+
+// AddAvailability inserts the slots, which must all be of resources that
+// exist.
+func (s *store) AddAvailability(slots []models.ResourceAvailability) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		ids := map[uuid.UUID]bool{}
+		for _, slot := range slots {
+			ids[slot.ResourceID] = true
+		}
+		for id := range ids {
+			var resources int
+			if err := tx.Model(&models.Resource{}).Where("id = ?", id).Count(&resources).Error; err != nil {
+				return stores.TranslateError(err)
+			}
+			if resources == 0 {
+				return fmt.Errorf("%w: resource %s", models.ErrNotFound, id)
+			}
+		}
+		for i := range slots {
+			if err := tx.Create(&slots[i]).Error; err != nil {
+				return stores.TranslateError(err)
+			}
+		}
+		return nil
+	})
+}
+
+func (s *store) DeleteAvailability(resourceID, slotID string) error {
+	result := s.db.Where("id = ? AND resource_id = ?", slotID, resourceID).Delete(&models.ResourceAvailability{})
+	if result.Error != nil {
+		return stores.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotFound
+	}
+	return nil
+}
@@ -3,17 +3,18 @@ package users
 import (
 	"github.com/jinzhu/gorm"
 	"github.com/rsys-speerzad/stackgen/pkg/models"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
 )
 
 type Store interface {
 	Create(user *models.User) error
 	Get(id string) (*models.User, error)
-	Update(id string, user *models.User) error
+	Update(id string, update *models.UserUpdate) error
 	Delete(id string) error
 	GetAvailability(userID string) ([]models.UserAvailability, error)
 	AddAvailability(slots []models.UserAvailability) error
 	UpdateAvailability(slotID string, slot models.UserAvailability) error
-	DeleteAvailability(slotID string) error
+	DeleteAvailability(userID, slotID string) error
 }
 
 type store struct {
@@ -26,7 +27,7 @@ func NewStore(db *gorm.DB) Store {
 
 func (s *store) Create(user *models.User) error {
 	if err := s.db.Create(user).Error; err != nil {
-		return err
+		return stores.TranslateError(err)
 	}
 	return nil
 }
@@ -34,27 +35,41 @@ func (s *store) Create(user *models.User) error {
 func (s *store) Get(id string) (*models.User, error) {
 	var user models.User
 	if err := s.db.Preload("Availabilities").Where("id = ?", id).First(&user).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return nil, gorm.ErrRecordNotFound
-		}
-		return nil, err
+		return nil, stores.TranslateError(err)
 	}
 	return &user, nil
 }
 
-func (s *store) Update(id string, user *models.User) error {
-	if err := s.db.Model(&models.User{}).Where("id = ?", id).Updates(user).Error; err != nil {
-		return err
+// Update sets the columns given in update, including empty and zero values.
+func (s *store) Update(id string, update *models.UserUpdate) error {
+	columns := update.Columns()
+	if len(columns) == 0 {
+		var users int
+		if err := s.db.Model(&models.User{}).Where("id = ?", id).Count(&users).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		if users == 0 {
+			return models.ErrNotFound
+		}
+		return nil
+	}
+	result := s.db.Model(&models.User{}).Where("id = ?", id).Updates(columns)
+	if result.Error != nil {
+		return stores.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotFound
 	}
 	return nil
 }
 
 func (s *store) Delete(id string) error {
-	if err := s.db.Where("id = ?", id).Delete(&models.User{}).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return gorm.ErrRecordNotFound
-		}
-		return err
+	result := s.db.Where("id = ?", id).Delete(&models.User{})
+	if result.Error != nil {
+		return stores.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotFound
 	}
 	return nil
 }
@@ -62,28 +77,36 @@ func (s *store) Delete(id string) error {
 func (s *store) AddAvailability(slots []models.UserAvailability) error {
 	for _, slot := range slots {
 		if err := s.db.Create(&slot).Error; err != nil {
-			return err
+			return stores.TranslateError(err)
 		}
 	}
 	return nil
 }
 
+// UpdateAvailability replaces the period and preference of the slot, including
+// an empty preference.
 func (s *store) UpdateAvailability(slotID string, slot models.UserAvailability) error {
-	if err := s.db.Model(&models.UserAvailability{}).Where("id = ?", slotID).Updates(slot).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return gorm.ErrRecordNotFound
-		}
-		return err
+	result := s.db.Model(&models.UserAvailability{}).Where("id = ? AND user_id = ?", slotID, slot.UserID).Updates(map[string]interface{}{
+		"start_time": slot.StartTime,
+		"end_time":   slot.EndTime,
+		"preference": slot.Preference,
+	})
+	if result.Error != nil {
+		return stores.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotFound
 	}
 	return nil
 }
 
-func (s *store) DeleteAvailability(slotID string) error {
-	if err := s.db.Where("id = ?", slotID).Delete(&models.UserAvailability{}).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return gorm.ErrRecordNotFound
-		}
-		return err
+func (s *store) DeleteAvailability(userID, slotID string) error {
+	result := s.db.Where("id = ? AND user_id = ?", slotID, userID).Delete(&models.UserAvailability{})
+	if result.Error != nil {
+		return stores.TranslateError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return models.ErrNotFound
 	}
 	return nil
 }
@@ -91,10 +114,7 @@ func (s *store) DeleteAvailability(slotID string) error {
 func (s *store) GetAvailability(userID string) ([]models.UserAvailability, error) {
 	var availabilities []models.UserAvailability
 	if err := s.db.Where("user_id = ? and event_id IS NULL", userID).Find(&availabilities).Error; err != nil {
-		if gorm.IsRecordNotFoundError(err) {
-			return nil, gorm.ErrRecordNotFound
-		}
-		return nil, err
+		return nil, stores.TranslateError(err)
 	}
 	return availabilities, nil
 }
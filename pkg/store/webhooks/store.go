@@ -0,0 +1,224 @@
+package webhooks
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	stores "github.com/rsys-speerzad/stackgen/pkg/store"
+)
+
+type Store interface {
+	CreateSubscription(sub *models.WebhookSubscription) error
+	GetSubscription(id string) (*models.WebhookSubscription, error)
+	ListSubscriptions() ([]models.WebhookSubscription, error)
+	DeleteSubscription(id string) error
+	// Enqueue adds a pending delivery to the outbox for every subscription
+	// interested in eventType.
+	Enqueue(eventType string, payload []byte) error
+	// ClaimDue leases up to limit pending deliveries that are due at now, so
+	// that no other replica picks them up until lease has passed.
+	ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error)
+	SaveDelivery(delivery *models.WebhookDelivery) error
+	ListDeliveries(subscriptionID string) ([]models.WebhookDelivery, error)
+	// SwapSnapshot stores the best slot of an event and reports whether it
+	// differs from the previously stored one.
+	SwapSnapshot(snapshot models.RecommendationSnapshot) (bool, error)
+	// Lead makes this replica the one that checks recommendations, unless
+	// another one is. The returned channel is closed when it stops being the
+	// one, and is nil if another replica is.
+	Lead(ctx context.Context) (<-chan struct{}, error)
+}
+
+type store struct {
+	db *gorm.DB
+}
+
+func NewStore(db *gorm.DB) Store {
+	return &store{db: db}
+}
+
+// CreateSubscription inserts a new webhook subscription.
+func (s *store) CreateSubscription(sub *models.WebhookSubscription) error {
+	if err := s.db.Create(sub).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	return nil
+}
+
+// GetSubscription retrieves a webhook subscription by its ID.
+func (s *store) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	var sub models.WebhookSubscription
+	if err := s.db.Where("id = ?", id).First(&sub).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return &sub, nil
+}
+
+// ListSubscriptions retrieves every webhook subscription.
+func (s *store) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	var subs []models.WebhookSubscription
+	if err := s.db.Order("created_at").Find(&subs).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return subs, nil
+}
+
+// DeleteSubscription removes a webhook subscription and its delivery log.
+func (s *store) DeleteSubscription(id string) error {
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("subscription_id = ?", id).Delete(&models.WebhookDelivery{}).Error; err != nil {
+			return stores.TranslateError(err)
+		}
+		result := tx.Where("id = ?", id).Delete(&models.WebhookSubscription{})
+		if result.Error != nil {
+			return stores.TranslateError(result.Error)
+		}
+		if result.RowsAffected == 0 {
+			return models.ErrNotFound
+		}
+		return nil
+	})
+}
+
+// Enqueue adds a pending delivery for every subscription of eventType.
+func (s *store) Enqueue(eventType string, payload []byte) error {
+	var subs []models.WebhookSubscription
+	if err := s.db.Where("? = ANY(event_types)", eventType).Find(&subs).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	now := time.Now()
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		for _, sub := range subs {
+			delivery := models.WebhookDelivery{
+				SubscriptionID: sub.ID,
+				EventType:      eventType,
+				Payload:        string(payload),
+				Status:         models.DeliveryPending,
+				NextAttemptAt:  now,
+			}
+			if err := tx.Create(&delivery).Error; err != nil {
+				return stores.TranslateError(err)
+			}
+		}
+		return nil
+	})
+}
+
+// ClaimDue leases due deliveries by pushing their next attempt past the lease.
+// Rows locked by another replica are skipped.
+func (s *store) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Set("gorm:query_option", "FOR UPDATE SKIP LOCKED").
+			Where("status = ? AND next_attempt_at <= ?", models.DeliveryPending, now).
+			Order("next_attempt_at").Limit(limit).Find(&deliveries).Error; err != nil {
+			return err
+		}
+		if len(deliveries) == 0 {
+			return nil
+		}
+		ids := make([]string, 0, len(deliveries))
+		for _, d := range deliveries {
+			ids = append(ids, d.ID.String())
+		}
+		return tx.Model(&models.WebhookDelivery{}).Where("id IN (?)", ids).
+			Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return deliveries, nil
+}
+
+// SaveDelivery records the outcome of a delivery attempt.
+func (s *store) SaveDelivery(delivery *models.WebhookDelivery) error {
+	if err := s.db.Save(delivery).Error; err != nil {
+		return stores.TranslateError(err)
+	}
+	return nil
+}
+
+// ListDeliveries returns the delivery log of a subscription, newest first.
+func (s *store) ListDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	var deliveries []models.WebhookDelivery
+	if err := s.db.Where("subscription_id = ?", subscriptionID).Order("created_at DESC").Limit(100).Find(&deliveries).Error; err != nil {
+		return nil, stores.TranslateError(err)
+	}
+	return deliveries, nil
+}
+
+// SwapSnapshot upserts the snapshot and reports whether the best slot changed.
+func (s *store) SwapSnapshot(snapshot models.RecommendationSnapshot) (bool, error) {
+	changed := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var previous models.RecommendationSnapshot
+		err := tx.Set("gorm:query_option", "FOR UPDATE").Where("event_id = ?", snapshot.EventID).First(&previous).Error
+		switch {
+		case gorm.IsRecordNotFoundError(err):
+			changed = true
+			return tx.Create(&snapshot).Error
+		case err != nil:
+			return err
+		}
+		if previous.StartTime.Equal(snapshot.StartTime) && previous.EndTime.Equal(snapshot.EndTime) {
+			return nil
+		}
+		changed = true
+		return tx.Save(&snapshot).Error
+	})
+	if err != nil {
+		return false, stores.TranslateError(err)
+	}
+	return changed, nil
+}
+
+// recommendationsLock is the advisory lock key, "stackrec" in ASCII, held by
+// the replica that checks recommendations.
+const recommendationsLock = 0x737461636b726563
+
+// leaseCheck is how often the replica holding recommendationsLock makes sure
+// its connection, and so the lock, is still alive.
+const leaseCheck = 10 * time.Second
+
+// Lead takes recommendationsLock, if it is free, on a connection set aside
+// for it. The lock is held until ctx is done or the connection breaks, when
+// Postgres releases it for another replica to take.
+func (s *store) Lead(ctx context.Context) (<-chan struct{}, error) {
+	conn, err := s.db.DB().Conn(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var taken bool
+	if err := conn.QueryRowContext(ctx, "SELECT pg_try_advisory_lock($1)", recommendationsLock).Scan(&taken); err != nil || !taken {
+		conn.Close()
+		return nil, err
+	}
+	lost := make(chan struct{})
+	go func() {
+		defer close(lost)
+		ticker := time.NewTicker(leaseCheck)
+		defer ticker.Stop()
+		for alive := true; alive; {
+			select {
+			case <-ctx.Done():
+				alive = false
+			case <-ticker.C:
+				if _, err := conn.ExecContext(ctx, "SELECT 1"); err != nil {
+					alive = false
+					if ctx.Err() == nil {
+						log.Printf("webhooks: lost the connection holding the recommendations lock: %v", err)
+					}
+				}
+			}
+		}
+		// the connection goes back to the pool, which must not keep the lock
+		unlock, cancel := context.WithTimeout(context.Background(), leaseCheck)
+		defer cancel()
+		conn.ExecContext(unlock, "SELECT pg_advisory_unlock($1)", recommendationsLock)
+		conn.Close()
+	}()
+	return lost, nil
+}
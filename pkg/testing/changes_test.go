@@ -0,0 +1,52 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangesReachEveryReplica(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+
+	// two replicas, each with its own listener
+	replicas := make([]*bus.Postgres, 2)
+	for i := range replicas {
+		b, err := bus.NewPostgres(store.DSN())
+		require.NoError(t, err)
+		defer b.Close()
+		replicas[i] = b
+	}
+	var woken []<-chan struct{}
+	for _, b := range replicas {
+		c, cancel := b.Subscribe(func(c bus.Change) bool { return c.Kind == bus.UserChanged })
+		defer cancel()
+		woken = append(woken, c)
+	}
+	// give the listeners time to connect
+	time.Sleep(200 * time.Millisecond)
+
+	// a write made outside the API is announced too
+	user := models.User{Name: "Replica", Email: "replica@example.com"}
+	require.NoError(t, store.GetDB().Create(&user).Error)
+	defer store.GetDB().Delete(&user)
+
+	for i, c := range woken {
+		select {
+		case <-c:
+		case <-time.After(5 * time.Second):
+			assert.Fail(t, "change not received", "replica %d", i)
+		}
+	}
+}
@@ -0,0 +1,42 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventUpdateKeepsServerColumns(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+
+	start := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	end, reminded := start.Add(time.Hour), start.Add(-24*time.Hour)
+	event := models.Event{Title: "Planning", EstimatedDuration: 60, FinalStartTime: &start, FinalEndTime: &end, ReminderSentAt: &reminded}
+	require.NoError(t, store.GetDB().Create(&event).Error)
+	defer store.GetDB().Delete(&event)
+
+	// a PUT body carries none of them
+	update := models.Event{ID: event.ID, Title: "Planning, again", EstimatedDuration: 30}
+	require.NoError(t, events.NewStore(store.GetDB()).Update(&update))
+	assert.True(t, update.IsFinalized(), "the updated event is returned as stored")
+
+	var stored models.Event
+	require.NoError(t, store.GetDB().First(&stored, "id = ?", event.ID).Error)
+	assert.Equal(t, "Planning, again", stored.Title)
+	require.True(t, stored.IsFinalized())
+	assert.True(t, start.Equal(*stored.FinalStartTime))
+	assert.True(t, end.Equal(*stored.FinalEndTime))
+	require.NotNil(t, stored.ReminderSentAt)
+	assert.True(t, reminded.Equal(*stored.ReminderSentAt))
+}
@@ -26,7 +26,7 @@ func TestUserEventRecommendationFlow(t *testing.T) {
 	store.InitDB()
 
 	// create a test server
-	server := httptest.NewServer(router.NewServer().Handler)
+	server := httptest.NewServer(router.NewServer(router.NewBus()).Handler)
 	defer server.Close()
 
 	// 1. Create a user
@@ -63,7 +63,7 @@ func TestUserEventRecommendationFlow(t *testing.T) {
 	req.Header.Set("Content-Type", "application/json")
 	resp, err = http.DefaultClient.Do(req)
 	assert.NoError(t, err)
-	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, http.StatusNoContent, resp.StatusCode)
 	resp.Body.Close()
 
 	// 3. Create an event
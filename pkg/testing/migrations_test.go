@@ -0,0 +1,41 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMigrationRemovesInvertedAvailability(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+	db := store.GetDB()
+
+	user := models.User{Name: "Inverted", Email: "inverted@example.com"}
+	require.NoError(t, db.Create(&user).Error)
+	defer db.Delete(&user)
+
+	// a row written before the check existed
+	start := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, db.Exec("ALTER TABLE user_availabilities DROP CONSTRAINT chk_user_availabilities_period").Error)
+	inverted := models.UserAvailability{UserID: user.ID, Slot: models.Slot{StartTime: start, EndTime: start.Add(-time.Hour)}}
+	require.NoError(t, db.Create(&inverted).Error)
+	empty := models.UserAvailability{UserID: user.ID, Slot: models.Slot{StartTime: start, EndTime: start}}
+	require.NoError(t, db.Create(&empty).Error)
+
+	require.NoError(t, store.AutoMigrate())
+	var kept []models.UserAvailability
+	require.NoError(t, db.Where("user_id = ?", user.ID).Find(&kept).Error)
+	require.Len(t, kept, 1, "zero-length rows are kept")
+	assert.Equal(t, empty.ID, kept[0].ID)
+	assert.Error(t, db.Create(&models.UserAvailability{UserID: user.ID, Slot: models.Slot{StartTime: start, EndTime: start.Add(-time.Hour)}}).Error)
+}
@@ -0,0 +1,85 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+	"github.com/rsys-speerzad/stackgen/pkg/store/notifications"
+	"github.com/rsys-speerzad/stackgen/pkg/store/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestUnresponsive(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+
+	db := store.GetDB()
+	userStore := users.NewStore(db)
+	alice := models.User{Name: "Alice", Email: "alice.unresponsive@example.com"}
+	bob := models.User{Name: "Bob", Email: "bob.unresponsive@example.com"}
+	require.NoError(t, userStore.Create(&alice))
+	defer userStore.Delete(alice.ID.String())
+	require.NoError(t, userStore.Create(&bob))
+	defer userStore.Delete(bob.ID.String())
+	participants := []string{alice.ID.String(), bob.ID.String()}
+	names := func(found []models.User) []string {
+		var result []string
+		for _, user := range found {
+			result = append(result, user.Name)
+		}
+		return result
+	}
+
+	start := time.Date(2031, 3, 10, 9, 0, 0, 0, time.UTC)
+	// Alice is free on the day searched, and away for the proposed slot
+	require.NoError(t, userStore.AddAvailability([]models.UserAvailability{{
+		UserID: alice.ID,
+		Slot:   models.Slot{StartTime: start.Add(24 * time.Hour), EndTime: start.Add(26 * time.Hour)},
+	}}))
+	s := notifications.NewStore(db)
+
+	t.Run("availability within the search range", func(t *testing.T) {
+		event := models.Event{
+			Title:             "Search",
+			EstimatedDuration: 60,
+			ParticipantIDs:    participants,
+			Search:            &models.SlotSearch{From: start, To: start.Add(48 * time.Hour), DayStart: "09:00", DayEnd: "17:00"},
+		}
+		require.NoError(t, db.Create(&event).Error)
+		defer db.Delete(&event)
+
+		pending, err := s.Unresponsive(&event)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Bob"}, names(pending))
+	})
+
+	t.Run("votes", func(t *testing.T) {
+		event := models.Event{
+			Title:             "Proposed",
+			EstimatedDuration: 60,
+			ParticipantIDs:    participants,
+			EventSlots:        []models.EventSlot{{StartTime: start, EndTime: start.Add(time.Hour)}},
+		}
+		require.NoError(t, db.Create(&event).Error)
+		defer db.Delete(&event)
+
+		pending, err := s.Unresponsive(&event)
+		require.NoError(t, err)
+		assert.ElementsMatch(t, []string{"Alice", "Bob"}, names(pending))
+
+		vote := models.SlotVote{UserID: bob.ID, Vote: models.VoteNo}
+		require.NoError(t, events.NewStore(db).Vote(event.ID.String(), event.EventSlots[0].ID.String(), &vote))
+		pending, err = s.Unresponsive(&event)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"Alice"}, names(pending))
+	})
+}
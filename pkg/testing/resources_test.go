@@ -0,0 +1,53 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/rsys-speerzad/stackgen/pkg/store/resources"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeletedResourcesLeaveNoReferences(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+	db := store.GetDB()
+	s := resources.NewStore(db)
+
+	room := models.Resource{Name: "Boardroom", Kind: models.ResourceRoom}
+	require.NoError(t, s.Create(&room))
+	defer s.Delete(room.ID.String())
+	held := time.Now().Add(-48 * time.Hour)
+	past := models.Event{Title: "Held", EstimatedDuration: 60, RoomID: &room.ID}
+	require.NoError(t, db.Create(&past).Error)
+	defer db.Delete(&past)
+	require.NoError(t, db.Create(&models.ResourceBooking{ResourceID: room.ID, EventID: past.ID, Period: models.Period{StartTime: held, EndTime: held.Add(time.Hour)}}).Error)
+	upcoming := models.ResourceBooking{ResourceID: room.ID, EventID: uuid.New(), Period: models.Period{StartTime: held.Add(96 * time.Hour), EndTime: held.Add(97 * time.Hour)}}
+	require.NoError(t, db.Create(&upcoming).Error)
+
+	assert.ErrorIs(t, s.Delete(room.ID.String()), models.ErrConflict, "the room is booked")
+
+	require.NoError(t, db.Delete(&upcoming).Error)
+	require.NoError(t, s.AddAvailability([]models.ResourceAvailability{{ResourceID: room.ID, Period: models.Period{StartTime: held, EndTime: held.Add(time.Hour)}}}))
+	require.NoError(t, s.Delete(room.ID.String()))
+	var stored models.Event
+	require.NoError(t, db.First(&stored, "id = ?", past.ID).Error)
+	assert.Nil(t, stored.RoomID, "the event no longer refers to the room")
+	var left int
+	require.NoError(t, db.Model(&models.ResourceBooking{}).Where("resource_id = ?", room.ID).Count(&left).Error)
+	assert.Zero(t, left)
+	require.NoError(t, db.Model(&models.ResourceAvailability{}).Where("resource_id = ?", room.ID).Count(&left).Error)
+	assert.Zero(t, left)
+
+	err := s.AddAvailability([]models.ResourceAvailability{{ResourceID: room.ID, Period: models.Period{StartTime: held, EndTime: held.Add(time.Hour)}}})
+	assert.ErrorIs(t, err, models.ErrNotFound, "the room no longer exists")
+}
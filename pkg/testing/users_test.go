@@ -0,0 +1,73 @@
+package testing
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/rsys-speerzad/stackgen/pkg/store/users"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDeleteAvailabilityIsScopedToUser(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+
+	s := users.NewStore(store.GetDB())
+	alice := models.User{Name: "Alice", Email: "alice.scoped@example.com"}
+	bob := models.User{Name: "Bob", Email: "bob.scoped@example.com"}
+	require.NoError(t, s.Create(&alice))
+	defer s.Delete(alice.ID.String())
+	require.NoError(t, s.Create(&bob))
+	defer s.Delete(bob.ID.String())
+
+	start := time.Date(2025, 1, 13, 9, 0, 0, 0, time.UTC)
+	require.NoError(t, s.AddAvailability([]models.UserAvailability{{UserID: bob.ID, Slot: models.Slot{StartTime: start, EndTime: start.Add(time.Hour)}}}))
+	slots, err := s.GetAvailability(bob.ID.String())
+	require.NoError(t, err)
+	require.Len(t, slots, 1)
+
+	// Alice cannot delete Bob's slot
+	assert.ErrorIs(t, s.DeleteAvailability(alice.ID.String(), slots[0].ID.String()), models.ErrNotFound)
+	slots, err = s.GetAvailability(bob.ID.String())
+	require.NoError(t, err)
+	assert.Len(t, slots, 1)
+
+	require.NoError(t, s.DeleteAvailability(bob.ID.String(), slots[0].ID.String()))
+	assert.ErrorIs(t, s.DeleteAvailability(bob.ID.String(), slots[0].ID.String()), models.ErrNotFound)
+}
+
+func TestUpdateSetsOnlyGivenFields(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+
+	s := users.NewStore(store.GetDB())
+	alice := models.User{Name: "Alice", Email: "alice.update@example.com", TimeZone: "Europe/Berlin", WorkdayStart: "09:00", WorkdayEnd: "17:00", BufferBefore: 10, BufferAfter: 15}
+	require.NoError(t, s.Create(&alice))
+	defer s.Delete(alice.ID.String())
+
+	empty, zero := "", 0
+	require.NoError(t, s.Update(alice.ID.String(), &models.UserUpdate{WorkdayStart: &empty, WorkdayEnd: &empty, BufferBefore: &zero}))
+	got, err := s.Get(alice.ID.String())
+	require.NoError(t, err)
+	assert.Equal(t, "Alice", got.Name)
+	assert.Equal(t, "Europe/Berlin", got.TimeZone)
+	assert.Empty(t, got.WorkdayStart)
+	assert.Empty(t, got.WorkdayEnd)
+	assert.Zero(t, got.BufferBefore)
+	assert.Equal(t, 15, got.BufferAfter)
+
+	require.NoError(t, s.Update(alice.ID.String(), &models.UserUpdate{}))
+	assert.ErrorIs(t, s.Update("00000000-0000-0000-0000-000000000000", &models.UserUpdate{}), models.ErrNotFound)
+}
@@ -0,0 +1,46 @@
+package testing
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/store"
+	"github.com/rsys-speerzad/stackgen/pkg/store/webhooks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestOneReplicaLeads(t *testing.T) {
+	os.Setenv("DB_HOST", "localhost")
+	os.Setenv("DB_PORT", "5432")
+	os.Setenv("DB_USER", "postgres")
+	os.Setenv("DB_PASS", "admin")
+	os.Setenv("DB_NAME", "stackgen")
+	require.NoError(t, store.AutoMigrate())
+	s := webhooks.NewStore(store.GetDB())
+
+	ctx, stepDown := context.WithCancel(context.Background())
+	lost, err := s.Lead(ctx)
+	require.NoError(t, err)
+	require.NotNil(t, lost)
+
+	other, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	follower, err := s.Lead(other)
+	require.NoError(t, err)
+	assert.Nil(t, follower, "the lock is held")
+
+	stepDown()
+	<-lost
+	successor, err := s.Lead(other)
+	require.NoError(t, err)
+	assert.NotNil(t, successor, "the lock is released when the leader steps down")
+	cancel()
+	select {
+	case <-successor:
+	case <-time.After(time.Second):
+		t.Fatal("leadership outlived its context")
+	}
+}
@@ -0,0 +1,139 @@
+package webhooks
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store/webhooks"
+)
+
+const (
+	pollInterval   = 5 * time.Second
+	batchSize      = 50
+	deliveryLease  = time.Minute
+	requestTimeout = 10 * time.Second
+	maxAttempts    = 10
+	baseBackoff    = 30 * time.Second
+	maxBackoff     = 6 * time.Hour
+)
+
+// Dispatcher delivers pending outbox entries to their subscribers, retrying
+// failed attempts with exponential backoff.
+type Dispatcher struct {
+	store  webhooks.Store
+	client *http.Client
+	now    func() time.Time
+}
+
+func NewDispatcher(db *gorm.DB) *Dispatcher {
+	return newDispatcher(webhooks.NewStore(db), &http.Client{Timeout: requestTimeout})
+}
+
+func newDispatcher(store webhooks.Store, client *http.Client) *Dispatcher {
+	return &Dispatcher{store: store, client: client, now: time.Now}
+}
+
+// Run delivers due webhooks until ctx is done.
+func (d *Dispatcher) Run(ctx context.Context) {
+	ticker := time.NewTicker(pollInterval)
+	defer ticker.Stop()
+	for {
+		if err := d.DeliverDue(ctx); err != nil {
+			log.Printf("webhooks: delivering: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// DeliverDue makes one attempt at every delivery that is currently due.
+func (d *Dispatcher) DeliverDue(ctx context.Context) error {
+	deliveries, err := d.store.ClaimDue(d.now(), deliveryLease, batchSize)
+	if err != nil {
+		return err
+	}
+	subs := map[string]*models.WebhookSubscription{}
+	for i := range deliveries {
+		delivery := &deliveries[i]
+		id := delivery.SubscriptionID.String()
+		sub, ok := subs[id]
+		if !ok {
+			if sub, err = d.store.GetSubscription(id); err != nil {
+				log.Printf("webhooks: subscription %s: %v", id, err)
+				continue
+			}
+			subs[id] = sub
+		}
+		d.attempt(ctx, sub, delivery)
+		if err := d.store.SaveDelivery(delivery); err != nil {
+			log.Printf("webhooks: saving delivery %s: %v", delivery.ID, err)
+		}
+	}
+	return nil
+}
+
+// attempt posts the delivery and records the outcome on it.
+func (d *Dispatcher) attempt(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery) {
+	now := d.now()
+	delivery.Attempts++
+	status, err := d.post(ctx, sub, delivery, now)
+	delivery.ResponseStatus = status
+	if err == nil {
+		delivery.Status = models.DeliverySucceeded
+		delivery.LastError = ""
+		delivery.DeliveredAt = &now
+		return
+	}
+	delivery.LastError = err.Error()
+	if delivery.Attempts >= maxAttempts {
+		delivery.Status = models.DeliveryFailed
+		return
+	}
+	delivery.NextAttemptAt = now.Add(Backoff(delivery.Attempts))
+}
+
+func (d *Dispatcher) post(ctx context.Context, sub *models.WebhookSubscription, delivery *models.WebhookDelivery, now time.Time) (int, error) {
+	body := []byte(delivery.Payload)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "stackgen-webhooks/1.0")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, delivery.ID.String())
+	req.Header.Set(HeaderSignature, Sign(sub.Secret, now, body))
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("subscriber responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Backoff returns the wait before the next attempt after the given number of
+// failed attempts: 30s, 1m, 2m, ... capped at 6h.
+func Backoff(attempts int) time.Duration {
+	backoff := baseBackoff
+	for i := 1; i < attempts && backoff < maxBackoff; i++ {
+		backoff *= 2
+	}
+	if backoff > maxBackoff {
+		backoff = maxBackoff
+	}
+	return backoff
+}
@@ -0,0 +1,79 @@
+package webhooks
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDispatcher_DeliversSignedRequest(t *testing.T) {
+	var got *http.Request
+	var body []byte
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer receiver.Close()
+
+	store := newFakeStore(models.WebhookSubscription{URL: receiver.URL, Secret: "s3cret", EventTypes: []string{models.WebhookEventCreated}})
+	require.NoError(t, store.Enqueue(models.WebhookEventCreated, []byte(`{"type":"event.created"}`)))
+	d := newDispatcher(store, receiver.Client())
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	require.NoError(t, d.DeliverDue(context.Background()))
+	require.NotNil(t, got)
+	assert.Equal(t, models.WebhookEventCreated, got.Header.Get(HeaderEvent))
+	assert.Equal(t, store.deliveries[0].ID.String(), got.Header.Get(HeaderDelivery))
+	assert.NoError(t, Verify("s3cret", got.Header.Get(HeaderSignature), body, time.Minute, now))
+	assert.Equal(t, models.DeliverySucceeded, store.deliveries[0].Status)
+	assert.Equal(t, 1, store.deliveries[0].Attempts)
+	assert.Equal(t, http.StatusNoContent, store.deliveries[0].ResponseStatus)
+}
+
+func TestDispatcher_RetriesWithBackoffThenGivesUp(t *testing.T) {
+	calls := 0
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer receiver.Close()
+
+	store := newFakeStore(models.WebhookSubscription{URL: receiver.URL, Secret: "s3cret", EventTypes: []string{models.WebhookEventDeleted}})
+	require.NoError(t, store.Enqueue(models.WebhookEventDeleted, []byte(`{}`)))
+	d := newDispatcher(store, receiver.Client())
+	now := time.Now()
+	d.now = func() time.Time { return now }
+
+	require.NoError(t, d.DeliverDue(context.Background()))
+	delivery := store.deliveries[0]
+	assert.Equal(t, models.DeliveryPending, delivery.Status)
+	assert.Equal(t, 1, delivery.Attempts)
+	assert.Equal(t, now.Add(Backoff(1)), delivery.NextAttemptAt)
+	assert.Contains(t, delivery.LastError, "500")
+
+	// not due again until the backoff has passed
+	require.NoError(t, d.DeliverDue(context.Background()))
+	assert.Equal(t, 1, calls)
+
+	for i := 0; i < maxAttempts; i++ {
+		now = now.Add(maxBackoff)
+		require.NoError(t, d.DeliverDue(context.Background()))
+	}
+	assert.Equal(t, maxAttempts, calls)
+	assert.Equal(t, models.DeliveryFailed, store.deliveries[0].Status)
+}
+
+func TestDispatcher_OnlySubscribedTypes(t *testing.T) {
+	store := newFakeStore(models.WebhookSubscription{URL: "http://example.invalid", EventTypes: []string{models.WebhookEventFinalized}})
+	require.NoError(t, store.Enqueue(models.WebhookEventCreated, []byte(`{}`)))
+	assert.Empty(t, store.deliveries)
+}
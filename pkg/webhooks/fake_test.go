@@ -0,0 +1,197 @@
+package webhooks
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+)
+
+// fakeStore is an in-memory webhooks.Store.
+type fakeStore struct {
+	mu         sync.Mutex
+	subs       map[string]*models.WebhookSubscription
+	deliveries []*models.WebhookDelivery
+	snapshots  map[uuid.UUID]models.RecommendationSnapshot
+	// follow keeps Lead from making the caller the leader, and lost is
+	// closed to end its leadership
+	follow bool
+	lost   chan struct{}
+}
+
+func newFakeStore(subs ...models.WebhookSubscription) *fakeStore {
+	s := &fakeStore{subs: map[string]*models.WebhookSubscription{}, snapshots: map[uuid.UUID]models.RecommendationSnapshot{}}
+	for i := range subs {
+		if subs[i].ID == uuid.Nil {
+			subs[i].ID = uuid.New()
+		}
+		s.subs[subs[i].ID.String()] = &subs[i]
+	}
+	return s
+}
+
+func (s *fakeStore) CreateSubscription(sub *models.WebhookSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub.ID = uuid.New()
+	s.subs[sub.ID.String()] = sub
+	return nil
+}
+
+func (s *fakeStore) GetSubscription(id string) (*models.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	sub, ok := s.subs[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	copied := *sub
+	return &copied, nil
+}
+
+func (s *fakeStore) ListSubscriptions() ([]models.WebhookSubscription, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var subs []models.WebhookSubscription
+	for _, sub := range s.subs {
+		subs = append(subs, *sub)
+	}
+	return subs, nil
+}
+
+func (s *fakeStore) DeleteSubscription(id string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.subs, id)
+	return nil
+}
+
+func (s *fakeStore) Enqueue(eventType string, payload []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, sub := range s.subs {
+		if sub.Subscribes(eventType) {
+			s.deliveries = append(s.deliveries, &models.WebhookDelivery{
+				ID:             uuid.New(),
+				SubscriptionID: sub.ID,
+				EventType:      eventType,
+				Payload:        string(payload),
+				Status:         models.DeliveryPending,
+			})
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) ClaimDue(now time.Time, lease time.Duration, limit int) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var due []models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.Status == models.DeliveryPending && !d.NextAttemptAt.After(now) && len(due) < limit {
+			d.NextAttemptAt = now.Add(lease)
+			due = append(due, *d)
+		}
+	}
+	return due, nil
+}
+
+func (s *fakeStore) SaveDelivery(delivery *models.WebhookDelivery) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, d := range s.deliveries {
+		if d.ID == delivery.ID {
+			copied := *delivery
+			s.deliveries[i] = &copied
+		}
+	}
+	return nil
+}
+
+func (s *fakeStore) ListDeliveries(subscriptionID string) ([]models.WebhookDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []models.WebhookDelivery
+	for _, d := range s.deliveries {
+		if d.SubscriptionID.String() == subscriptionID {
+			out = append(out, *d)
+		}
+	}
+	return out, nil
+}
+
+func (s *fakeStore) SwapSnapshot(snapshot models.RecommendationSnapshot) (bool, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	previous, ok := s.snapshots[snapshot.EventID]
+	s.snapshots[snapshot.EventID] = snapshot
+	return !ok || !previous.StartTime.Equal(snapshot.StartTime) || !previous.EndTime.Equal(snapshot.EndTime), nil
+}
+
+// Lead makes the caller the leader unless follow is set. Leadership ends
+// when lost is closed.
+func (s *fakeStore) Lead(ctx context.Context) (<-chan struct{}, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.follow {
+		return nil, nil
+	}
+	s.lost = make(chan struct{})
+	return s.lost, nil
+}
+
+// types lists the event types of the queued deliveries in order.
+func (s *fakeStore) types() []string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var out []string
+	for _, d := range s.deliveries {
+		out = append(out, d.EventType)
+	}
+	return out
+}
+
+// fakeEvents serves canned recommendations for a set of open events, and
+// keeps the events they were computed for.
+type fakeEvents struct {
+	events.Store
+	open            []models.Event
+	recommendations map[string]*models.RecommendedSlot
+	// rosters are the events of each user, and finalized the finalized events
+	rosters   map[string][]string
+	finalized map[string]bool
+
+	mu      sync.Mutex
+	checked []string
+}
+
+func (f *fakeEvents) ListOpen(time.Time) ([]models.Event, error) {
+	return f.open, nil
+}
+
+func (f *fakeEvents) GetRecommendations(id string) (*models.RecommendedSlot, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.checked = append(f.checked, id)
+	rec, ok := f.recommendations[id]
+	if !ok {
+		return nil, models.ErrNotFound
+	}
+	return rec, nil
+}
+
+// affected is the events.Affected of the fake events.
+func (f *fakeEvents) affected(change bus.Change) ([]string, bool, error) {
+	switch {
+	case change.EventID != "":
+		return []string{change.EventID}, f.finalized[change.EventID], nil
+	case change.UserID != "":
+		return f.rosters[change.UserID], false, nil
+	default:
+		return nil, true, nil
+	}
+}
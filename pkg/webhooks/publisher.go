@@ -0,0 +1,267 @@
+// Package webhooks publishes lifecycle events to subscribed URLs through a
+// persistent outbox, and delivers them with signed, retried HTTP requests.
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jinzhu/gorm"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/rsys-speerzad/stackgen/pkg/store/events"
+	"github.com/rsys-speerzad/stackgen/pkg/store/webhooks"
+)
+
+// Publisher announces lifecycle events to webhook subscribers.
+type Publisher interface {
+	Publish(eventType string, data interface{})
+}
+
+// Discard is a Publisher that drops every event.
+var Discard Publisher = discard{}
+
+type discard struct{}
+
+func (discard) Publish(string, interface{}) {}
+
+// AvailabilityChange is the data of availability.submitted and availability.deleted events.
+type AvailabilityChange struct {
+	UserID string        `json:"user_id"`
+	Slots  []models.Slot `json:"slots,omitempty"`
+}
+
+// RecommendationChange is the data of recommendation.changed events.
+type RecommendationChange struct {
+	EventID        string                  `json:"event_id"`
+	Recommendation *models.RecommendedSlot `json:"recommendation"`
+}
+
+// maxPending bounds the changes queued for the recommendation check; past
+// it, every open event is checked instead.
+const maxPending = 1000
+
+// leadRetry is how often a replica that doesn't check recommendations tries
+// to take over.
+const leadRetry = 30 * time.Second
+
+// Service writes published events to the outbox. While watching the change
+// bus, it also publishes recommendation.changed for the open events whose
+// best slot changes move. When every replica sees every change, one of them
+// checks recommendations at a time, and the others stand by.
+type Service struct {
+	store    webhooks.Store
+	events   events.Store
+	affected events.Affected
+	now      func() time.Time
+	// shared tells whether every replica sees the changes on a bus
+	shared    func(bus.Bus) bool
+	leadRetry time.Duration
+
+	mu sync.Mutex
+	// pending are the changes not yet checked, or nil with everything set
+	// once they overflowed
+	pending    []bus.Change
+	everything bool
+	wake       chan struct{}
+}
+
+func NewService(db *gorm.DB) *Service {
+	return newService(webhooks.NewStore(db), events.NewStore(db), events.NewAffected(db))
+}
+
+func newService(store webhooks.Store, events events.Store, affected events.Affected) *Service {
+	return &Service{
+		store:    store,
+		events:   events,
+		affected: affected,
+		now:      time.Now,
+		shared: func(changes bus.Bus) bool {
+			_, ok := changes.(*bus.Postgres)
+			return ok
+		},
+		leadRetry: leadRetry,
+		wake:      make(chan struct{}, 1),
+	}
+}
+
+// Publish enqueues eventType for every interested subscriber. Failures are
+// logged rather than returned: the change that triggered the event has
+// already been committed.
+func (s *Service) Publish(eventType string, data interface{}) {
+	s.enqueue(eventType, data)
+}
+
+// Watch checks the open events each change on changes may affect for a new
+// best slot, one batch of changes at a time, until ctx is done. If changes
+// are shared, only while this replica leads.
+func (s *Service) Watch(ctx context.Context, changes bus.Bus) {
+	stop := changes.Listen(s.queue)
+	defer stop()
+	shared := s.shared(changes)
+	var lost <-chan struct{} // closed when this replica stops leading, nil while it doesn't
+	if shared {
+		lost = s.lead(ctx, false)
+	}
+	retry := time.NewTicker(s.leadRetry)
+	defer retry.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-lost:
+			lost = nil
+		case <-retry.C:
+			if shared && lost == nil {
+				lost = s.lead(ctx, true)
+			}
+		case <-s.wake:
+			select {
+			case <-lost:
+				lost = nil
+			default:
+			}
+			if !shared || lost != nil {
+				s.checkPending()
+			} else {
+				s.dropPending()
+			}
+		}
+	}
+}
+
+// lead tries to make this replica the one that checks recommendations,
+// returning the channel closed when it stops being the one, or nil if
+// another replica is. Taking over from another, it checks every open event
+// once, for the changes that replica may have left unchecked.
+func (s *Service) lead(ctx context.Context, takeover bool) <-chan struct{} {
+	lost, err := s.store.Lead(ctx)
+	if err != nil {
+		log.Printf("webhooks: leading recommendation checks: %v", err)
+		return nil
+	}
+	if lost != nil && takeover {
+		s.mu.Lock()
+		s.pending, s.everything = nil, true
+		s.mu.Unlock()
+		select {
+		case s.wake <- struct{}{}:
+		default:
+		}
+	}
+	return lost
+}
+
+// dropPending forgets the pending changes, which the leading replica checks.
+func (s *Service) dropPending() {
+	s.mu.Lock()
+	s.pending, s.everything = nil, false
+	s.mu.Unlock()
+}
+
+// queue adds change to those to check, waking the worker.
+func (s *Service) queue(change bus.Change) {
+	s.mu.Lock()
+	if len(s.pending) < maxPending && !s.everything {
+		s.pending = append(s.pending, change)
+	} else {
+		s.pending, s.everything = nil, true
+	}
+	s.mu.Unlock()
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (s *Service) enqueue(eventType string, data interface{}) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		log.Printf("webhooks: encoding %s data: %v", eventType, err)
+		return
+	}
+	payload, err := json.Marshal(models.WebhookEvent{
+		ID:        uuid.New(),
+		Type:      eventType,
+		CreatedAt: s.now().UTC(),
+		Data:      raw,
+	})
+	if err != nil {
+		log.Printf("webhooks: encoding %s: %v", eventType, err)
+		return
+	}
+	if err := s.store.Enqueue(eventType, payload); err != nil {
+		log.Printf("webhooks: enqueueing %s: %v", eventType, err)
+	}
+}
+
+// checkPending looks for best slot changes in the events the pending changes
+// may affect: those changed themselves, and the open events among the rest.
+func (s *Service) checkPending() {
+	s.mu.Lock()
+	pending, everything := s.pending, s.everything
+	s.pending, s.everything = nil, false
+	s.mu.Unlock()
+	changed, affected := map[string]bool{}, map[string]bool{}
+	for _, change := range pending {
+		ids, all, err := s.affected(change)
+		if err != nil {
+			log.Printf("webhooks: events affected by %s change: %v", change.Kind, err)
+		}
+		if err != nil || all {
+			everything = true
+			break
+		}
+		if change.EventID != "" {
+			changed[change.EventID] = true
+			continue
+		}
+		for _, id := range ids {
+			affected[id] = true
+		}
+	}
+	if !everything {
+		for id := range changed {
+			s.checkRecommendation(id)
+		}
+		if len(affected) == 0 {
+			return
+		}
+	}
+	open, err := s.events.ListOpen(s.now())
+	if err != nil {
+		log.Printf("webhooks: listing open events: %v", err)
+		return
+	}
+	for _, event := range open {
+		if id := event.ID.String(); everything || affected[id] && !changed[id] {
+			s.checkRecommendation(id)
+		}
+	}
+}
+
+// checkRecommendation publishes recommendation.changed when the best slot of
+// the event differs from the last one announced.
+func (s *Service) checkRecommendation(eventID string) {
+	rec, err := s.events.GetRecommendations(eventID)
+	if err != nil {
+		log.Printf("webhooks: recommendations for event %s: %v", eventID, err)
+		return
+	}
+	id, err := uuid.Parse(eventID)
+	if err != nil {
+		return
+	}
+	changed, err := s.store.SwapSnapshot(models.RecommendationSnapshot{EventID: id, StartTime: rec.StartTime, EndTime: rec.EndTime})
+	if err != nil {
+		log.Printf("webhooks: snapshot for event %s: %v", eventID, err)
+		return
+	}
+	if changed {
+		s.enqueue(models.WebhookRecommendationsChanged, RecommendationChange{EventID: eventID, Recommendation: rec})
+	}
+}
@@ -0,0 +1,172 @@
+package webhooks
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/rsys-speerzad/stackgen/pkg/bus"
+	"github.com/rsys-speerzad/stackgen/pkg/models"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestService(events *fakeEvents) (*Service, *fakeStore) {
+	store := newFakeStore(models.WebhookSubscription{
+		URL: "https://example.com/hook",
+		EventTypes: []string{
+			models.WebhookEventCreated,
+			models.WebhookAvailabilitySubmitted,
+			models.WebhookRecommendationsChanged,
+		},
+	})
+	return newService(store, events, events.affected), store
+}
+
+func TestService_PublishWritesEnvelope(t *testing.T) {
+	s, store := newTestService(&fakeEvents{})
+	s.Publish(models.WebhookAvailabilitySubmitted, AvailabilityChange{UserID: "u1"})
+
+	require.Len(t, store.deliveries, 1)
+	var envelope models.WebhookEvent
+	require.NoError(t, json.Unmarshal([]byte(store.deliveries[0].Payload), &envelope))
+	assert.Equal(t, models.WebhookAvailabilitySubmitted, envelope.Type)
+	assert.NotEqual(t, uuid.Nil, envelope.ID)
+	assert.JSONEq(t, `{"user_id":"u1"}`, string(envelope.Data))
+}
+
+func TestService_RecommendationChangedOnlyWhenBestSlotMoves(t *testing.T) {
+	event, other := models.Event{ID: uuid.New(), Title: "Sync"}, models.Event{ID: uuid.New(), Title: "Retro"}
+	start := time.Date(2025, 1, 12, 14, 0, 0, 0, time.UTC)
+	events := &fakeEvents{
+		open: []models.Event{event, other},
+		recommendations: map[string]*models.RecommendedSlot{
+			event.ID.String(): {StartTime: start, EndTime: start.Add(time.Hour)},
+			other.ID.String(): {StartTime: start, EndTime: start.Add(time.Hour)},
+		},
+		rosters: map[string][]string{"u1": {event.ID.String()}},
+	}
+	s, store := newTestService(events)
+	check := func(change bus.Change) {
+		s.queue(change)
+		s.checkPending()
+	}
+
+	check(bus.Change{Kind: bus.EventChanged, EventID: event.ID.String()})
+	assert.Equal(t, []string{models.WebhookRecommendationsChanged}, store.types())
+	assert.Equal(t, []string{event.ID.String()}, events.checked, "only the changed event is checked")
+
+	// same best slot: nothing new to announce
+	check(bus.Change{Kind: bus.AvailabilityChanged, UserID: "u1"})
+	assert.Len(t, store.types(), 1)
+
+	// u2 is on no event's roster
+	events.recommendations[event.ID.String()] = &models.RecommendedSlot{StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour)}
+	events.checked = nil
+	check(bus.Change{Kind: bus.AvailabilityChanged, UserID: "u2"})
+	assert.Empty(t, events.checked)
+	check(bus.Change{Kind: bus.AvailabilityChanged, UserID: "u1"})
+	assert.Equal(t, []string{event.ID.String()}, events.checked)
+	assert.Equal(t, []string{models.WebhookRecommendationsChanged, models.WebhookRecommendationsChanged}, store.types())
+}
+
+func TestService_ChecksEveryOpenEventWhenAnyMayMove(t *testing.T) {
+	event, other := models.Event{ID: uuid.New()}, models.Event{ID: uuid.New()}
+	events := &fakeEvents{open: []models.Event{event, other}, recommendations: map[string]*models.RecommendedSlot{}}
+	s, _ := newTestService(events)
+
+	// a finalized event can free or take up anyone's time
+	events.finalized = map[string]bool{"final": true}
+	s.queue(bus.Change{Kind: bus.EventChanged, EventID: "final"})
+	s.checkPending()
+	assert.ElementsMatch(t, []string{event.ID.String(), other.ID.String()}, events.checked)
+
+	// so does a backlog too long to look through
+	events.checked = nil
+	for i := 0; i <= maxPending; i++ {
+		s.queue(bus.Change{Kind: bus.AvailabilityChanged, UserID: "u2"})
+	}
+	s.checkPending()
+	assert.ElementsMatch(t, []string{event.ID.String(), other.ID.String()}, events.checked)
+}
+
+func TestService_WatchChecksOnChanges(t *testing.T) {
+	event := models.Event{ID: uuid.New()}
+	start := time.Date(2025, 1, 12, 14, 0, 0, 0, time.UTC)
+	events := &fakeEvents{
+		open:            []models.Event{event},
+		recommendations: map[string]*models.RecommendedSlot{event.ID.String(): {StartTime: start, EndTime: start.Add(time.Hour)}},
+	}
+	s, store := newTestService(events)
+	changes := bus.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Watch(ctx, changes)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+
+	assert.Eventually(t, func() bool {
+		// the worker may not be listening yet
+		changes.Publish(bus.Change{Kind: bus.EventChanged, EventID: event.ID.String()})
+		return len(store.types()) == 1
+	}, time.Second, 10*time.Millisecond)
+}
+
+func TestService_WatchChecksOnlyWhileLeading(t *testing.T) {
+	event := models.Event{ID: uuid.New()}
+	start := time.Date(2025, 1, 12, 14, 0, 0, 0, time.UTC)
+	events := &fakeEvents{
+		open:            []models.Event{event},
+		recommendations: map[string]*models.RecommendedSlot{event.ID.String(): {StartTime: start, EndTime: start.Add(time.Hour)}},
+	}
+	s, store := newTestService(events)
+	s.shared = func(bus.Bus) bool { return true }
+	s.leadRetry = 10 * time.Millisecond
+	store.follow = true
+	changes := bus.NewMemory()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Watch(ctx, changes)
+		close(done)
+	}()
+	defer func() {
+		cancel()
+		<-done
+	}()
+	change := bus.Change{Kind: bus.EventChanged, EventID: event.ID.String()}
+
+	// another replica leads
+	assert.Never(t, func() bool {
+		changes.Publish(change)
+		return len(store.types()) > 0
+	}, 100*time.Millisecond, 10*time.Millisecond)
+
+	// taking over, every open event is checked, for the changes missed
+	store.mu.Lock()
+	store.follow = false
+	store.mu.Unlock()
+	assert.Eventually(t, func() bool {
+		return len(store.types()) == 1
+	}, time.Second, 10*time.Millisecond)
+
+	// having lost the lead, changes are left to the new leader
+	store.mu.Lock()
+	store.follow = true
+	close(store.lost)
+	store.mu.Unlock()
+	events.mu.Lock()
+	events.recommendations[event.ID.String()] = &models.RecommendedSlot{StartTime: start.Add(time.Hour), EndTime: start.Add(2 * time.Hour)}
+	events.mu.Unlock()
+	assert.Never(t, func() bool {
+		changes.Publish(change)
+		return len(store.types()) > 1
+	}, 100*time.Millisecond, 10*time.Millisecond)
+}
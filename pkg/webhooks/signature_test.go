@@ -0,0 +1,50 @@
+package webhooks
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSignVerify(t *testing.T) {
+	now := time.Unix(1736700000, 0)
+	body := []byte(`{"type":"event.created"}`)
+	header := Sign("s3cret", now, body)
+	assert.Regexp(t, `^t=1736700000,v1=[0-9a-f]{64}$`, header)
+
+	assert.NoError(t, Verify("s3cret", header, body, 5*time.Minute, now.Add(time.Minute)))
+
+	tests := []struct {
+		name   string
+		secret string
+		header string
+		body   string
+		now    time.Time
+	}{
+		{"wrong secret", "other", header, string(body), now},
+		{"tampered body", "s3cret", header, `{"type":"event.deleted"}`, now},
+		{"too old", "s3cret", header, string(body), now.Add(10 * time.Minute)},
+		{"malformed", "s3cret", "garbage", string(body), now},
+	}
+	for _, tt := range tests {
+		err := Verify(tt.secret, tt.header, []byte(tt.body), 5*time.Minute, tt.now)
+		assert.True(t, errors.Is(err, ErrInvalidSignature), tt.name)
+	}
+}
+
+func TestNewSecret(t *testing.T) {
+	a, err := NewSecret()
+	assert.NoError(t, err)
+	b, _ := NewSecret()
+	assert.Regexp(t, `^whsec_[0-9a-f]{64}$`, a)
+	assert.NotEqual(t, a, b)
+}
+
+func TestBackoff(t *testing.T) {
+	assert.Equal(t, 30*time.Second, Backoff(1))
+	assert.Equal(t, time.Minute, Backoff(2))
+	assert.Equal(t, 4*time.Minute, Backoff(4))
+	assert.Equal(t, 6*time.Hour, Backoff(20))
+}